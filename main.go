@@ -10,7 +10,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/iknowhtml/locationtracker/pkg/common"
 	"github.com/iknowhtml/locationtracker/pkg/config"
 	"github.com/iknowhtml/locationtracker/pkg/message"
@@ -23,6 +22,7 @@ var (
 	mode      = flag.String("m", "http", "mode: client or udp or http")
 	sentcount = flag.String("c", "1", "sent count: (positive number, only use for UDP client)")
 	cid       = flag.String("cid", "1", "client ID: (positive number, only use for socket client)")
+	version   = flag.String("v", "1", "message version: (1 or 2, only use for UDP and socket client)")
 	env       = flag.String("e", string(common.EnvType_Dev), "server environment: (dev or prod)")
 )
 
@@ -39,7 +39,8 @@ func main() {
 	}
 
 	var c_wg, s_wg sync.WaitGroup
-	ch := make(chan message.DriverStatusPoll)
+	// buffer a batch of packets so reading UDP is not blocked while a batch is flushed
+	ch := make(chan message.DriverStatusPollV2, configuration.Udpserver.BatchSize)
 
 	stop := make(chan os.Signal)
 	signal.Notify(stop, os.Interrupt)
@@ -59,6 +60,9 @@ func main() {
 			Wg:         &s_wg}
 		server := terminal.NewServer(hsh)
 
+		// start a separate thread for background jobs, such as setting stale drivers unreachable
+		go server.Process()
+
 		go server.Run()
 
 		// wait for all goroutines to finished
@@ -83,6 +87,9 @@ func main() {
 			Wg:   &s_wg}
 		server := terminal.NewServer(ssh)
 
+		// start a separate thread to consume the fence events and push them to the subscribers
+		go server.Process()
+
 		go server.Run()
 
 		// wait for all goroutines to finished
@@ -102,7 +109,12 @@ func main() {
 
 		//server := new(terminal.UDPServer)
 		//server.Init(*port, &s_wg, ch)
-		ush := &terminal.UDPServer{Addr: configuration.Udpserver.Addr, Wg: &s_wg, Ch: ch}
+		ush := &terminal.UDPServer{
+			Addr:        configuration.Udpserver.Addr,
+			BatchSize:   int(configuration.Udpserver.BatchSize),
+			BatchWindow: time.Duration(configuration.Udpserver.BatchWindowMs) * time.Millisecond,
+			Wg:          &s_wg,
+			Ch:          ch}
 		server := terminal.NewServer(ush)
 
 		// start a separate data processing thread first to read data from channel (to prevent a blocking channel)
@@ -124,7 +136,8 @@ func main() {
 	case "udpclient":
 		// testing client to send data to UDP Server
 		client := new(terminal.UDPClient)
-		client.Init(configuration.Udpserver.Addr, &c_wg)
+		v, _ := strconv.Atoi(*version)
+		client.Init(configuration.Udpserver.Addr, &c_wg, int32(v))
 
 		i, _ := strconv.Atoi(*sentcount)
 		client.Run(i)
@@ -135,7 +148,7 @@ func main() {
 		s_wg.Add(2)
 
 		//u := url.URL{Scheme: "ws", Host: configuration.Socketserver.Addr, Path: "/socket/handle"}
-		u := url.URL{Scheme: "ws", Host: configuration.Socketserver.Addr, Path: "/socket/driver/" + *cid + "/status"}
+		u := url.URL{Scheme: "ws", Host: configuration.Socketserver.Addr, Path: "/socket/driver/" + *cid + "/status", RawQuery: "version=" + *version}
 		log.Printf("connecting to %s", u.String())
 
 		c, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
@@ -179,8 +192,8 @@ func main() {
 				case websocket.BinaryMessage:
 					// BinaryMessage denotes a binary data message.
 					log.Printf("Binary Message Received: Size: %d\n", len(msg))
-					data := &message.DriverStatusPoll{}
-					if err := proto.Unmarshal(msg, data); err != nil {
+					data, err := message.UnmarshalDriverStatusPoll(msg)
+					if err != nil {
 						log.Printf("Error decoding binary message: %v\n", err)
 					} else {
 						log.Printf("Decoded Message: (%d) Driver# %d currently at (%f, %f)\n", len(msg), data.DriverId, data.Lat, data.Lng)
@@ -0,0 +1,62 @@
+package common
+
+import "math"
+
+// BoundingBox is the box of the points within the latitudes and the longitudes.
+// A box across the antimeridian has MinLng greater than MaxLng
+type BoundingBox struct {
+	MinLat float64 `json:"minlat"`
+	MinLng float64 `json:"minlng"`
+	MaxLat float64 `json:"maxlat"`
+	MaxLng float64 `json:"maxlng"`
+}
+
+// BoundingBoxFromRadius returns the smallest box of the points within the radius in meters around the center.
+// A circle reaching a pole spans all longitudes
+func BoundingBoxFromRadius(center GeoPoint, radius float64) BoundingBox {
+	d := radius / Earth_Radius_Meter
+	lat := center.Lat * math.Pi / 180
+
+	minLat := lat - d
+	maxLat := lat + d
+	if minLat <= -math.Pi/2 || maxLat >= math.Pi/2 {
+		return BoundingBox{
+			MinLat: math.Max(minLat*180/math.Pi, -90),
+			MinLng: -180,
+			MaxLat: math.Min(maxLat*180/math.Pi, 90),
+			MaxLng: 180,
+		}
+	}
+
+	dLng := math.Asin(math.Sin(d)/math.Cos(lat)) * 180 / math.Pi
+	if center.Lng-dLng <= -180 && center.Lng+dLng >= 180 {
+		return BoundingBox{MinLat: minLat * 180 / math.Pi, MinLng: -180, MaxLat: maxLat * 180 / math.Pi, MaxLng: 180}
+	}
+
+	// wrapped across the antimeridian
+	minLng := center.Lng - dLng
+	if minLng < -180 {
+		minLng += 360
+	}
+	maxLng := center.Lng + dLng
+	if maxLng > 180 {
+		maxLng -= 360
+	}
+	return BoundingBox{MinLat: minLat * 180 / math.Pi, MinLng: minLng, MaxLat: maxLat * 180 / math.Pi, MaxLng: maxLng}
+}
+
+// Contains returns true when the point is within the box, the edges included
+func (b BoundingBox) Contains(p GeoPoint) bool {
+	if p.Lat < b.MinLat || p.Lat > b.MaxLat {
+		return false
+	}
+	if b.MinLng > b.MaxLng {
+		return p.Lng >= b.MinLng || p.Lng <= b.MaxLng
+	}
+	return p.Lng >= b.MinLng && p.Lng <= b.MaxLng
+}
+
+// CrossesAntimeridian returns true when the box spans the longitude 180
+func (b BoundingBox) CrossesAntimeridian() bool {
+	return b.MinLng > b.MaxLng
+}
@@ -0,0 +1,74 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBoundingBoxFromRadius(t *testing.T) {
+	// one degree of arc on the earth
+	degree := Earth_Radius_Meter * math.Pi / 180
+
+	tests := []struct {
+		name   string
+		center GeoPoint
+		radius float64
+		want   BoundingBox
+		cross  bool
+	}{
+		{"equator", GeoPoint{Lat: 0, Lng: 10}, degree, BoundingBox{MinLat: -1, MinLng: 9, MaxLat: 1, MaxLng: 11}, false},
+		{"prime meridian", GeoPoint{Lat: 0, Lng: 0.5}, degree, BoundingBox{MinLat: -1, MinLng: -0.5, MaxLat: 1, MaxLng: 1.5}, false},
+		{"zero radius", GeoPoint{Lat: 3, Lng: 101}, 0, BoundingBox{MinLat: 3, MinLng: 101, MaxLat: 3, MaxLng: 101}, false},
+		{"across the antimeridian east", GeoPoint{Lat: 0, Lng: 179.5}, degree, BoundingBox{MinLat: -1, MinLng: 178.5, MaxLat: 1, MaxLng: -179.5}, true},
+		{"across the antimeridian west", GeoPoint{Lat: 0, Lng: -179.5}, degree, BoundingBox{MinLat: -1, MinLng: 179.5, MaxLat: 1, MaxLng: -178.5}, true},
+		{"north pole clamp", GeoPoint{Lat: 89.5, Lng: 10}, degree, BoundingBox{MinLat: 88.5, MinLng: -180, MaxLat: 90, MaxLng: 180}, false},
+		{"south pole clamp", GeoPoint{Lat: -89.5, Lng: 10}, degree, BoundingBox{MinLat: -90, MinLng: -180, MaxLat: -88.5, MaxLng: 180}, false},
+		{"all longitudes", GeoPoint{Lat: 0, Lng: 0}, 200 * degree, BoundingBox{MinLat: -90, MinLng: -180, MaxLat: 90, MaxLng: 180}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BoundingBoxFromRadius(tt.center, tt.radius)
+			if math.Abs(got.MinLat-tt.want.MinLat) > 1e-9 || math.Abs(got.MinLng-tt.want.MinLng) > 1e-9 ||
+				math.Abs(got.MaxLat-tt.want.MaxLat) > 1e-9 || math.Abs(got.MaxLng-tt.want.MaxLng) > 1e-9 {
+				t.Errorf("BoundingBoxFromRadius(%v, %v) = %+v, want %+v", tt.center, tt.radius, got, tt.want)
+			}
+			if got.CrossesAntimeridian() != tt.cross {
+				t.Errorf("CrossesAntimeridian() of %+v = %v, want %v", got, got.CrossesAntimeridian(), tt.cross)
+			}
+			if !got.Contains(tt.center) {
+				t.Errorf("BoundingBoxFromRadius(%v, %v) = %+v does not contain the center", tt.center, tt.radius, got)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxContains(t *testing.T) {
+	box := BoundingBox{MinLat: -1, MinLng: 9, MaxLat: 1, MaxLng: 11}
+	crossing := BoundingBox{MinLat: -1, MinLng: 178.5, MaxLat: 1, MaxLng: -179.5}
+
+	tests := []struct {
+		name string
+		b    BoundingBox
+		p    GeoPoint
+		want bool
+	}{
+		{"inside", box, GeoPoint{Lat: 0.5, Lng: 10}, true},
+		{"on the edge", box, GeoPoint{Lat: 1, Lng: 11}, true},
+		{"north of", box, GeoPoint{Lat: 1.1, Lng: 10}, false},
+		{"west of", box, GeoPoint{Lat: 0, Lng: 8.9}, false},
+		{"crossing east side", crossing, GeoPoint{Lat: 0, Lng: 179.9}, true},
+		{"crossing west side", crossing, GeoPoint{Lat: 0, Lng: -179.9}, true},
+		{"crossing antimeridian", crossing, GeoPoint{Lat: 0, Lng: 180}, true},
+		{"crossing outside", crossing, GeoPoint{Lat: 0, Lng: 0}, false},
+		{"crossing south of", crossing, GeoPoint{Lat: -1.1, Lng: 179.9}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.b.Contains(tt.p); got != tt.want {
+				t.Errorf("Contains(%v) of %+v = %v, want %v", tt.p, tt.b, got, tt.want)
+			}
+		})
+	}
+}
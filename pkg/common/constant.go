@@ -7,3 +7,6 @@ const (
 	EnvType_Dev  EnvType = "dev"
 	EnvType_Prod EnvType = "prod"
 )
+
+// mean radius of the earth, as the distances of the geo store (Tile38)
+const Earth_Radius_Meter float64 = 6371000
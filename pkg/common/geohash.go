@@ -0,0 +1,127 @@
+package common
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrGeohashInvalid   = errors.New("Geohash is invalid")
+	ErrGeohashPrecision = errors.New("Geohash precision must be from 1 to 12")
+)
+
+// base32 alphabet of the geohash, without a, i, l and o
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+const Geohash_Max_Precision = 12
+
+// EncodeGeohash returns the geohash of the point with the precision in characters, from 1 to 12.
+// 5 characters is a cell of about 4.9 x 4.9 km, 7 of about 153 x 153 m at the equator
+func EncodeGeohash(p GeoPoint, precision int) (string, error) {
+	if precision < 1 || precision > Geohash_Max_Precision {
+		return "", ErrGeohashPrecision
+	}
+
+	minLat, maxLat := -90.0, 90.0
+	minLng, maxLng := -180.0, 180.0
+	hash := make([]byte, 0, precision)
+	even := true // bits alternate from longitude
+	bit, ch := 0, 0
+	for len(hash) < precision {
+		if even {
+			mid := (minLng + maxLng) / 2
+			if p.Lng >= mid {
+				ch |= 1 << uint(4-bit)
+				minLng = mid
+			} else {
+				maxLng = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if p.Lat >= mid {
+				ch |= 1 << uint(4-bit)
+				minLat = mid
+			} else {
+				maxLat = mid
+			}
+		}
+		even = !even
+
+		if bit < 4 {
+			bit++
+			continue
+		}
+		hash = append(hash, geohashBase32[ch])
+		bit, ch = 0, 0
+	}
+	return string(hash), nil
+}
+
+// DecodeGeohash returns the center and the bounds of the cell of the geohash
+func DecodeGeohash(hash string) (GeoPoint, BoundingBox, error) {
+	if hash == "" || len(hash) > Geohash_Max_Precision {
+		return GeoPoint{}, BoundingBox{}, ErrGeohashInvalid
+	}
+
+	b := BoundingBox{MinLat: -90, MinLng: -180, MaxLat: 90, MaxLng: 180}
+	even := true
+	for i := 0; i < len(hash); i++ {
+		ch := strings.IndexByte(geohashBase32, lowerByte(hash[i]))
+		if ch < 0 {
+			return GeoPoint{}, BoundingBox{}, ErrGeohashInvalid
+		}
+		for bit := 4; bit >= 0; bit-- {
+			set := ch&(1<<uint(bit)) != 0
+			if even {
+				mid := (b.MinLng + b.MaxLng) / 2
+				if set {
+					b.MinLng = mid
+				} else {
+					b.MaxLng = mid
+				}
+			} else {
+				mid := (b.MinLat + b.MaxLat) / 2
+				if set {
+					b.MinLat = mid
+				} else {
+					b.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+
+	center := GeoPoint{Lat: (b.MinLat + b.MaxLat) / 2, Lng: (b.MinLng + b.MaxLng) / 2}
+	return center, b, nil
+}
+
+// GeohashNeighbors returns the geohashes of the 8 cells around the cell, in the order
+// N, NE, E, SE, S, SW, W, NW. Longitudes wrap across the antimeridian,
+// the cells beyond a pole do not exist and are returned empty
+func GeohashNeighbors(hash string) ([]string, error) {
+	center, b, err := DecodeGeohash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	height := b.MaxLat - b.MinLat
+	width := b.MaxLng - b.MinLng
+	offsets := [8][2]float64{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	neighbors := make([]string, len(offsets))
+	for i, o := range offsets {
+		lat := center.Lat + o[0]*height
+		if lat > 90 || lat < -90 {
+			continue
+		}
+		lng := normalizeLng(center.Lng + o[1]*width)
+		neighbors[i], _ = EncodeGeohash(GeoPoint{Lat: lat, Lng: lng}, len(hash))
+	}
+	return neighbors, nil
+}
+
+func lowerByte(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
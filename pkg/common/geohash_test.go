@@ -0,0 +1,157 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEncodeGeohash(t *testing.T) {
+	tests := []struct {
+		name      string
+		p         GeoPoint
+		precision int
+		want      string
+		err       error
+	}{
+		{"reference", GeoPoint{Lat: 57.64911, Lng: 10.40744}, 11, "u4pruydqqvj", nil},
+		{"reference short", GeoPoint{Lat: 42.605, Lng: -5.603}, 5, "ezs42", nil},
+		{"kuala lumpur", GeoPoint{Lat: 3.1390, Lng: 101.6869}, 7, "w283cgq", nil},
+		{"single character", GeoPoint{Lat: 3.1390, Lng: 101.6869}, 1, "w", nil},
+		{"north east corner", GeoPoint{Lat: 90, Lng: 180}, 4, "zzzz", nil},
+		{"south west corner", GeoPoint{Lat: -90, Lng: -180}, 4, "0000", nil},
+		{"precision zero", GeoPoint{Lat: 3.1390, Lng: 101.6869}, 0, "", ErrGeohashPrecision},
+		{"precision above max", GeoPoint{Lat: 3.1390, Lng: 101.6869}, Geohash_Max_Precision + 1, "", ErrGeohashPrecision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeGeohash(tt.p, tt.precision)
+			if err != tt.err {
+				t.Fatalf("EncodeGeohash(%v, %v) error = %v, want %v", tt.p, tt.precision, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("EncodeGeohash(%v, %v) = %q, want %q", tt.p, tt.precision, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeGeohash(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+		want GeoPoint
+		err  error
+	}{
+		{"reference", "ezs42", GeoPoint{Lat: 42.60498046875, Lng: -5.60302734375}, nil},
+		{"upper case", "EZS42", GeoPoint{Lat: 42.60498046875, Lng: -5.60302734375}, nil},
+		{"single character", "s", GeoPoint{Lat: 22.5, Lng: 22.5}, nil},
+		{"empty", "", GeoPoint{}, ErrGeohashInvalid},
+		{"too long", "ezs42ezs42ezs", GeoPoint{}, ErrGeohashInvalid},
+		{"invalid character", "ezs4a", GeoPoint{}, ErrGeohashInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, b, err := DecodeGeohash(tt.hash)
+			if err != tt.err {
+				t.Fatalf("DecodeGeohash(%q) error = %v, want %v", tt.hash, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeGeohash(%q) = %v, want %v", tt.hash, got, tt.want)
+			}
+			if err == nil && !b.Contains(got) {
+				t.Errorf("DecodeGeohash(%q) bounds %v do not contain the center %v", tt.hash, b, got)
+			}
+		})
+	}
+}
+
+func TestGeohashRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		p         GeoPoint
+		precision int
+	}{
+		{"kuala lumpur", GeoPoint{Lat: 3.1390, Lng: 101.6869}, 7},
+		{"southern western hemisphere", GeoPoint{Lat: -34.6037, Lng: -58.3816}, 9},
+		{"equator", GeoPoint{Lat: 0, Lng: 32.5825}, 5},
+		{"prime meridian", GeoPoint{Lat: 51.4779, Lng: 0}, 12},
+		{"antimeridian", GeoPoint{Lat: -16.5, Lng: 179.9999}, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := EncodeGeohash(tt.p, tt.precision)
+			if err != nil {
+				t.Fatalf("EncodeGeohash(%v, %v) error = %v", tt.p, tt.precision, err)
+			}
+			center, b, err := DecodeGeohash(hash)
+			if err != nil {
+				t.Fatalf("DecodeGeohash(%q) error = %v", hash, err)
+			}
+			if !b.Contains(tt.p) {
+				t.Errorf("DecodeGeohash(%q) bounds %v do not contain %v", hash, b, tt.p)
+			}
+			if got, _ := EncodeGeohash(center, tt.precision); got != hash {
+				t.Errorf("EncodeGeohash() of the center %v = %q, want %q", center, got, hash)
+			}
+		})
+	}
+}
+
+func TestGeohashNeighbors(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+		want []string // N, NE, E, SE, S, SW, W, NW
+		err  error
+	}{
+		{"reference", "gbsuv", []string{"gbsvj", "gbsvn", "gbsuy", "gbsuw", "gbsut", "gbsus", "gbsuu", "gbsvh"}, nil},
+		{"across the antimeridian", "xbpb", []string{"xbpc", "8001", "8000", "2pbp", "rzzz", "rzzx", "xbp8", "xbp9"}, nil},
+		{"north pole", "zzzz", []string{"", "", "bpbp", "bpbn", "zzzy", "zzzw", "zzzx", ""}, nil},
+		{"south pole", "0000", []string{"0001", "0003", "0002", "", "", "", "pbpb", "pbpc"}, nil},
+		{"invalid", "ezs4a", nil, ErrGeohashInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GeohashNeighbors(tt.hash)
+			if err != tt.err {
+				t.Fatalf("GeohashNeighbors(%q) error = %v, want %v", tt.hash, err, tt.err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("GeohashNeighbors(%q) = %v, want %v", tt.hash, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("GeohashNeighbors(%q) = %v, want %v", tt.hash, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestGeohashNeighborsAdjacent(t *testing.T) {
+	hash := "w283cgq"
+	center, b, _ := DecodeGeohash(hash)
+	neighbors, err := GeohashNeighbors(hash)
+	if err != nil {
+		t.Fatalf("GeohashNeighbors(%q) error = %v", hash, err)
+	}
+
+	height := b.MaxLat - b.MinLat
+	width := b.MaxLng - b.MinLng
+	for _, n := range neighbors {
+		c, _, err := DecodeGeohash(n)
+		if err != nil {
+			t.Fatalf("DecodeGeohash(%q) error = %v", n, err)
+		}
+		dLat := math.Abs(c.Lat-center.Lat) / height
+		dLng := math.Abs(c.Lng-center.Lng) / width
+		if math.Round(dLat) > 1 || math.Round(dLng) > 1 || (math.Round(dLat) == 0 && math.Round(dLng) == 0) {
+			t.Errorf("neighbor %q of %q is not adjacent", n, hash)
+		}
+	}
+}
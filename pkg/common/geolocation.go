@@ -25,7 +25,7 @@ func Distance(lat1, lon1, lat2, lon2 float64) float64 {
 	la2 = lat2 * math.Pi / 180
 	lo2 = lon2 * math.Pi / 180
 
-	r = 6378100 // Earth radius in METERS
+	r = Earth_Radius_Meter // Earth radius in METERS
 
 	// calculate
 	h := hsin(la2-la1) + math.Cos(la1)*math.Cos(la2)*hsin(lo2-lo1)
@@ -0,0 +1,122 @@
+package common
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+var (
+	ErrGeoPointNaN         = errors.New("Location is not a number")
+	ErrGeoPointLatRange    = errors.New("Location (Latitude) is out of range")
+	ErrGeoPointLngRange    = errors.New("Location (Longitude) is out of range")
+	ErrGeoPointNullIsland  = errors.New("Location (0, 0) is not accepted, the location is missing")
+	ErrGeoPointLatInvalid  = errors.New("Location (Latitude) is invalid")
+	ErrGeoPointLngInvalid  = errors.New("Location (Longitude) is invalid")
+	ErrGeoPointLatRequired = errors.New("Location (Latitude) is missing, but required")
+	ErrGeoPointLngRequired = errors.New("Location (Longitude) is missing, but required")
+)
+
+// GeoPoint is a point on the earth in degrees.
+// Lat and Lng are named, GeoJSON coordinates are ordered [lng, lat] and only converted by
+// GeoPointFromGeoJSON and GeoJSON, so the order is never guessed from an index
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// NewGeoPoint returns the point when it is valid
+func NewGeoPoint(lat float64, lng float64) (GeoPoint, error) {
+	p := GeoPoint{Lat: lat, Lng: lng}
+	err := p.Validate()
+	if err != nil {
+		return GeoPoint{}, err
+	}
+	return p, nil
+}
+
+// ParseGeoPoint parses the latitude and longitude in decimal degrees, such as url params
+func ParseGeoPoint(lat string, lng string) (GeoPoint, error) {
+	if lat == "" {
+		return GeoPoint{}, ErrGeoPointLatRequired
+	}
+	if lng == "" {
+		return GeoPoint{}, ErrGeoPointLngRequired
+	}
+	la, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return GeoPoint{}, ErrGeoPointLatInvalid
+	}
+	lo, err := strconv.ParseFloat(lng, 64)
+	if err != nil {
+		return GeoPoint{}, ErrGeoPointLngInvalid
+	}
+	return NewGeoPoint(la, lo)
+}
+
+// GeoPointFromGeoJSON returns the point of GeoJSON coordinates [lng, lat]
+func GeoPointFromGeoJSON(coordinates [2]float64) GeoPoint {
+	return GeoPoint{Lat: coordinates[1], Lng: coordinates[0]}
+}
+
+// GeoJSON returns the coordinates of the point in GeoJSON order [lng, lat]
+func (p GeoPoint) GeoJSON() [2]float64 {
+	return [2]float64{p.Lng, p.Lat}
+}
+
+// Validate checks the point is a number within the range of latitude [-90, 90] and longitude [-180, 180].
+// The equator and the prime meridian are valid, but (0, 0) is rejected as it is what a missing location decodes to
+func (p GeoPoint) Validate() error {
+	if math.IsNaN(p.Lat) || math.IsNaN(p.Lng) {
+		return ErrGeoPointNaN
+	}
+	if p.Lat < -90 || p.Lat > 90 {
+		return ErrGeoPointLatRange
+	}
+	if p.Lng < -180 || p.Lng > 180 {
+		return ErrGeoPointLngRange
+	}
+	if p.IsZero() {
+		return ErrGeoPointNullIsland
+	}
+	return nil
+}
+
+// IsZero returns true for (0, 0)
+func (p GeoPoint) IsZero() bool {
+	return p.Lat == 0 && p.Lng == 0
+}
+
+// DistanceTo returns the distance in meters to the point
+func (p GeoPoint) DistanceTo(to GeoPoint) float64 {
+	return Distance(p.Lat, p.Lng, to.Lat, to.Lng)
+}
+
+// BearingTo returns the initial bearing to the point in degrees clockwise from north, from 0 to 360
+func (p GeoPoint) BearingTo(to GeoPoint) float64 {
+	la1 := p.Lat * math.Pi / 180
+	la2 := to.Lat * math.Pi / 180
+	dlo := (to.Lng - p.Lng) * math.Pi / 180
+
+	y := math.Sin(dlo) * math.Cos(la2)
+	x := math.Cos(la1)*math.Sin(la2) - math.Sin(la1)*math.Cos(la2)*math.Cos(dlo)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// Destination returns the point at the distance in meters along the great circle of the initial bearing,
+// in degrees clockwise from north
+func (p GeoPoint) Destination(bearing float64, distance float64) GeoPoint {
+	la1 := p.Lat * math.Pi / 180
+	lo1 := p.Lng * math.Pi / 180
+	b := bearing * math.Pi / 180
+	d := distance / Earth_Radius_Meter
+
+	la2 := math.Asin(math.Sin(la1)*math.Cos(d) + math.Cos(la1)*math.Sin(d)*math.Cos(b))
+	lo2 := lo1 + math.Atan2(math.Sin(b)*math.Sin(d)*math.Cos(la1), math.Cos(d)-math.Sin(la1)*math.Sin(la2))
+	return GeoPoint{Lat: la2 * 180 / math.Pi, Lng: normalizeLng(lo2 * 180 / math.Pi)}
+}
+
+// normalizeLng wraps the longitude into [-180, 180)
+func normalizeLng(lng float64) float64 {
+	return math.Mod(math.Mod(lng+180, 360)+360, 360) - 180
+}
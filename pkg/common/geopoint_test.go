@@ -0,0 +1,223 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewGeoPoint(t *testing.T) {
+	tests := []struct {
+		name string
+		lat  float64
+		lng  float64
+		err  error
+	}{
+		{"valid", 3.1390, 101.6869, nil},
+		{"equator", 0, 101.6869, nil},
+		{"prime meridian", 51.4779, 0, nil},
+		{"north pole", 90, 0, nil},
+		{"south pole", -90, 0, nil},
+		{"antimeridian east", 3, 180, nil},
+		{"antimeridian west", 3, -180, nil},
+		{"null island", 0, 0, ErrGeoPointNullIsland},
+		{"lat above range", 90.0001, 101, ErrGeoPointLatRange},
+		{"lat below range", -90.0001, 101, ErrGeoPointLatRange},
+		{"lng above range", 3, 180.0001, ErrGeoPointLngRange},
+		{"lng below range", 3, -180.0001, ErrGeoPointLngRange},
+		{"lat NaN", math.NaN(), 101, ErrGeoPointNaN},
+		{"lng NaN", 3, math.NaN(), ErrGeoPointNaN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewGeoPoint(tt.lat, tt.lng)
+			if err != tt.err {
+				t.Fatalf("NewGeoPoint(%v, %v) error = %v, want %v", tt.lat, tt.lng, err, tt.err)
+			}
+			if err != nil {
+				if p != (GeoPoint{}) {
+					t.Errorf("NewGeoPoint(%v, %v) = %v, want zero point on error", tt.lat, tt.lng, p)
+				}
+				return
+			}
+			if p.Lat != tt.lat || p.Lng != tt.lng {
+				t.Errorf("NewGeoPoint(%v, %v) = %v", tt.lat, tt.lng, p)
+			}
+			if err := p.Validate(); err != nil {
+				t.Errorf("Validate() of %v = %v, want nil", p, err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		p    GeoPoint
+		err  error
+	}{
+		{"valid", GeoPoint{Lat: -6.2088, Lng: 106.8456}, nil},
+		{"equator", GeoPoint{Lat: 0, Lng: -78.4678}, nil},
+		{"prime meridian", GeoPoint{Lat: -33.9249, Lng: 0}, nil},
+		{"null island", GeoPoint{}, ErrGeoPointNullIsland},
+		{"lat out of range", GeoPoint{Lat: 101, Lng: 3}, ErrGeoPointLatRange},
+		{"lng out of range", GeoPoint{Lat: 3, Lng: 181}, ErrGeoPointLngRange},
+		{"NaN", GeoPoint{Lat: math.NaN(), Lng: math.NaN()}, ErrGeoPointNaN},
+		{"infinite lat", GeoPoint{Lat: math.Inf(1), Lng: 3}, ErrGeoPointLatRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.p.Validate(); err != tt.err {
+				t.Errorf("Validate() of %v = %v, want %v", tt.p, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseGeoPoint(t *testing.T) {
+	tests := []struct {
+		name string
+		lat  string
+		lng  string
+		want GeoPoint
+		err  error
+	}{
+		{"valid", "3.1390", "101.6869", GeoPoint{Lat: 3.1390, Lng: 101.6869}, nil},
+		{"equator", "0", "101.6869", GeoPoint{Lat: 0, Lng: 101.6869}, nil},
+		{"prime meridian", "51.4779", "0.0", GeoPoint{Lat: 51.4779, Lng: 0}, nil},
+		{"null island", "0", "0", GeoPoint{}, ErrGeoPointNullIsland},
+		{"lat missing", "", "101", GeoPoint{}, ErrGeoPointLatRequired},
+		{"lng missing", "3", "", GeoPoint{}, ErrGeoPointLngRequired},
+		{"lat invalid", "north", "101", GeoPoint{}, ErrGeoPointLatInvalid},
+		{"lng invalid", "3", "east", GeoPoint{}, ErrGeoPointLngInvalid},
+		{"lat out of range", "-91", "101", GeoPoint{}, ErrGeoPointLatRange},
+		{"lng out of range", "3", "200", GeoPoint{}, ErrGeoPointLngRange},
+		{"NaN", "NaN", "101", GeoPoint{}, ErrGeoPointNaN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseGeoPoint(tt.lat, tt.lng)
+			if err != tt.err {
+				t.Fatalf("ParseGeoPoint(%q, %q) error = %v, want %v", tt.lat, tt.lng, err, tt.err)
+			}
+			if p != tt.want {
+				t.Errorf("ParseGeoPoint(%q, %q) = %v, want %v", tt.lat, tt.lng, p, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeoJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		p    GeoPoint
+	}{
+		{"kuala lumpur", GeoPoint{Lat: 3.1390, Lng: 101.6869}},
+		{"southern western hemisphere", GeoPoint{Lat: -34.6037, Lng: -58.3816}},
+		{"equator", GeoPoint{Lat: 0, Lng: 32.5825}},
+		{"prime meridian", GeoPoint{Lat: 51.4779, Lng: 0}},
+		{"antimeridian", GeoPoint{Lat: -16.5, Lng: 180}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coordinates := tt.p.GeoJSON()
+			// GeoJSON order is [lng, lat]
+			if coordinates[0] != tt.p.Lng || coordinates[1] != tt.p.Lat {
+				t.Errorf("GeoJSON() of %v = %v, want [%v %v]", tt.p, coordinates, tt.p.Lng, tt.p.Lat)
+			}
+			if got := GeoPointFromGeoJSON(coordinates); got != tt.p {
+				t.Errorf("GeoPointFromGeoJSON(%v) = %v, want %v", coordinates, got, tt.p)
+			}
+		})
+	}
+}
+
+func TestGeoPointFromGeoJSON(t *testing.T) {
+	p := GeoPointFromGeoJSON([2]float64{101.6869, 3.1390})
+	if p.Lat != 3.1390 || p.Lng != 101.6869 {
+		t.Errorf("GeoPointFromGeoJSON([101.6869 3.1390]) = %v, want lat 3.1390 lng 101.6869", p)
+	}
+}
+
+func TestBearingTo(t *testing.T) {
+	tests := []struct {
+		name string
+		from GeoPoint
+		to   GeoPoint
+		want float64
+	}{
+		{"north", GeoPoint{Lat: 0, Lng: 10}, GeoPoint{Lat: 1, Lng: 10}, 0},
+		{"east", GeoPoint{Lat: 0, Lng: 10}, GeoPoint{Lat: 0, Lng: 11}, 90},
+		{"south", GeoPoint{Lat: 1, Lng: 10}, GeoPoint{Lat: 0, Lng: 10}, 180},
+		{"west", GeoPoint{Lat: 0, Lng: 11}, GeoPoint{Lat: 0, Lng: 10}, 270},
+		{"east across the antimeridian", GeoPoint{Lat: 0, Lng: 179.5}, GeoPoint{Lat: 0, Lng: -179.5}, 90},
+		{"north east on the equator", GeoPoint{Lat: 0, Lng: 101}, GeoPoint{Lat: 0.0001, Lng: 101.0001}, 45},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.from.BearingTo(tt.to)
+			if math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("BearingTo() from %v to %v = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDestination(t *testing.T) {
+	// one degree of arc on the earth
+	degree := Earth_Radius_Meter * math.Pi / 180
+
+	tests := []struct {
+		name     string
+		from     GeoPoint
+		bearing  float64
+		distance float64
+		want     GeoPoint
+	}{
+		{"north", GeoPoint{Lat: 0, Lng: 10}, 0, degree, GeoPoint{Lat: 1, Lng: 10}},
+		{"east", GeoPoint{Lat: 0, Lng: 10}, 90, degree, GeoPoint{Lat: 0, Lng: 11}},
+		{"south", GeoPoint{Lat: 0, Lng: 10}, 180, degree, GeoPoint{Lat: -1, Lng: 10}},
+		{"west", GeoPoint{Lat: 0, Lng: 10}, 270, degree, GeoPoint{Lat: 0, Lng: 9}},
+		{"east across the antimeridian", GeoPoint{Lat: 0, Lng: 179.5}, 90, degree, GeoPoint{Lat: 0, Lng: -179.5}},
+		{"west across the antimeridian", GeoPoint{Lat: 0, Lng: -179.5}, 270, degree, GeoPoint{Lat: 0, Lng: 179.5}},
+		{"zero distance", GeoPoint{Lat: 3, Lng: 101}, 45, 0, GeoPoint{Lat: 3, Lng: 101}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.from.Destination(tt.bearing, tt.distance)
+			if math.Abs(got.Lat-tt.want.Lat) > 1e-9 || math.Abs(got.Lng-tt.want.Lng) > 1e-9 {
+				t.Errorf("Destination(%v, %v) from %v = %v, want %v", tt.bearing, tt.distance, tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDestinationRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     GeoPoint
+		bearing  float64
+		distance float64
+	}{
+		{"short", GeoPoint{Lat: 3.1390, Lng: 101.6869}, 30, 1500},
+		{"long", GeoPoint{Lat: -33.8688, Lng: 151.2093}, 250, 500000},
+		{"southern", GeoPoint{Lat: -60, Lng: -70}, 135, 20000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to := tt.from.Destination(tt.bearing, tt.distance)
+			if d := tt.from.DistanceTo(to); math.Abs(d-tt.distance) > tt.distance*1e-6+0.01 {
+				t.Errorf("DistanceTo() of the destination = %v, want %v", d, tt.distance)
+			}
+			if b := tt.from.BearingTo(to); math.Abs(b-tt.bearing) > 1e-6 {
+				t.Errorf("BearingTo() of the destination = %v, want %v", b, tt.bearing)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package common
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrHexCellInvalid = errors.New("Hex cell is invalid")
+
+// max latitude of the web mercator projection of the hex grid, points beyond are clamped
+const hexMaxLat = 85.05112878
+
+// HexCell is a cell of a hexagonal grid in axial coordinates. The grid is laid over the web mercator
+// projection with pointy-top hexagons of the edge size in meters at the equator, cells shrink with the
+// cosine of the latitude away from it as on web maps. A cell is only meaningful with the edge size of its grid
+type HexCell struct {
+	Q int64 `json:"q"`
+	R int64 `json:"r"`
+}
+
+// axial directions of the 6 neighbors, E, NE, NW, W, SW, SE
+var hexDirections = [6]HexCell{{1, 0}, {0, 1}, {-1, 1}, {-1, 0}, {0, -1}, {1, -1}}
+
+// HexCellOf returns the cell of the grid of the edge size in meters the point is in
+func HexCellOf(p GeoPoint, edge float64) HexCell {
+	x, y := mercator(p)
+	q := (math.Sqrt(3)/3*x - y/3) / edge
+	r := (2.0 / 3 * y) / edge
+	return hexRound(q, r)
+}
+
+// Center returns the center point of the cell in the grid of the edge size
+func (c HexCell) Center(edge float64) GeoPoint {
+	x := edge * (math.Sqrt(3)*float64(c.Q) + math.Sqrt(3)/2*float64(c.R))
+	y := edge * (1.5 * float64(c.R))
+	return inverseMercator(x, y)
+}
+
+// Boundary returns the 6 corners of the cell in the grid of the edge size, counterclockwise
+// as the exterior ring of a GeoJSON polygon without the closing corner
+func (c HexCell) Boundary(edge float64) []GeoPoint {
+	cx := edge * (math.Sqrt(3)*float64(c.Q) + math.Sqrt(3)/2*float64(c.R))
+	cy := edge * (1.5 * float64(c.R))
+	corners := make([]GeoPoint, 6)
+	for i := range corners {
+		angle := (60*float64(i) - 30) * math.Pi / 180
+		corners[i] = inverseMercator(cx+edge*math.Cos(angle), cy+edge*math.Sin(angle))
+	}
+	return corners
+}
+
+// Neighbors returns the 6 cells sharing an edge with the cell, in the order E, NE, NW, W, SW, SE
+func (c HexCell) Neighbors() []HexCell {
+	neighbors := make([]HexCell, len(hexDirections))
+	for i, d := range hexDirections {
+		neighbors[i] = HexCell{Q: c.Q + d.Q, R: c.R + d.R}
+	}
+	return neighbors
+}
+
+// GridDistance returns the number of cells to step from the cell to the cell
+func (c HexCell) GridDistance(to HexCell) int64 {
+	dq := c.Q - to.Q
+	dr := c.R - to.R
+	return (abs64(dq) + abs64(dr) + abs64(dq+dr)) / 2
+}
+
+// String returns the key of the cell, "q:r"
+func (c HexCell) String() string {
+	return strconv.FormatInt(c.Q, 10) + ":" + strconv.FormatInt(c.R, 10)
+}
+
+// ParseHexCell parses the key of a cell returned by String
+func ParseHexCell(key string) (HexCell, error) {
+	parts := strings.Split(key, ":")
+	if len(parts) != 2 {
+		return HexCell{}, ErrHexCellInvalid
+	}
+	q, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return HexCell{}, ErrHexCellInvalid
+	}
+	r, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return HexCell{}, ErrHexCellInvalid
+	}
+	return HexCell{Q: q, R: r}, nil
+}
+
+// hexRound returns the cell of the fractional axial coordinates, rounded in cube coordinates
+func hexRound(q float64, r float64) HexCell {
+	s := -q - r
+	rq, rr, rs := math.Round(q), math.Round(r), math.Round(s)
+	dq, dr, ds := math.Abs(rq-q), math.Abs(rr-r), math.Abs(rs-s)
+	if dq > dr && dq > ds {
+		rq = -rr - rs
+	} else if dr > ds {
+		rr = -rq - rs
+	}
+	return HexCell{Q: int64(rq), R: int64(rr)}
+}
+
+// mercator returns the web mercator coordinates of the point in meters
+func mercator(p GeoPoint) (float64, float64) {
+	lat := math.Max(math.Min(p.Lat, hexMaxLat), -hexMaxLat) * math.Pi / 180
+	x := Earth_Radius_Meter * p.Lng * math.Pi / 180
+	y := Earth_Radius_Meter * math.Log(math.Tan(math.Pi/4+lat/2))
+	return x, y
+}
+
+func inverseMercator(x float64, y float64) GeoPoint {
+	lat := (2*math.Atan(math.Exp(y/Earth_Radius_Meter)) - math.Pi/2) * 180 / math.Pi
+	lng := normalizeLng(x / Earth_Radius_Meter * 180 / math.Pi)
+	return GeoPoint{Lat: lat, Lng: lng}
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
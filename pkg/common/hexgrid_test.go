@@ -0,0 +1,147 @@
+package common
+
+import "testing"
+
+func TestHexCellRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		p    GeoPoint
+		edge float64
+	}{
+		{"kuala lumpur", GeoPoint{Lat: 3.1390, Lng: 101.6869}, 500},
+		{"southern western hemisphere", GeoPoint{Lat: -34.6037, Lng: -58.3816}, 1000},
+		{"equator", GeoPoint{Lat: 0, Lng: 32.5825}, 250},
+		{"prime meridian", GeoPoint{Lat: 51.4779, Lng: 0}, 100},
+		{"high latitude", GeoPoint{Lat: 69.6492, Lng: 18.9553}, 2000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cell := HexCellOf(tt.p, tt.edge)
+			center := cell.Center(tt.edge)
+			if got := HexCellOf(center, tt.edge); got != cell {
+				t.Errorf("HexCellOf() of the center %v = %v, want %v", center, got, cell)
+			}
+			if d := tt.p.DistanceTo(center); d > tt.edge {
+				t.Errorf("center %v of %v is %v meters from %v, want at most %v", center, cell, d, tt.p, tt.edge)
+			}
+			if got := len(cell.Boundary(tt.edge)); got != 6 {
+				t.Errorf("Boundary() of %v has %v corners, want 6", cell, got)
+			}
+		})
+	}
+}
+
+func TestHexCellNeighbors(t *testing.T) {
+	tests := []struct {
+		name string
+		cell HexCell
+		want []HexCell // E, NE, NW, W, SW, SE
+	}{
+		{"origin", HexCell{Q: 0, R: 0}, []HexCell{{1, 0}, {0, 1}, {-1, 1}, {-1, 0}, {0, -1}, {1, -1}}},
+		{"offset", HexCell{Q: 5, R: -3}, []HexCell{{6, -3}, {5, -2}, {4, -2}, {4, -3}, {5, -4}, {6, -4}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.cell.Neighbors()
+			if len(got) != len(tt.want) {
+				t.Fatalf("Neighbors() of %v = %v, want %v", tt.cell, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Neighbors() of %v = %v, want %v", tt.cell, got, tt.want)
+					break
+				}
+				if d := tt.cell.GridDistance(got[i]); d != 1 {
+					t.Errorf("GridDistance() from %v to its neighbor %v = %v, want 1", tt.cell, got[i], d)
+				}
+			}
+		})
+	}
+}
+
+func TestHexCellNeighborsGeographic(t *testing.T) {
+	const edge = 500
+	cell := HexCellOf(GeoPoint{Lat: 3.1390, Lng: 101.6869}, edge)
+	center := cell.Center(edge)
+	neighbors := cell.Neighbors()
+
+	east := neighbors[0].Center(edge)
+	if east.Lng <= center.Lng {
+		t.Errorf("east neighbor center %v is not east of %v", east, center)
+	}
+	west := neighbors[3].Center(edge)
+	if west.Lng >= center.Lng {
+		t.Errorf("west neighbor center %v is not west of %v", west, center)
+	}
+	for _, i := range []int{1, 2} {
+		if n := neighbors[i].Center(edge); n.Lat <= center.Lat {
+			t.Errorf("north neighbor center %v is not north of %v", n, center)
+		}
+	}
+	for _, i := range []int{4, 5} {
+		if n := neighbors[i].Center(edge); n.Lat >= center.Lat {
+			t.Errorf("south neighbor center %v is not south of %v", n, center)
+		}
+	}
+}
+
+func TestHexCellGridDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		from HexCell
+		to   HexCell
+		want int64
+	}{
+		{"same cell", HexCell{Q: 2, R: 3}, HexCell{Q: 2, R: 3}, 0},
+		{"along q", HexCell{Q: 0, R: 0}, HexCell{Q: 4, R: 0}, 4},
+		{"along r", HexCell{Q: 0, R: 0}, HexCell{Q: 0, R: -3}, 3},
+		{"diagonal", HexCell{Q: 0, R: 0}, HexCell{Q: 3, R: -1}, 3},
+		{"opposite signs", HexCell{Q: -2, R: 1}, HexCell{Q: 3, R: -4}, 5},
+		{"same signs", HexCell{Q: 0, R: 0}, HexCell{Q: 2, R: 2}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.from.GridDistance(tt.to); got != tt.want {
+				t.Errorf("GridDistance() from %v to %v = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+			if got := tt.to.GridDistance(tt.from); got != tt.want {
+				t.Errorf("GridDistance() from %v to %v = %v, want %v", tt.to, tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHexCell(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want HexCell
+		err  error
+	}{
+		{"origin", "0:0", HexCell{Q: 0, R: 0}, nil},
+		{"negative", "-12:34", HexCell{Q: -12, R: 34}, nil},
+		{"missing r", "12", HexCell{}, ErrHexCellInvalid},
+		{"too many parts", "1:2:3", HexCell{}, ErrHexCellInvalid},
+		{"invalid q", "a:2", HexCell{}, ErrHexCellInvalid},
+		{"invalid r", "1:b", HexCell{}, ErrHexCellInvalid},
+		{"empty", "", HexCell{}, ErrHexCellInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHexCell(tt.key)
+			if err != tt.err {
+				t.Fatalf("ParseHexCell(%q) error = %v, want %v", tt.key, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseHexCell(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+			if err == nil && got.String() != tt.key {
+				t.Errorf("String() of %v = %q, want %q", got, got.String(), tt.key)
+			}
+		})
+	}
+}
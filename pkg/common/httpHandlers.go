@@ -99,6 +99,22 @@ func HandleStatus400Response(w http.ResponseWriter, customError string) {
 	HandleHTTPResponse(w, response)
 }
 
+func HandleStatusConflictResponse(w http.ResponseWriter, customError string) {
+	log.Printf("Error 409: %v\n", customError)
+	// send a conflict error back to the caller
+	var msg string
+	if msg = customError; msg == "" {
+		msg = "Conflict"
+	}
+	errorStatus := http.StatusConflict
+	response := HTTPResponseWrapper{
+		Ok:      false,
+		Status:  errorStatus,
+		Message: msg,
+	}
+	HandleHTTPResponse(w, response)
+}
+
 func HandleStatusOKResponse(w http.ResponseWriter, data HTTPResult) {
 	log.Printf("Response 200: %v\n", data)
 
@@ -117,3 +133,12 @@ func HandleHTTPResponse(w http.ResponseWriter, data HTTPResponseWrapper) {
 	// write json data
 	json.NewEncoder(w).Encode(data)
 }
+
+// HandleGeoJSONResponse writes the GeoJSON object without the response wrapper, so it can be loaded by map tools as is
+func HandleGeoJSONResponse(w http.ResponseWriter, data interface{}) {
+	log.Printf("Response 200: GeoJSON %v\n", data)
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(data)
+}
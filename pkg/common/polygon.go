@@ -0,0 +1,55 @@
+package common
+
+// Polygon is a list of linear rings of GeoJSON [lng, lat] positions, the first ring is the
+// exterior and the others are holes
+type Polygon [][][2]float64
+
+// Contains returns true when the point is inside the exterior ring and outside all holes
+func (p Polygon) Contains(pt GeoPoint) bool {
+	if len(p) == 0 || !RingContains(p[0], pt) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if RingContains(hole, pt) {
+			return false
+		}
+	}
+	return true
+}
+
+// Bounds returns the bounding box of the exterior ring
+func (p Polygon) Bounds() BoundingBox {
+	b := BoundingBox{MinLat: 90, MinLng: 180, MaxLat: -90, MaxLng: -180}
+	if len(p) == 0 {
+		return b
+	}
+	for _, pos := range p[0] {
+		if pos[1] < b.MinLat {
+			b.MinLat = pos[1]
+		}
+		if pos[1] > b.MaxLat {
+			b.MaxLat = pos[1]
+		}
+		if pos[0] < b.MinLng {
+			b.MinLng = pos[0]
+		}
+		if pos[0] > b.MaxLng {
+			b.MaxLng = pos[0]
+		}
+	}
+	return b
+}
+
+// RingContains returns true when the point is inside the linear ring of [lng, lat] positions,
+// with the ray casting algorithm
+func RingContains(ring [][2]float64, pt GeoPoint) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > pt.Lat) != (yj > pt.Lat) && pt.Lng < (xj-xi)*(pt.Lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
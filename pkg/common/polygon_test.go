@@ -0,0 +1,52 @@
+package common
+
+import "testing"
+
+func TestPolygonContains(t *testing.T) {
+	square := Polygon{
+		{{100, 0}, {101, 0}, {101, 1}, {100, 1}, {100, 0}},
+	}
+	withHole := Polygon{
+		{{100, 0}, {101, 0}, {101, 1}, {100, 1}, {100, 0}},
+		{{100.2, 0.2}, {100.8, 0.2}, {100.8, 0.8}, {100.2, 0.8}, {100.2, 0.2}},
+	}
+	concave := Polygon{
+		{{0, 0}, {4, 0}, {4, 4}, {2, 2}, {0, 4}, {0, 0}},
+	}
+
+	tests := []struct {
+		name string
+		poly Polygon
+		p    GeoPoint
+		want bool
+	}{
+		{"inside", square, GeoPoint{Lat: 0.5, Lng: 100.5}, true},
+		{"outside", square, GeoPoint{Lat: 1.5, Lng: 100.5}, false},
+		{"outside in line", square, GeoPoint{Lat: 0.5, Lng: 99}, false},
+		{"inside the hole", withHole, GeoPoint{Lat: 0.5, Lng: 100.5}, false},
+		{"between the hole and the exterior", withHole, GeoPoint{Lat: 0.1, Lng: 100.5}, true},
+		{"outside with a hole", withHole, GeoPoint{Lat: -0.5, Lng: 100.5}, false},
+		{"concave inside", concave, GeoPoint{Lat: 1, Lng: 2}, true},
+		{"concave notch", concave, GeoPoint{Lat: 3, Lng: 2}, false},
+		{"empty", Polygon{}, GeoPoint{Lat: 0.5, Lng: 100.5}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.poly.Contains(tt.p); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolygonBounds(t *testing.T) {
+	poly := Polygon{
+		{{100, 0}, {101.5, 0.5}, {101, 1.25}, {99.75, 1}, {100, 0}},
+		{{100.2, 0.2}, {100.8, 0.2}, {100.8, 0.8}, {100.2, 0.2}},
+	}
+	want := BoundingBox{MinLat: 0, MinLng: 99.75, MaxLat: 1.25, MaxLng: 101.5}
+	if got := poly.Bounds(); got != want {
+		t.Errorf("Bounds() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrPolylineInvalid = errors.New("Polyline is invalid")
+
+// decimal digits of the coordinates of an encoded polyline, 5 as Google Maps, 6 as OSRM and Valhalla
+const Polyline_Precision = 5
+
+// EncodePolyline returns the points in the encoded polyline algorithm format with the precision in decimal digits
+func EncodePolyline(points []GeoPoint, precision int) string {
+	factor := math.Pow(10, float64(precision))
+	buf := make([]byte, 0, len(points)*8)
+	var lastLat, lastLng int64
+	for _, p := range points {
+		lat := int64(math.Round(p.Lat * factor))
+		lng := int64(math.Round(p.Lng * factor))
+		buf = appendPolylineValue(buf, lat-lastLat)
+		buf = appendPolylineValue(buf, lng-lastLng)
+		lastLat, lastLng = lat, lng
+	}
+	return string(buf)
+}
+
+// DecodePolyline returns the points of the encoded polyline with the precision in decimal digits
+func DecodePolyline(polyline string, precision int) ([]GeoPoint, error) {
+	factor := math.Pow(10, float64(precision))
+	points := []GeoPoint{}
+	var lat, lng int64
+	for i := 0; i < len(polyline); {
+		dLat, n, err := readPolylineValue(polyline[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		dLng, n, err := readPolylineValue(polyline[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+
+		lat += dLat
+		lng += dLng
+		points = append(points, GeoPoint{Lat: float64(lat) / factor, Lng: float64(lng) / factor})
+	}
+	return points, nil
+}
+
+// appendPolylineValue appends the zigzag encoded value in chunks of 5 bits, each offset by 63 into printable ascii
+func appendPolylineValue(buf []byte, v int64) []byte {
+	u := uint64(v) << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		buf = append(buf, byte(0x20|(u&0x1f))+63)
+		u >>= 5
+	}
+	return append(buf, byte(u)+63)
+}
+
+// readPolylineValue returns the value at the start of the polyline and the number of bytes read
+func readPolylineValue(polyline string) (int64, int, error) {
+	var u uint64
+	var shift uint
+	for i := 0; i < len(polyline); i++ {
+		b := polyline[i]
+		if b < 63 || b > 126 || shift > 60 {
+			return 0, 0, ErrPolylineInvalid
+		}
+		chunk := uint64(b - 63)
+		u |= (chunk & 0x1f) << shift
+		shift += 5
+		if chunk < 0x20 {
+			v := int64(u >> 1)
+			if u&1 != 0 {
+				v = ^v
+			}
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, ErrPolylineInvalid
+}
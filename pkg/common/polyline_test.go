@@ -0,0 +1,95 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEncodePolyline(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    []GeoPoint
+		precision int
+		want      string
+	}{
+		{"reference", []GeoPoint{{Lat: 38.5, Lng: -120.2}, {Lat: 40.7, Lng: -120.95}, {Lat: 43.252, Lng: -126.453}}, Polyline_Precision, "_p~iF~ps|U_ulLnnqC_mqNvxq`@"},
+		{"reference precision 6", []GeoPoint{{Lat: 38.5, Lng: -120.2}}, 6, "_izlhA~rlgdF"},
+		{"equator and prime meridian", []GeoPoint{{Lat: 0, Lng: 1}, {Lat: 1, Lng: 0}}, Polyline_Precision, "?_ibE_ibE~hbE"},
+		{"empty", []GeoPoint{}, Polyline_Precision, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EncodePolyline(tt.points, tt.precision); got != tt.want {
+				t.Errorf("EncodePolyline(%v, %v) = %q, want %q", tt.points, tt.precision, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodePolyline(t *testing.T) {
+	tests := []struct {
+		name      string
+		polyline  string
+		precision int
+		want      []GeoPoint
+		err       error
+	}{
+		{"reference", "_p~iF~ps|U_ulLnnqC_mqNvxq`@", Polyline_Precision, []GeoPoint{{Lat: 38.5, Lng: -120.2}, {Lat: 40.7, Lng: -120.95}, {Lat: 43.252, Lng: -126.453}}, nil},
+		{"reference precision 6", "_izlhA~rlgdF", 6, []GeoPoint{{Lat: 38.5, Lng: -120.2}}, nil},
+		{"empty", "", Polyline_Precision, []GeoPoint{}, nil},
+		{"truncated value", "_p~iF~ps|U_ulLnnqC_mqNvxq", Polyline_Precision, nil, ErrPolylineInvalid},
+		{"missing longitude", "_p~iF", Polyline_Precision, nil, ErrPolylineInvalid},
+		{"invalid character", "_p~iF ps|U", Polyline_Precision, nil, ErrPolylineInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodePolyline(tt.polyline, tt.precision)
+			if err != tt.err {
+				t.Fatalf("DecodePolyline(%q, %v) error = %v, want %v", tt.polyline, tt.precision, err, tt.err)
+			}
+			if len(got) != len(tt.want) || (got == nil) != (tt.want == nil) {
+				t.Fatalf("DecodePolyline(%q, %v) = %v, want %v", tt.polyline, tt.precision, got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(got[i].Lat-tt.want[i].Lat) > 1e-9 || math.Abs(got[i].Lng-tt.want[i].Lng) > 1e-9 {
+					t.Errorf("DecodePolyline(%q, %v) = %v, want %v", tt.polyline, tt.precision, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestPolylineRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    []GeoPoint
+		precision int
+	}{
+		{"kuala lumpur", []GeoPoint{{Lat: 3.1390, Lng: 101.6869}, {Lat: 3.1478, Lng: 101.6953}, {Lat: 3.1579, Lng: 101.7116}}, Polyline_Precision},
+		{"southern western hemisphere", []GeoPoint{{Lat: -34.6037, Lng: -58.3816}, {Lat: -34.6118, Lng: -58.4173}}, 6},
+		{"repeated point", []GeoPoint{{Lat: 51.4779, Lng: 0}, {Lat: 51.4779, Lng: 0}}, Polyline_Precision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := EncodePolyline(tt.points, tt.precision)
+			got, err := DecodePolyline(encoded, tt.precision)
+			if err != nil {
+				t.Fatalf("DecodePolyline(%q, %v) error = %v", encoded, tt.precision, err)
+			}
+			if len(got) != len(tt.points) {
+				t.Fatalf("DecodePolyline(%q, %v) = %v, want %v", encoded, tt.precision, got, tt.points)
+			}
+			tolerance := 0.5 / math.Pow(10, float64(tt.precision))
+			for i := range got {
+				if math.Abs(got[i].Lat-tt.points[i].Lat) > tolerance || math.Abs(got[i].Lng-tt.points[i].Lng) > tolerance {
+					t.Errorf("DecodePolyline(%q, %v) = %v, want %v", encoded, tt.precision, got, tt.points)
+					break
+				}
+			}
+		})
+	}
+}
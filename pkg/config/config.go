@@ -23,7 +23,9 @@ type CORSConfig struct {
 }
 
 type UDPServerConfig struct {
-	Addr string `json:"addr"`
+	Addr          string `json:"addr"`
+	BatchSize     int32  `json:"batchsize"`     // max packets per location update batch
+	BatchWindowMs int32  `json:"batchwindowms"` // max wait in millisecond before a batch is flushed
 }
 
 type HTTPServerConfig struct {
@@ -32,26 +34,125 @@ type HTTPServerConfig struct {
 
 type LocationRemoteServerConfig struct {
 	RemoteAddr          string   `json:"remoteaddr"`
+	Store               string   `json:"store"` // tile38 or memory
 	HookEndpoints       []string `json:"hookendpoints"`
 	SearchTier1Meter    int32    `json:"searchtier1meter"`
 	SearchTier2Meter    int32    `json:"searchtier2meter"`
 	SearchTier3Meter    int32    `json:"searchtier3meter"`
 	DetectArrivingMeter int32    `json:"detectarrivingmeter"`
 	DetectArrivedMeter  int32    `json:"detectarrivedmeter"`
+	// drivers without update within the timeout are excluded from searches and set unreachable, 0 to disable
+	HeartbeatTimeoutSecond int32 `json:"heartbeattimeoutsecond"`
+	HeartbeatSweepSecond   int32 `json:"heartbeatsweepsecond"`
+	// drivers faster than the moving speed are moving, the others are idle until they did not move within the stopped time
+	MotionMovingKmh     float64 `json:"motionmovingkmh"`
+	MotionStoppedSecond int32   `json:"motionstoppedsecond"`
+	// tenant of the drivers and the requests without a fleet, its drivers are kept in the fleet collection
+	DefaultTenant string `json:"defaulttenant"`
+}
+
+// TenantConfig is a business line or a brand with drivers of its own, such as the roadside mechanics.
+// The drivers of each tenant are kept in a collection of their own, so a tenant never finds the drivers of another
+type TenantConfig struct {
+	Name string `json:"name"` // the fleet of the DriverStatusPoll and of the fleet url param
+	ID   int32  `json:"id"`   // kept in the fields of the jobs of the tenant, from 1 since 0 is the default tenant
+	// search tier radii of the tenant, the tiers of locationremoteserver when none is set
+	SearchTier1Meter int32 `json:"searchtier1meter"`
+	SearchTier2Meter int32 `json:"searchtier2meter"`
+	SearchTier3Meter int32 `json:"searchtier3meter"`
+}
+
+// PositionFilterConfig is the filter of the positions sent by the drivers, before they are set in the fleet collection
+type PositionFilterConfig struct {
+	Enabled          bool    `json:"enabled"`
+	MaxSpeedKmh      float64 `json:"maxspeedkmh"`      // positions implying a higher speed from the last position are rejected
+	MaxAccuracyMeter float64 `json:"maxaccuracymeter"` // positions with a worse accuracy are rejected, 0 to disable
+	// positions are smoothed by a Kalman filter, the noise is the expected change of speed in meter per second, 0 to disable
+	SmoothingNoiseMps    float64 `json:"smoothingnoisemps"`
+	DefaultAccuracyMeter float64 `json:"defaultaccuracymeter"` // accuracy of the positions sent without one
+	// after the consecutive rejections the next position is accepted as the new start, the last position may be the bad one
+	MaxRejects     int32 `json:"maxrejects"`
+	TrackTTLSecond int32 `json:"trackttlsecond"` // the last position of a driver is forgotten after the ttl
 }
 
 type AuthServerConfig struct {
 	RemoteAddr string `json:"remoteaddr"`
 }
 
+// The fleet info of the drivers is cached, so the fleet server is not asked on each change of availability
 type FleetServerConfig struct {
 	RemoteAddr string `json:"remoteaddr"`
+	// the fleet info is asked again after the ttl, 0 to disable the cache
+	CacheTTLSecond int32 `json:"cachettlsecond"`
+	// a driver not found or without an active service is asked again after the negative ttl
+	CacheNegativeTTLSecond int32 `json:"cachenegativettlsecond"`
+	// when the fleet server fails, fleet info older than the ttl is still used up to the max stale age, 0 to disable
+	CacheMaxStaleSecond int32 `json:"cachemaxstalesecond"`
+}
+
+type HistoryStoreConfig struct {
+	Store string `json:"store"` // file or none
+	Dir   string `json:"dir"`
 }
 
 type SocketServerConfig struct {
 	Addr string `json:"addr"`
 }
 
+type FenceConsumerConfig struct {
+	Transport    string `json:"transport"`    // webhook or memory
+	Sink         string `json:"sink"`         // log or webhook, events are also pushed to WebSocket subscribers
+	SinkEndpoint string `json:"sinkendpoint"` // url of the webhook sink
+}
+
+type DispatchConfig struct {
+	Strategy       string `json:"strategy"`       // scoring strategy of the candidates, weighted or nearest
+	RadiusMeter    int32  `json:"radiusmeter"`    // search radius of the candidates
+	CandidateLimit int32  `json:"candidatelimit"` // max number of candidates scored per match
+	// weights of the weighted strategy, each factor is scored from 0 to 1
+	WeightDistance  float64 `json:"weightdistance"`
+	WeightPriority  float64 `json:"weightpriority"`
+	WeightFreshness float64 `json:"weightfreshness"`
+	WeightFairness  float64 `json:"weightfairness"`
+	// assignments of each provider within the window lower the fairness score of its drivers
+	FairnessWindowSecond int32 `json:"fairnesswindowsecond"`
+	// jobs of the experiment percent are matched with the experiment strategy, for A/B testing
+	ExperimentStrategy string `json:"experimentstrategy"`
+	ExperimentPercent  int32  `json:"experimentpercent"`
+	// a job is offered to the candidates one by one, each offer waits the timeout for accept or decline
+	OfferTimeoutSecond int32 `json:"offertimeoutsecond"`
+	OfferCandidates    int32 `json:"offercandidates"` // max number of drivers offered a job before it waits for a manual assignment
+	AvoidMovingAway    bool  `json:"avoidmovingaway"` // drivers moving away from the pickup are not candidates of the jobs
+}
+
+// ETAServiceTypeConfig is the average speed of a service type
+type ETAServiceTypeConfig struct {
+	ServiceTypeID int32   `json:"servicetypeid"`
+	SpeedKmh      float64 `json:"speedkmh"`
+}
+
+// ETAPeriodConfig multiplies the travel times within the hours of the day, such as rush hours
+type ETAPeriodConfig struct {
+	FromHour   int32   `json:"fromhour"`   // inclusive, from 0 to 23
+	ToHour     int32   `json:"tohour"`     // exclusive, a period ending before it starts ends on the next day
+	Multiplier float64 `json:"multiplier"` // 1.5 takes 50% longer
+}
+
+type ETAConfig struct {
+	Estimator    string                 `json:"estimator"`    // straightline or roadgraph
+	SpeedKmh     float64                `json:"speedkmh"`     // average speed of the service types without a speed
+	DetourFactor float64                `json:"detourfactor"` // road distance over the straight-line distance, straightline only
+	ServiceTypes []ETAServiceTypeConfig `json:"servicetypes"`
+	Periods      []ETAPeriodConfig      `json:"periods"`
+	Timezone     string                 `json:"timezone"` // of the periods, such as Asia/Jakarta, local time of the server when empty
+	// roadgraph only, the roads are loaded from the OSM XML extract.
+	// Points further than the snap distance from a road are estimated in a straight line
+	RoadGraphFile string  `json:"roadgraphfile"`
+	SnapMeter     float64 `json:"snapmeter"`
+	// the nearest drivers of a search get a road ETA, the others are estimated in a straight line
+	RoadMaxEstimates int32 `json:"roadmaxestimates"`
+}
+
 type Configuration struct {
 	Corsconfig           CORSConfig                 `json:"corsconfig"`
 	Udpserver            UDPServerConfig            `json:"udpserver"`
@@ -60,6 +161,12 @@ type Configuration struct {
 	Authserver           AuthServerConfig           `json:"authserver"`
 	Fleetserver          FleetServerConfig          `json:"fleetserver"`
 	Socketserver         SocketServerConfig         `json:"socketserver"`
+	Historystore         HistoryStoreConfig         `json:"historystore"`
+	Fenceconsumer        FenceConsumerConfig        `json:"fenceconsumer"`
+	Dispatch             DispatchConfig             `json:"dispatch"`
+	Positionfilter       PositionFilterConfig       `json:"positionfilter"`
+	Eta                  ETAConfig                  `json:"eta"`
+	Tenants              []TenantConfig             `json:"tenants"`
 }
 
 var c *Configuration
@@ -90,16 +197,54 @@ func GetInstance(env string) (*Configuration, error) {
 
 		// Set defaults
 		v.SetDefault("udpserver.addr", ":9000")
+		v.SetDefault("udpserver.batchsize", 500)
+		v.SetDefault("udpserver.batchwindowms", 50)
 		v.SetDefault("httpserver.addr", ":8000")
 		v.SetDefault("socketserver.addr", ":8010")
 		v.SetDefault("locationremoteserver.remoteaddr", "35.185.186.230:9851")
+		v.SetDefault("locationremoteserver.store", "tile38")
 		v.SetDefault("hookendpoints", []string{"http://localhost:8000/ep1", "http://localhost:8000/ep2"})
 		v.SetDefault("locationremoteserver.searchtier1meter", 5000)
 		v.SetDefault("locationremoteserver.searchtier2meter", 10000)
 		v.SetDefault("locationremoteserver.searchtier3meter", 0)
 		v.SetDefault("locationremoteserver.detectarrivingmeter", 500)
 		v.SetDefault("locationremoteserver.detectarrivedmeter", 50)
+		v.SetDefault("locationremoteserver.heartbeattimeoutsecond", 120)
+		v.SetDefault("locationremoteserver.heartbeatsweepsecond", 30)
+		v.SetDefault("locationremoteserver.motionmovingkmh", 5)
+		v.SetDefault("locationremoteserver.motionstoppedsecond", 300)
+		v.SetDefault("locationremoteserver.defaulttenant", "towing")
 		v.SetDefault("authserver.remoteaddr", "35.240.167.230:8080")
+		v.SetDefault("fleetserver.cachettlsecond", 300)
+		v.SetDefault("fleetserver.cachenegativettlsecond", 30)
+		v.SetDefault("fleetserver.cachemaxstalesecond", 3600)
+		v.SetDefault("historystore.store", "file")
+		v.SetDefault("historystore.dir", "history")
+		v.SetDefault("fenceconsumer.transport", "webhook")
+		v.SetDefault("fenceconsumer.sink", "log")
+		v.SetDefault("dispatch.strategy", "weighted")
+		v.SetDefault("dispatch.radiusmeter", 10000)
+		v.SetDefault("dispatch.candidatelimit", 50)
+		v.SetDefault("dispatch.weightdistance", 0.5)
+		v.SetDefault("dispatch.weightpriority", 0.2)
+		v.SetDefault("dispatch.weightfreshness", 0.1)
+		v.SetDefault("dispatch.weightfairness", 0.2)
+		v.SetDefault("dispatch.fairnesswindowsecond", 86400)
+		v.SetDefault("dispatch.offertimeoutsecond", 30)
+		v.SetDefault("dispatch.offercandidates", 5)
+		v.SetDefault("dispatch.avoidmovingaway", true)
+		v.SetDefault("positionfilter.enabled", true)
+		v.SetDefault("positionfilter.maxspeedkmh", 250)
+		v.SetDefault("positionfilter.maxaccuracymeter", 200)
+		v.SetDefault("positionfilter.smoothingnoisemps", 3)
+		v.SetDefault("positionfilter.defaultaccuracymeter", 15)
+		v.SetDefault("positionfilter.maxrejects", 5)
+		v.SetDefault("positionfilter.trackttlsecond", 300)
+		v.SetDefault("eta.estimator", "straightline")
+		v.SetDefault("eta.speedkmh", 30)
+		v.SetDefault("eta.detourfactor", 1.3)
+		v.SetDefault("eta.snapmeter", 500)
+		v.SetDefault("eta.roadmaxestimates", 20)
 
 		// Read configuration
 		log.Printf("Reading configuration for %s env...\n", env)
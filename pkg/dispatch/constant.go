@@ -0,0 +1,54 @@
+package dispatch
+
+// DispatchStrategy, name of the built-in scoring strategies
+const (
+	Dispatch_Strategy_Weighted string = "weighted" // weighted sum of distance, priority, freshness and fairness
+	Dispatch_Strategy_Nearest  string = "nearest"  // distance only, the order of the nearby search
+)
+
+// DispatchLimit
+const (
+	Dispatch_Shortlist_Limit    int32 = 5     // default number of ranked candidates returned
+	Dispatch_Radius_Meter       int32 = 10000 // radius of the candidates when the radius is not configured
+	Dispatch_Fairness_Limit     int32 = 1000  // page size of the assigned jobs read for the fairness of the providers
+	Dispatch_Freshness_Second   int32 = 120   // age of a driver update scored zero when the heartbeat timeout is disabled
+	Dispatch_Experiment_Buckets int32 = 100   // jobs are split into buckets by id for the experiment strategy
+)
+
+// OfferMessage_Type, type of the offer messages exchanged with the driver over WebSocket
+type OfferMessage_Type string
+
+const (
+	OfferMessage_Type_Offer   OfferMessage_Type = "offer"   // the job is offered to the driver
+	OfferMessage_Type_Expired OfferMessage_Type = "expired" // the offer is no longer valid
+	OfferMessage_Type_Accept  OfferMessage_Type = "accept"  // the driver accepts the offer
+	OfferMessage_Type_Decline OfferMessage_Type = "decline" // the driver declines the offer
+)
+
+// Offer_Status, progress of the offers of a job
+type Offer_Status string
+
+const (
+	Offer_Status_Offering  Offer_Status = "offering"  // the job is offered to the candidates one by one
+	Offer_Status_Assigned  Offer_Status = "assigned"  // a driver accepted and the job is assigned to the driver
+	Offer_Status_Exhausted Offer_Status = "exhausted" // no candidate accepted, the job waits for a manual assignment
+	Offer_Status_Stopped   Offer_Status = "stopped"   // the job is no longer waiting for a driver
+)
+
+// Offer_Result, outcome of the offer to a candidate
+type Offer_Result string
+
+const (
+	Offer_Result_Accepted    Offer_Result = "accepted"
+	Offer_Result_Declined    Offer_Result = "declined"
+	Offer_Result_Expired     Offer_Result = "expired"     // no answer within the offer timeout
+	Offer_Result_Unavailable Offer_Result = "unavailable" // the driver cannot be offered the job, e.g. no longer available
+	Offer_Result_Unreachable Offer_Result = "unreachable" // the driver is not connected
+	Offer_Result_Failed      Offer_Result = "failed"      // the driver accepted, but the job cannot be assigned to the driver
+)
+
+// DispatchOffer
+const (
+	Dispatch_Offer_Timeout_Second   int32 = 30   // offer timeout when the timeout is not configured
+	Dispatch_Offer_Retention_Second int64 = 3600 // the progress of the offers is kept after the offers ended
+)
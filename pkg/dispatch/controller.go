@@ -0,0 +1,332 @@
+package dispatch
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/config"
+	"github.com/iknowhtml/locationtracker/pkg/job"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+type DispatchController struct {
+	locationService    *location.LocationService
+	locationController *location.LocationController
+	jobController      *job.JobController
+}
+
+func (dc *DispatchController) Init() error {
+	dc.locationService = new(location.LocationService)
+	err := dc.locationService.Init(nil)
+	if err != nil {
+		return err
+	}
+	dc.locationController = new(location.LocationController)
+	err = dc.locationController.Init()
+	if err != nil {
+		return err
+	}
+	dc.jobController = new(job.JobController)
+	err = dc.jobController.Init()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Match ranks the available drivers around the pickup point which meet the requirements,
+// and returns the shortlist of the best candidates
+func (dc *DispatchController) Match(req *MatchRequestObject) (*MatchObject, error) {
+	// load system configuration based on environment, singleton pattern
+	configuration, err := config.GetInstance("")
+	if configuration == nil {
+		return nil, err
+	}
+
+	err = ValidateMatchRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	radius := req.RadiusMeter
+	if radius <= 0 {
+		radius = configuration.Dispatch.RadiusMeter
+	}
+	if radius <= 0 {
+		radius = Dispatch_Radius_Meter
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = Dispatch_Shortlist_Limit
+	}
+	// every candidate is scored, so the best candidate is not the nearest one only
+	candidateLimit := configuration.Dispatch.CandidateLimit
+	if candidateLimit < limit {
+		candidateLimit = limit
+	}
+	priority := req.Priority
+	if priority == "" {
+		priority = location.NearbySearch_Priority_All
+	}
+
+	strategyName := matchStrategy(req, &configuration.Dispatch)
+	strategy, err := GetStrategy(strategyName)
+	if err != nil {
+		return nil, err
+	}
+
+	motion := &location.SearchMotionObject{Motions: req.Motions, MaxSpeedKmh: req.MaxSpeedKmh}
+	if req.AvoidMovingAway {
+		motion.MovingAwayFrom = &common.GeoPoint{Lat: req.Lat, Lng: req.Lng}
+	}
+	// only the drivers of the tenant are candidates
+	locationController, err := dc.locationController.ForTenant(req.Fleet)
+	if err != nil {
+		return nil, err
+	}
+	res, err := locationController.SearchDriverCandidates(
+		candidateLimit, req.Lat, req.Lng, radius, req.ProviderIDs, req.ServiceTypeID, req.ServiceID, priority,
+		&location.SearchExcludeObject{DriverIDs: req.ExcludeDriverIDs, ProviderIDs: req.ExcludeProviderIDs}, motion)
+	if err != nil {
+		return nil, err
+	}
+
+	// check if ok is false
+	if res.Ok == false {
+		return nil, errors.New(res.Error)
+	}
+
+	candidates := make([]*CandidateObject, len(res.Objects))
+	providerIDs := []int32{}
+	providers := map[int32]bool{}
+	for i, o := range res.Objects {
+		candidates[i] = &CandidateObject{
+			DriverID:             o.Fields.DriverID,
+			ProviderID:           o.Fields.ProviderID,
+			Priority:             o.Fields.Priority,
+			Location:             o.Object,
+			Distance:             o.Distance,
+			ETASeconds:           o.ETASeconds,
+			LastUpdatedTimestamp: o.Fields.LastUpdatedTimestamp,
+			Speed:                o.Fields.Speed,
+			Bearing:              o.Fields.Bearing,
+			Motion:               o.Fields.Motion,
+		}
+		if !providers[o.Fields.ProviderID] {
+			providers[o.Fields.ProviderID] = true
+			providerIDs = append(providerIDs, o.Fields.ProviderID)
+		}
+	}
+
+	assignments, err := dc.providerAssignments(providerIDs, configuration.Dispatch.FairnessWindowSecond)
+	if err != nil {
+		return nil, err
+	}
+
+	freshness := configuration.Locationremoteserver.HeartbeatTimeoutSecond
+	if freshness <= 0 {
+		freshness = Dispatch_Freshness_Second
+	}
+	scoreFactors(candidates, assignments, radius, freshness, time.Now().Unix())
+
+	for _, c := range candidates {
+		c.Score = strategy.Score(req, c)
+	}
+	// the best score first, the nearest first for the same score
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Distance < candidates[j].Distance
+	})
+	if int32(len(candidates)) > limit {
+		candidates = candidates[:limit]
+	}
+	for i, c := range candidates {
+		c.Rank = int32(i + 1)
+	}
+
+	log.Printf("Dispatch match: job %d, strategy %s, %d of %d candidates\n", req.JobID, strategyName, len(candidates), len(res.Objects))
+	return &MatchObject{
+		JobID:      req.JobID,
+		Strategy:   strategyName,
+		Count:      int32(len(candidates)),
+		Candidates: candidates,
+	}, nil
+}
+
+// MatchJob ranks the drivers for the job waiting for a driver, the candidates are limited to the provider of the job when set
+func (dc *DispatchController) MatchJob(jobID int32, limit int32, strategy string) (*MatchObject, error) {
+	// load system configuration based on environment, singleton pattern
+	configuration, err := config.GetInstance("")
+	if configuration == nil {
+		return nil, err
+	}
+
+	jobObj, err := dc.jobController.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if jobObj.Status != job.Job_Status_Created {
+		return nil, &job.JobStatusError{Status: jobObj.Status, Action: "matched"}
+	}
+
+	req := &MatchRequestObject{
+		JobID:         jobObj.ID,
+		Fleet:         jobObj.Fleet,
+		Lat:           jobObj.Pickup.Lat,
+		Lng:           jobObj.Pickup.Lng,
+		ServiceTypeID: jobObj.ServiceTypeID,
+		ServiceID:     jobObj.ServiceID,
+		Priority:      location.NearbySearch_Priority_All,
+		Limit:         limit,
+		Strategy:      strategy,
+		// a driver heading away is far longer to reach the pickup than the distance tells
+		AvoidMovingAway: configuration.Dispatch.AvoidMovingAway,
+	}
+	if jobObj.ProviderID != 0 {
+		req.ProviderIDs = []int32{jobObj.ProviderID}
+	}
+
+	return dc.Match(req)
+}
+
+// ValidateMatchRequest checks the pickup point, the limit and the fleet of the match
+func ValidateMatchRequest(req *MatchRequestObject) error {
+	p := common.GeoPoint{Lat: req.Lat, Lng: req.Lng}
+	if p.IsZero() {
+		return errors.New("Pickup location is missing, but required")
+	}
+	if err := p.Validate(); err != nil {
+		return errors.New("Pickup location is invalid: " + err.Error())
+	}
+	if req.Limit < 0 || req.Limit > location.Search_Max_Limit {
+		return errors.New("Limit is out of range")
+	}
+	for _, motion := range req.Motions {
+		if motion != location.DriverMotion_MOVING && motion != location.DriverMotion_IDLE && motion != location.DriverMotion_STOPPED {
+			return errors.New("Motion is invalid")
+		}
+	}
+	if req.MaxSpeedKmh < 0 {
+		return errors.New("Max speed is out of range")
+	}
+	if _, err := location.GetTenant(req.Fleet); err != nil {
+		return err
+	}
+	return nil
+}
+
+// matchStrategy returns the strategy of the request, or the configured one.
+// Jobs in the buckets of the experiment percent are matched with the experiment strategy
+func matchStrategy(req *MatchRequestObject, dispatchConfig *config.DispatchConfig) string {
+	if req.Strategy != "" {
+		return req.Strategy
+	}
+	if dispatchConfig.ExperimentStrategy != "" && req.JobID > 0 &&
+		req.JobID%Dispatch_Experiment_Buckets < dispatchConfig.ExperimentPercent {
+		return dispatchConfig.ExperimentStrategy
+	}
+	if dispatchConfig.Strategy == "" {
+		return Dispatch_Strategy_Weighted
+	}
+	return dispatchConfig.Strategy
+}
+
+// weightedStrategy returns the weighted strategy with the configured weights
+func weightedStrategy() (Strategy, error) {
+	// load system configuration based on environment, singleton pattern
+	configuration, err := config.GetInstance("")
+	if configuration == nil {
+		return nil, err
+	}
+
+	return &WeightedStrategy{
+		Distance:  configuration.Dispatch.WeightDistance,
+		Priority:  configuration.Dispatch.WeightPriority,
+		Freshness: configuration.Dispatch.WeightFreshness,
+		Fairness:  configuration.Dispatch.WeightFairness,
+	}, nil
+}
+
+// providerAssignments counts the jobs assigned to the drivers of each provider within the window
+func (dc *DispatchController) providerAssignments(providerIDs []int32, window int32) (map[int32]int32, error) {
+	assignments := map[int32]int32{}
+	if len(providerIDs) == 0 || window <= 0 {
+		return assignments, nil
+	}
+
+	var vals []interface{}
+	for _, providerID := range providerIDs {
+		vals = append(vals, providerID)
+	}
+	whereList := []location.WhereConditionFieldObject{
+		location.WhereConditionFieldObject{FieldName: "assignedtime", Min: time.Now().Unix() - int64(window), Max: "+inf"},
+	}
+	whereInList := []location.WhereInConditionFieldObject{
+		location.WhereInConditionFieldObject{FieldName: "driverproviderid", Values: vals},
+	}
+
+	// every page is read, a truncated count would rank the providers wrongly
+	var cursor int32
+	for {
+		res, err := dc.locationService.ScanObject(location.Object_Collection_Job, Dispatch_Fairness_Limit, cursor, whereList, whereInList)
+		if err != nil {
+			return nil, err
+		}
+
+		// check if ok is false
+		if res.Ok == false {
+			return nil, errors.New(res.Error)
+		}
+
+		for i := range res.Objects {
+			assignments[int32(res.FieldMap(i)["driverproviderid"])]++
+		}
+
+		// the last page has no cursor
+		if res.Cursor == 0 || len(res.Objects) == 0 {
+			break
+		}
+		cursor = res.Cursor
+	}
+	return assignments, nil
+}
+
+// scoreFactors scores each factor of the candidates from 0 to 1
+func scoreFactors(candidates []*CandidateObject, assignments map[int32]int32, radius int32, freshness int32, timeNow int64) {
+	maxAssignments := int32(0)
+	for _, n := range assignments {
+		if n > maxAssignments {
+			maxAssignments = n
+		}
+	}
+
+	for _, c := range candidates {
+		c.ProviderAssignments = assignments[c.ProviderID]
+
+		c.Factors.Distance = clamp(1 - c.Distance/float64(radius))
+		if c.Priority == int32(location.DriverPriority_YES) {
+			c.Factors.Priority = 1
+		}
+		c.Factors.Freshness = clamp(1 - float64(timeNow-c.LastUpdatedTimestamp)/float64(freshness))
+		// the provider with the most assignments scores zero, providers without assignment score one
+		c.Factors.Fairness = 1
+		if maxAssignments > 0 {
+			c.Factors.Fairness = clamp(1 - float64(c.ProviderAssignments)/float64(maxAssignments))
+		}
+	}
+}
+
+func clamp(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
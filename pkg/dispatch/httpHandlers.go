@@ -0,0 +1,174 @@
+package dispatch
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/job"
+)
+
+// POST body: { "jobid": 0, "fleet": "towing", "lat": 3.1, "lng": 101.6, "servicetypeid": 1, "serviceid": 1, "priority": [1|0|all],
+// "providerids": [1, 2], "excludedriverids": [3], "excludeproviderids": [4], "radius": 10000, "limit": 5, "strategy": [weighted|nearest],
+// "motions": [1|2|3], "maxspeedkmh": 60, "avoidmovingaway": true }
+// only lat and lng are required
+func HandleMatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	// reading POST body
+	log.Println("Decoding request json body")
+	decoder := json.NewDecoder(r.Body)
+	var reqObj MatchRequestObject
+	err := decoder.Decode(&reqObj)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+	log.Println(reqObj)
+
+	if err := ValidateMatchRequest(&reqObj); err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	dispatchController := new(DispatchController)
+	err = dispatchController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := dispatchController.Match(&reqObj)
+	if err != nil {
+		handleDispatchErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &MatchResultObject{Match: res})
+}
+
+// url: job id ("id") (required)
+// Url Param: number of candidates (limit) (optional, default 5)
+// Url Param: scoring strategy (strategy) (optional, the configured strategy)
+func HandleMatchJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	jobID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil || jobID <= 0 {
+		common.HandleStatus400Response(w, "Job id is invalid")
+		return
+	}
+
+	// get Url Param
+	queryValues := r.URL.Query()
+	log.Println(queryValues)
+
+	var limit int64
+	if queryValues.Get("limit") != "" {
+		limit, err = strconv.ParseInt(queryValues.Get("limit"), 10, 32)
+		if err != nil || limit <= 0 {
+			common.HandleStatus400Response(w, "Limit is invalid")
+			return
+		}
+	}
+
+	dispatchController := new(DispatchController)
+	err = dispatchController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := dispatchController.MatchJob(int32(jobID), int32(limit), queryValues.Get("strategy"))
+	if err != nil {
+		handleDispatchErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &MatchResultObject{Match: res})
+}
+
+func handleDispatchErrorResponse(w http.ResponseWriter, err error) {
+	if _, ok := err.(*job.JobStatusError); ok {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	switch err {
+	case job.ErrJobNotFound, ErrOfferNotFound, ErrOfferNoCandidate:
+		common.HandleStatusNotFoundResponse(w, err.Error())
+	case ErrOfferInProgress:
+		common.HandleStatusConflictResponse(w, err.Error())
+	case ErrStrategyNotSupported:
+		common.HandleStatus400Response(w, err.Error())
+	default:
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+	}
+}
+
+// url: job id ("id") (required)
+// Url Param: scoring strategy (strategy) (optional, the configured strategy)
+// the job is offered to the candidates in the background, the progress is returned by HandleGetOffer
+func HandleOfferJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	jobID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil || jobID <= 0 {
+		common.HandleStatus400Response(w, "Job id is invalid")
+		return
+	}
+
+	dispatchController := new(DispatchController)
+	err = dispatchController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := dispatchController.OfferJob(int32(jobID), r.URL.Query().Get("strategy"))
+	if err != nil {
+		handleDispatchErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &OfferResultObject{Offer: res})
+}
+
+// url: job id ("id") (required)
+func HandleGetOffer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	jobID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil || jobID <= 0 {
+		common.HandleStatus400Response(w, "Job id is invalid")
+		return
+	}
+
+	res, err := GetOffer(int32(jobID))
+	if err != nil {
+		handleDispatchErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &OfferResultObject{Offer: res})
+}
@@ -0,0 +1,26 @@
+package dispatch
+
+import (
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+func NewRouter() []common.Route {
+
+	dispatchRouter := []common.Route{
+		common.Route{"Match", "POST", "/dispatch/match", HandleMatch},
+		common.Route{"MatchJob", "GET", "/jobs/{id:[0-9]+}/candidates", HandleMatchJob},
+	}
+
+	return dispatchRouter
+}
+
+// NewOfferRouter returns the offer routes, served by the socket server which holds the connections of the drivers
+func NewOfferRouter() []common.Route {
+
+	offerRouter := []common.Route{
+		common.Route{"OfferJob", "POST", "/dispatch/jobs/{id:[0-9]+}/offer", HandleOfferJob},
+		common.Route{"GetOffer", "GET", "/dispatch/jobs/{id:[0-9]+}/offer", HandleGetOffer},
+	}
+
+	return offerRouter
+}
@@ -0,0 +1,117 @@
+package dispatch
+
+import (
+	"github.com/iknowhtml/locationtracker/pkg/job"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+// MatchRequestObject is the pickup point and the requirements of a job to match drivers for
+type MatchRequestObject struct {
+	JobID         int32                          `json:"jobid"` // optional, splits the jobs for the experiment strategy
+	Fleet         string                         `json:"fleet"` // tenant of the candidates, the default tenant when empty
+	Lat           float64                        `json:"lat"`
+	Lng           float64                        `json:"lng"`
+	ServiceTypeID int32                          `json:"servicetypeid"`
+	ServiceID     int32                          `json:"serviceid"`
+	Priority      location.NearbySearch_Priority `json:"priority"`    // 1|0|all, only the drivers of the priority are candidates
+	ProviderIDs   []int32                        `json:"providerids"` // only the drivers of the providers are candidates when set
+	RadiusMeter   int32                          `json:"radius"`
+	Limit         int32                          `json:"limit"`
+	Strategy      string                         `json:"strategy"` // scoring strategy, the configured strategy when empty
+	// drivers and providers which are not candidates, e.g. drivers who declined the job or providers blocked by the customer
+	ExcludeDriverIDs   []int32 `json:"excludedriverids"`
+	ExcludeProviderIDs []int32 `json:"excludeproviderids"`
+	// motion of the candidates, e.g. only idle drivers, and whether the drivers moving away from the pickup are left out
+	Motions         []location.DriverMotion `json:"motions"`
+	MaxSpeedKmh     float64                 `json:"maxspeedkmh"`
+	AvoidMovingAway bool                    `json:"avoidmovingaway"`
+}
+
+// FactorsObject is the score of each factor of a candidate, from 0 (worst) to 1 (best)
+type FactorsObject struct {
+	Distance  float64 `json:"distance"`
+	Priority  float64 `json:"priority"`
+	Freshness float64 `json:"freshness"` // time since the last update of the driver
+	Fairness  float64 `json:"fairness"`  // assignments of the provider of the driver within the fairness window
+}
+
+// CandidateObject is a driver which can be offered the job
+type CandidateObject struct {
+	DriverID             int32                           `json:"driverid"`
+	ProviderID           int32                           `json:"providerid"`
+	Priority             int32                           `json:"priority"`
+	Location             location.LocationResponseObject `json:"location"`
+	Distance             float64                         `json:"distance"`    // meter
+	ETASeconds           int64                           `json:"eta_seconds"` // travel time to the pickup
+	LastUpdatedTimestamp int64                           `json:"lastupdatedtime"`
+	Speed                float64                         `json:"speed"` // km/h
+	Bearing              float64                         `json:"bearing"`
+	Motion               location.DriverMotion           `json:"motion"`
+	ProviderAssignments  int32                           `json:"providerassignments"`
+	Factors              FactorsObject                   `json:"factors"`
+	Score                float64                         `json:"score"`
+	Rank                 int32                           `json:"rank"`
+}
+
+// MatchObject is the ranked shortlist of the candidates, the best first
+type MatchObject struct {
+	JobID      int32              `json:"jobid,omitempty"`
+	Strategy   string             `json:"strategy"`
+	Count      int32              `json:"count"`
+	Candidates []*CandidateObject `json:"candidates"`
+}
+
+type MatchResultObject struct {
+	Match interface{} `json:"match"`
+}
+
+func (o *MatchResultObject) SetResult(result interface{}) {
+	o.Match = result
+}
+
+// OfferObject is the offer message pushed to the driver
+type OfferObject struct {
+	Type             OfferMessage_Type   `json:"type"`
+	Fleet            string              `json:"fleet,omitempty"` // tenant of the driver
+	JobID            int32               `json:"jobid"`
+	DriverID         int32               `json:"driverid"`
+	Pickup           *job.JobPointObject `json:"pickup,omitempty"`
+	Dropoff          *job.JobPointObject `json:"dropoff,omitempty"`
+	Distance         float64             `json:"distance,omitempty"`    // meter, from the driver to the pickup
+	ETASeconds       int64               `json:"eta_seconds,omitempty"` // travel time from the driver to the pickup
+	ExpiresTimestamp int64               `json:"expirestime,omitempty"` // the offer expires without an answer at the time
+}
+
+// OfferResponseObject is the answer message of the driver to the offer
+type OfferResponseObject struct {
+	Type  OfferMessage_Type `json:"type"` // accept|decline
+	JobID int32             `json:"jobid"`
+}
+
+// OfferAttemptObject is the offer of the job to a candidate
+type OfferAttemptObject struct {
+	DriverID          int32        `json:"driverid"`
+	Rank              int32        `json:"rank"`
+	Result            Offer_Result `json:"result,omitempty"` // empty while waiting for the answer
+	OfferedTimestamp  int64        `json:"offeredtime"`
+	AnsweredTimestamp int64        `json:"answeredtime,omitempty"`
+}
+
+// OfferCascadeObject is the progress of the offers of a job, the candidates are offered the job in rank order
+type OfferCascadeObject struct {
+	JobID            int32                 `json:"jobid"`
+	Status           Offer_Status          `json:"status"`
+	Strategy         string                `json:"strategy"`
+	DriverID         int32                 `json:"driverid,omitempty"` // driver assigned to the job
+	Attempts         []*OfferAttemptObject `json:"attempts"`
+	StartedTimestamp int64                 `json:"startedtime"`
+	EndedTimestamp   int64                 `json:"endedtime,omitempty"`
+}
+
+type OfferResultObject struct {
+	Offer interface{} `json:"offer"`
+}
+
+func (o *OfferResultObject) SetResult(result interface{}) {
+	o.Offer = result
+}
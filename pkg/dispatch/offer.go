@@ -0,0 +1,313 @@
+package dispatch
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/config"
+	"github.com/iknowhtml/locationtracker/pkg/job"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+// OfferSender pushes the offer messages to the driver, an error is returned when the driver cannot receive the message
+type OfferSender interface {
+	SendOffer(offer *OfferObject) error
+}
+
+// OfferSenderFunc turns a function into an OfferSender
+type OfferSenderFunc func(offer *OfferObject) error
+
+func (f OfferSenderFunc) SendOffer(offer *OfferObject) error {
+	return f(offer)
+}
+
+var (
+	ErrOfferSenderNotSet = errors.New("Offer sender is not set, jobs are offered by the socket server only")
+	ErrOfferInProgress   = errors.New("Job is being offered")
+	ErrOfferNotFound     = errors.New("Offer not found")
+	ErrOfferNoCandidate  = errors.New("No candidate found for the job")
+)
+
+// pendingOffer is an offer waiting for the answer of the driver
+type pendingOffer struct {
+	jobID  int32
+	answer chan bool
+}
+
+var offerSender OfferSender
+var offerCascades = map[int32]*OfferCascadeObject{}
+var pendingOffers = map[location.TenantDriverKey]*pendingOffer{}
+var offerMu sync.Mutex
+
+// SetOfferSender sets the sender of the offers, the jobs cannot be offered until it is set
+func SetOfferSender(sender OfferSender) {
+	offerMu.Lock()
+	defer offerMu.Unlock()
+	offerSender = sender
+}
+
+// RespondOffer passes the answer of the driver of the fleet (tenant) to the offer waiting for it
+func RespondOffer(fleet string, driverID int32, resp *OfferResponseObject) error {
+	if resp.Type != OfferMessage_Type_Accept && resp.Type != OfferMessage_Type_Decline {
+		return errors.New("Offer response type not supported: " + string(resp.Type))
+	}
+	tenant, err := location.GetTenant(fleet)
+	if err != nil {
+		return err
+	}
+	key := tenant.DriverKey(driverID)
+
+	offerMu.Lock()
+	pending, ok := pendingOffers[key]
+	if !ok || pending.jobID != resp.JobID {
+		offerMu.Unlock()
+		return ErrOfferNotFound
+	}
+	delete(pendingOffers, key)
+	offerMu.Unlock()
+
+	// buffered, the offer reads it even after the timeout
+	pending.answer <- resp.Type == OfferMessage_Type_Accept
+	return nil
+}
+
+// GetOffer returns a copy of the progress of the offers of the job
+func GetOffer(jobID int32) (*OfferCascadeObject, error) {
+	offerMu.Lock()
+	defer offerMu.Unlock()
+
+	cascade, ok := offerCascades[jobID]
+	if !ok {
+		return nil, ErrOfferNotFound
+	}
+	return cascade.copy(), nil
+}
+
+// OfferJob offers the job waiting for a driver to the ranked candidates one by one, until a driver accepts it.
+// A driver offered the job is set offered, so it is not found by the other searches until the offer ends.
+// The offers run in the background, the progress is returned by GetOffer
+func (dc *DispatchController) OfferJob(jobID int32, strategy string) (*OfferCascadeObject, error) {
+	// load system configuration based on environment, singleton pattern
+	configuration, err := config.GetInstance("")
+	if configuration == nil {
+		return nil, err
+	}
+
+	offerMu.Lock()
+	sender := offerSender
+	inProgress := false
+	if cascade, ok := offerCascades[jobID]; ok {
+		inProgress = cascade.Status == Offer_Status_Offering
+	}
+	offerMu.Unlock()
+	if sender == nil {
+		return nil, ErrOfferSenderNotSet
+	}
+	if inProgress {
+		return nil, ErrOfferInProgress
+	}
+
+	jobObj, err := dc.jobController.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := dc.MatchJob(jobID, configuration.Dispatch.OfferCandidates, strategy)
+	if err != nil {
+		return nil, err
+	}
+	if len(match.Candidates) == 0 {
+		return nil, ErrOfferNoCandidate
+	}
+
+	cascade := &OfferCascadeObject{
+		JobID:            jobID,
+		Status:           Offer_Status_Offering,
+		Strategy:         match.Strategy,
+		Attempts:         []*OfferAttemptObject{},
+		StartedTimestamp: time.Now().Unix(),
+	}
+
+	offerMu.Lock()
+	if c, ok := offerCascades[jobID]; ok && c.Status == Offer_Status_Offering {
+		offerMu.Unlock()
+		return nil, ErrOfferInProgress
+	}
+	pruneOfferCascades(cascade.StartedTimestamp)
+	offerCascades[jobID] = cascade
+	res := cascade.copy()
+	offerMu.Unlock()
+
+	timeout := configuration.Dispatch.OfferTimeoutSecond
+	if timeout <= 0 {
+		timeout = Dispatch_Offer_Timeout_Second
+	}
+	go dc.runOffers(sender, cascade, jobObj, match.Candidates, time.Duration(timeout)*time.Second)
+
+	log.Printf("Job %d: offering to %d candidates\n", jobID, len(match.Candidates))
+	return res, nil
+}
+
+// runOffers offers the job to the candidates in rank order, the next candidate is offered when the offer is
+// declined or expired, or the driver cannot be offered
+func (dc *DispatchController) runOffers(sender OfferSender, cascade *OfferCascadeObject, jobObj *job.JobObject, candidates []*CandidateObject, timeout time.Duration) {
+	status := Offer_Status_Exhausted
+	for _, c := range candidates {
+		attempt := &OfferAttemptObject{DriverID: c.DriverID, Rank: c.Rank, OfferedTimestamp: time.Now().Unix()}
+		offerMu.Lock()
+		cascade.Attempts = append(cascade.Attempts, attempt)
+		offerMu.Unlock()
+
+		result := dc.offer(sender, jobObj, c, timeout)
+		if result == Offer_Result_Accepted {
+			_, err := dc.jobController.AssignJob(jobObj.ID, c.DriverID)
+			if err != nil {
+				log.Printf("Job %d: failed to assign driver %d: %v\n", jobObj.ID, c.DriverID, err)
+				result = Offer_Result_Failed
+				dc.releaseDriver(jobObj, c.DriverID)
+				sendExpired(sender, jobObj, c.DriverID)
+			}
+		}
+
+		offerMu.Lock()
+		attempt.Result = result
+		attempt.AnsweredTimestamp = time.Now().Unix()
+		if result == Offer_Result_Accepted {
+			cascade.DriverID = c.DriverID
+		}
+		offerMu.Unlock()
+		log.Printf("Job %d: offer to driver %d %s\n", jobObj.ID, c.DriverID, result)
+
+		if result == Offer_Result_Accepted {
+			status = Offer_Status_Assigned
+			break
+		}
+
+		// stop when the job is assigned, completed or cancelled by others
+		current, err := dc.jobController.GetJob(jobObj.ID)
+		if err != nil || current.Status != job.Job_Status_Created {
+			status = Offer_Status_Stopped
+			break
+		}
+	}
+
+	offerMu.Lock()
+	cascade.Status = status
+	cascade.EndedTimestamp = time.Now().Unix()
+	offerMu.Unlock()
+	log.Printf("Job %d: offers ended, %s\n", jobObj.ID, status)
+}
+
+// offer holds the driver for the job and waits for the answer of the driver within the timeout,
+// the driver is released unless the offer is accepted
+func (dc *DispatchController) offer(sender OfferSender, jobObj *job.JobObject, c *CandidateObject, timeout time.Duration) Offer_Result {
+	// only an available driver can be set offered, so the driver is not offered two jobs
+	locationController, err := dc.locationController.ForTenant(jobObj.Fleet)
+	if err == nil {
+		_, err = locationController.TransitionDriverStatus(c.DriverID, location.DriverStatus_OFFERED, jobObj.ID)
+	}
+	if err != nil {
+		log.Printf("Job %d: driver %d cannot be offered: %v\n", jobObj.ID, c.DriverID, err)
+		return Offer_Result_Unavailable
+	}
+
+	// the drivers with the same id in two tenants answer their own offers
+	key := locationController.Tenant().DriverKey(c.DriverID)
+	pending := &pendingOffer{jobID: jobObj.ID, answer: make(chan bool, 1)}
+	offerMu.Lock()
+	pendingOffers[key] = pending
+	offerMu.Unlock()
+
+	err = sender.SendOffer(&OfferObject{
+		Type:             OfferMessage_Type_Offer,
+		Fleet:            jobObj.Fleet,
+		JobID:            jobObj.ID,
+		DriverID:         c.DriverID,
+		Pickup:           &jobObj.Pickup,
+		Dropoff:          &jobObj.Dropoff,
+		Distance:         c.Distance,
+		ETASeconds:       c.ETASeconds,
+		ExpiresTimestamp: time.Now().Add(timeout).Unix(),
+	})
+	if err != nil {
+		log.Printf("Job %d: failed to send offer to driver %d: %v\n", jobObj.ID, c.DriverID, err)
+		removePendingOffer(key, pending)
+		dc.releaseDriver(jobObj, c.DriverID)
+		return Offer_Result_Unreachable
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case accepted := <-pending.answer:
+		if accepted {
+			return Offer_Result_Accepted
+		}
+		dc.releaseDriver(jobObj, c.DriverID)
+		return Offer_Result_Declined
+	case <-timer.C:
+		removePendingOffer(key, pending)
+		// the answer may arrive just before the offer is removed
+		select {
+		case accepted := <-pending.answer:
+			if accepted {
+				return Offer_Result_Accepted
+			}
+			dc.releaseDriver(jobObj, c.DriverID)
+			return Offer_Result_Declined
+		default:
+		}
+		dc.releaseDriver(jobObj, c.DriverID)
+		sendExpired(sender, jobObj, c.DriverID)
+		return Offer_Result_Expired
+	}
+}
+
+// releaseDriver sets the driver offered the job available again, the lifecycle checks the driver is still on the job
+func (dc *DispatchController) releaseDriver(jobObj *job.JobObject, driverID int32) {
+	locationController, err := dc.locationController.ForTenant(jobObj.Fleet)
+	if err == nil {
+		_, err = locationController.TransitionDriverStatus(driverID, location.DriverStatus_AVAILABLE, jobObj.ID)
+	}
+	if err != nil {
+		log.Printf("Job %d: failed to release driver %d: %v\n", jobObj.ID, driverID, err)
+	}
+}
+
+// pruneOfferCascades removes the progress of the offers ended before the retention, called with the lock held
+func pruneOfferCascades(timeNow int64) {
+	for jobID, cascade := range offerCascades {
+		if cascade.EndedTimestamp > 0 && timeNow-cascade.EndedTimestamp > Dispatch_Offer_Retention_Second {
+			delete(offerCascades, jobID)
+		}
+	}
+}
+
+func removePendingOffer(key location.TenantDriverKey, pending *pendingOffer) {
+	offerMu.Lock()
+	defer offerMu.Unlock()
+	if pendingOffers[key] == pending {
+		delete(pendingOffers, key)
+	}
+}
+
+// sendExpired tells the driver the offer of the job is no longer valid
+func sendExpired(sender OfferSender, jobObj *job.JobObject, driverID int32) {
+	err := sender.SendOffer(&OfferObject{Type: OfferMessage_Type_Expired, Fleet: jobObj.Fleet, JobID: jobObj.ID, DriverID: driverID})
+	if err != nil {
+		log.Printf("Job %d: failed to send expired offer to driver %d: %v\n", jobObj.ID, driverID, err)
+	}
+}
+
+func (o *OfferCascadeObject) copy() *OfferCascadeObject {
+	c := *o
+	c.Attempts = make([]*OfferAttemptObject, len(o.Attempts))
+	for i, a := range o.Attempts {
+		attempt := *a
+		c.Attempts[i] = &attempt
+	}
+	return &c
+}
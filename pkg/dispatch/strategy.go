@@ -0,0 +1,75 @@
+package dispatch
+
+import (
+	"errors"
+	"sync"
+)
+
+// Strategy scores a candidate of a job, candidates are ranked from the highest score
+type Strategy interface {
+	Score(req *MatchRequestObject, candidate *CandidateObject) float64
+}
+
+// StrategyFunc turns a function into a Strategy
+type StrategyFunc func(req *MatchRequestObject, candidate *CandidateObject) float64
+
+func (f StrategyFunc) Score(req *MatchRequestObject, candidate *CandidateObject) float64 {
+	return f(req, candidate)
+}
+
+// WeightedStrategy scores the weighted average of the factors of the candidate
+type WeightedStrategy struct {
+	Distance  float64
+	Priority  float64
+	Freshness float64
+	Fairness  float64
+}
+
+func (s *WeightedStrategy) Score(req *MatchRequestObject, candidate *CandidateObject) float64 {
+	total := s.Distance + s.Priority + s.Freshness + s.Fairness
+	if total <= 0 {
+		return 0
+	}
+	f := candidate.Factors
+	return (s.Distance*f.Distance + s.Priority*f.Priority + s.Freshness*f.Freshness + s.Fairness*f.Fairness) / total
+}
+
+var ErrStrategyNotSupported = errors.New("Strategy not supported")
+
+var strategies = map[string]Strategy{}
+var strategyMu sync.RWMutex
+
+// RegisterStrategy adds or replaces the strategy of the name, so the strategies can be compared by name
+func RegisterStrategy(name string, strategy Strategy) error {
+	if name == "" {
+		return errors.New("Strategy name is empty")
+	}
+	if strategy == nil {
+		return errors.New("Strategy is nil")
+	}
+	strategyMu.Lock()
+	defer strategyMu.Unlock()
+	strategies[name] = strategy
+	return nil
+}
+
+// GetStrategy returns the registered strategy, or the built-in strategy of the name
+func GetStrategy(name string) (Strategy, error) {
+	strategyMu.RLock()
+	strategy, ok := strategies[name]
+	strategyMu.RUnlock()
+	if ok {
+		return strategy, nil
+	}
+
+	switch name {
+	case Dispatch_Strategy_Weighted:
+		return weightedStrategy()
+	case Dispatch_Strategy_Nearest:
+		return StrategyFunc(func(req *MatchRequestObject, candidate *CandidateObject) float64 {
+			return candidate.Factors.Distance
+		}), nil
+	default:
+		return nil, ErrStrategyNotSupported
+	}
+}
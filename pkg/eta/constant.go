@@ -0,0 +1,41 @@
+package eta
+
+// Estimator_Type
+type Estimator_Type string
+
+const (
+	Estimator_Type_StraightLine Estimator_Type = "straightline" // default, straight-line distance at the average speed of the service type
+	Estimator_Type_RoadGraph    Estimator_Type = "roadgraph"    // shortest time on the roads of a local OSM extract
+)
+
+// defaults of the estimators when not configured
+const (
+	ETA_Speed_Kmh     float64 = 30
+	ETA_Detour_Factor float64 = 1.3
+	ETA_Snap_Meter    float64 = 500
+)
+
+// max number of road nodes visited by a route search before the points are estimated in a straight line
+const Road_Max_Visited_Nodes = 200000
+
+// default max number of drivers of a search with a road ETA, the others are estimated in a straight line
+const Road_Max_Estimates int32 = 20
+
+// Road_Speed_Kmh is the speed of the OSM highway types without a maxspeed, highways of other types are not routed
+var Road_Speed_Kmh = map[string]float64{
+	"motorway":       90,
+	"motorway_link":  60,
+	"trunk":          70,
+	"trunk_link":     50,
+	"primary":        50,
+	"primary_link":   40,
+	"secondary":      40,
+	"secondary_link": 35,
+	"tertiary":       35,
+	"tertiary_link":  30,
+	"unclassified":   30,
+	"residential":    25,
+	"living_street":  10,
+	"service":        15,
+	"road":           25,
+}
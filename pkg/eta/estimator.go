@@ -0,0 +1,130 @@
+package eta
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/config"
+)
+
+var ErrNoRoute = errors.New("No route between the points")
+
+// Estimator estimates the travel time between two points
+type Estimator interface {
+	// Estimate returns the travel time in seconds from the point to the point by the service type, starting at the time
+	Estimate(from common.GeoPoint, to common.GeoPoint, serviceTypeID int32, at time.Time) (int64, error)
+}
+
+// EstimatorFunc turns a function into an Estimator
+type EstimatorFunc func(from common.GeoPoint, to common.GeoPoint, serviceTypeID int32, at time.Time) (int64, error)
+
+func (f EstimatorFunc) Estimate(from common.GeoPoint, to common.GeoPoint, serviceTypeID int32, at time.Time) (int64, error) {
+	return f(from, to, serviceTypeID, at)
+}
+
+var es Estimator
+var esErr error
+var esOnce sync.Once
+
+// GetEstimator returns the estimator configured in eta.estimator, singleton pattern.
+// The road graph is loaded on the first call
+func GetEstimator() (Estimator, error) {
+	esOnce.Do(func() {
+		// load system configuration based on environment, singleton pattern
+		configuration, err := config.GetInstance("")
+		if configuration == nil {
+			esErr = err
+			return
+		}
+
+		es, esErr = NewEstimator(&configuration.Eta)
+	})
+
+	return es, esErr
+}
+
+// GetSearchEstimators returns the estimator of the first max drivers of a search, and the straight-line estimator
+// of the others. A route search per driver is too slow for a search of hundreds of drivers
+func GetSearchEstimators() (Estimator, Estimator, int, error) {
+	estimator, err := GetEstimator()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	road, ok := estimator.(*RoadGraphEstimator)
+	if !ok {
+		return estimator, estimator, 0, nil
+	}
+
+	max := Road_Max_Estimates
+	// load system configuration based on environment, singleton pattern
+	configuration, _ := config.GetInstance("")
+	if configuration != nil && configuration.Eta.RoadMaxEstimates > 0 {
+		max = configuration.Eta.RoadMaxEstimates
+	}
+	return road, road.straightLine, int(max), nil
+}
+
+// NewEstimator creates the estimator of the configuration
+func NewEstimator(etaConfig *config.ETAConfig) (Estimator, error) {
+	straightLine, err := NewStraightLineEstimator(etaConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	switch Estimator_Type(etaConfig.Estimator) {
+	case Estimator_Type_StraightLine, "":
+		log.Printf("Using straight-line ETA estimator\n")
+		return straightLine, nil
+	case Estimator_Type_RoadGraph:
+		log.Printf("Using road graph ETA estimator: %s\n", etaConfig.RoadGraphFile)
+		return NewRoadGraphEstimator(etaConfig, straightLine)
+	default:
+		return nil, errors.New("Unknown ETA estimator type: " + etaConfig.Estimator)
+	}
+}
+
+// timeOfDay multiplies the travel times by the period of the hour of the day
+type timeOfDay struct {
+	periods  []config.ETAPeriodConfig
+	location *time.Location
+}
+
+func newTimeOfDay(etaConfig *config.ETAConfig) (*timeOfDay, error) {
+	t := &timeOfDay{location: time.Local}
+	if etaConfig.Timezone != "" {
+		location, err := time.LoadLocation(etaConfig.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		t.location = location
+	}
+
+	for _, p := range etaConfig.Periods {
+		if p.FromHour < 0 || p.FromHour > 23 || p.ToHour < 0 || p.ToHour > 24 {
+			return nil, errors.New("ETA period hours must be from 0 to 24")
+		}
+		if p.Multiplier <= 0 {
+			return nil, errors.New("ETA period multiplier must be positive")
+		}
+		t.periods = append(t.periods, p)
+	}
+	return t, nil
+}
+
+// multiplier returns the multiplier of the first period the time is in, 1 outside of the periods
+func (t *timeOfDay) multiplier(at time.Time) float64 {
+	hour := int32(at.In(t.location).Hour())
+	for _, p := range t.periods {
+		in := hour >= p.FromHour && hour < p.ToHour
+		if p.FromHour > p.ToHour {
+			in = hour >= p.FromHour || hour < p.ToHour
+		}
+		if in {
+			return p.Multiplier
+		}
+	}
+	return 1
+}
@@ -0,0 +1,319 @@
+package eta
+
+import (
+	"container/heap"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/config"
+)
+
+// size of the cells of the road node index in degrees, about 1.1 km of latitude
+const roadCellDegree = 0.01
+
+type roadEdge struct {
+	to      int32
+	seconds float64
+}
+
+type roadCell struct {
+	lat int32
+	lng int32
+}
+
+// RoadGraphEstimator estimates the fastest route on the roads of an OSM extract, the points are snapped to the
+// nearest road node and the legs to the roads are estimated in a straight line.
+// Points which cannot be snapped or routed are estimated in a straight line
+type RoadGraphEstimator struct {
+	nodes        []common.GeoPoint
+	edges        [][]roadEdge
+	cells        map[roadCell][]int32
+	maxSpeedMps  float64 // of the fastest road, for the heuristic of the route search
+	snapMeter    float64
+	straightLine *StraightLineEstimator
+	timeOfDay    *timeOfDay
+}
+
+func NewRoadGraphEstimator(etaConfig *config.ETAConfig, straightLine *StraightLineEstimator) (*RoadGraphEstimator, error) {
+	if etaConfig.RoadGraphFile == "" {
+		return nil, errors.New("ETA road graph file is empty")
+	}
+	timeOfDay, err := newTimeOfDay(etaConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &RoadGraphEstimator{
+		cells:        map[roadCell][]int32{},
+		snapMeter:    etaConfig.SnapMeter,
+		straightLine: straightLine,
+		timeOfDay:    timeOfDay,
+	}
+	if e.snapMeter <= 0 {
+		e.snapMeter = ETA_Snap_Meter
+	}
+
+	f, err := os.Open(etaConfig.RoadGraphFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	err = e.load(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.nodes) == 0 {
+		return nil, errors.New("ETA road graph has no roads: " + etaConfig.RoadGraphFile)
+	}
+	log.Printf("Road graph loaded: %d nodes\n", len(e.nodes))
+	return e, nil
+}
+
+func (e *RoadGraphEstimator) Estimate(from common.GeoPoint, to common.GeoPoint, serviceTypeID int32, at time.Time) (int64, error) {
+	fromNode, fromMeter, ok := e.snap(from)
+	if !ok {
+		return e.straightLine.Estimate(from, to, serviceTypeID, at)
+	}
+	toNode, toMeter, ok := e.snap(to)
+	if !ok {
+		return e.straightLine.Estimate(from, to, serviceTypeID, at)
+	}
+
+	seconds, err := e.route(fromNode, toNode)
+	if err != nil {
+		return e.straightLine.Estimate(from, to, serviceTypeID, at)
+	}
+	seconds += e.straightLine.seconds(fromMeter+toMeter, serviceTypeID)
+	return int64(math.Ceil(seconds * e.timeOfDay.multiplier(at))), nil
+}
+
+type osmTag struct {
+	K string `xml:"k,attr"`
+	V string `xml:"v,attr"`
+}
+
+type osmNode struct {
+	ID  int64   `xml:"id,attr"`
+	Lat float64 `xml:"lat,attr"`
+	Lon float64 `xml:"lon,attr"`
+}
+
+type osmWay struct {
+	Nds []struct {
+		Ref int64 `xml:"ref,attr"`
+	} `xml:"nd"`
+	Tags []osmTag `xml:"tag"`
+}
+
+// load reads the nodes and the ways of the OSM XML, the nodes are listed before the ways in an extract
+func (e *RoadGraphEstimator) load(r io.Reader) error {
+	points := map[int64]common.GeoPoint{}
+	index := map[int64]int32{}
+
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "node":
+			var n osmNode
+			err = decoder.DecodeElement(&n, &start)
+			if err != nil {
+				return err
+			}
+			points[n.ID] = common.GeoPoint{Lat: n.Lat, Lng: n.Lon}
+		case "way":
+			var w osmWay
+			err = decoder.DecodeElement(&w, &start)
+			if err != nil {
+				return err
+			}
+			e.addWay(&w, points, index)
+		}
+	}
+	return nil
+}
+
+// addWay adds the segments of a road to the graph, in one direction for oneway roads
+func (e *RoadGraphEstimator) addWay(w *osmWay, points map[int64]common.GeoPoint, index map[int64]int32) {
+	tags := map[string]string{}
+	for _, t := range w.Tags {
+		tags[t.K] = t.V
+	}
+
+	speedKmh, ok := Road_Speed_Kmh[tags["highway"]]
+	if !ok {
+		return
+	}
+	if maxSpeed := parseMaxSpeed(tags["maxspeed"]); maxSpeed > 0 {
+		speedKmh = maxSpeed
+	}
+	speedMps := speedKmh / 3.6
+	if speedMps > e.maxSpeedMps {
+		e.maxSpeedMps = speedMps
+	}
+
+	forward, backward := true, true
+	switch tags["oneway"] {
+	case "yes", "true", "1":
+		backward = false
+	case "-1", "reverse":
+		forward = false
+	case "no", "false", "0":
+	default:
+		if tags["highway"] == "motorway" || tags["junction"] == "roundabout" {
+			backward = false
+		}
+	}
+
+	prev := int32(-1)
+	for _, nd := range w.Nds {
+		p, ok := points[nd.Ref]
+		if !ok {
+			prev = -1
+			continue
+		}
+		node, ok := index[nd.Ref]
+		if !ok {
+			node = e.addNode(p)
+			index[nd.Ref] = node
+		}
+
+		if prev >= 0 {
+			seconds := e.nodes[prev].DistanceTo(p) / speedMps
+			if forward {
+				e.edges[prev] = append(e.edges[prev], roadEdge{to: node, seconds: seconds})
+			}
+			if backward {
+				e.edges[node] = append(e.edges[node], roadEdge{to: prev, seconds: seconds})
+			}
+		}
+		prev = node
+	}
+}
+
+func (e *RoadGraphEstimator) addNode(p common.GeoPoint) int32 {
+	node := int32(len(e.nodes))
+	e.nodes = append(e.nodes, p)
+	e.edges = append(e.edges, nil)
+
+	cell := cellOf(p)
+	e.cells[cell] = append(e.cells[cell], node)
+	return node
+}
+
+// parseMaxSpeed returns the maxspeed tag in km/h, such as "50" or "30 mph", 0 when it is not a number
+func parseMaxSpeed(maxSpeed string) float64 {
+	fields := strings.Fields(maxSpeed)
+	if len(fields) == 0 {
+		return 0
+	}
+	speed, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || speed <= 0 {
+		return 0
+	}
+	if len(fields) > 1 && fields[1] == "mph" {
+		speed *= 1.609344
+	}
+	return speed
+}
+
+func cellOf(p common.GeoPoint) roadCell {
+	return roadCell{lat: int32(math.Floor(p.Lat / roadCellDegree)), lng: int32(math.Floor(p.Lng / roadCellDegree))}
+}
+
+// snap returns the nearest road node within the snap distance, and its distance in meters
+func (e *RoadGraphEstimator) snap(p common.GeoPoint) (int32, float64, bool) {
+	// meters of a cell, the cells narrow toward the poles
+	cellLatMeter := roadCellDegree * 111195
+	cellLngMeter := cellLatMeter * math.Cos(p.Lat*math.Pi/180)
+	latRings := int32(math.Ceil(e.snapMeter / cellLatMeter))
+	lngRings := int32(50) // at most, near the poles
+	if cellLngMeter > e.snapMeter/50 {
+		lngRings = int32(math.Ceil(e.snapMeter / cellLngMeter))
+	}
+
+	center := cellOf(p)
+	nearest, nearestMeter := int32(-1), e.snapMeter
+	for lat := center.lat - latRings; lat <= center.lat+latRings; lat++ {
+		for lng := center.lng - lngRings; lng <= center.lng+lngRings; lng++ {
+			for _, node := range e.cells[roadCell{lat: lat, lng: lng}] {
+				meter := p.DistanceTo(e.nodes[node])
+				if meter <= nearestMeter {
+					nearest, nearestMeter = node, meter
+				}
+			}
+		}
+	}
+	return nearest, nearestMeter, nearest >= 0
+}
+
+// route returns the seconds of the fastest route between the nodes, searched with A*
+func (e *RoadGraphEstimator) route(from int32, to int32) (float64, error) {
+	if from == to {
+		return 0, nil
+	}
+
+	target := e.nodes[to]
+	seconds := map[int32]float64{from: 0}
+	done := map[int32]bool{}
+	queue := &roadQueue{{node: from, priority: e.nodes[from].DistanceTo(target) / e.maxSpeedMps}}
+	for queue.Len() > 0 && len(done) < Road_Max_Visited_Nodes {
+		item := heap.Pop(queue).(roadQueueItem)
+		if item.node == to {
+			return seconds[to], nil
+		}
+		if done[item.node] {
+			continue
+		}
+		done[item.node] = true
+
+		for _, edge := range e.edges[item.node] {
+			s := seconds[item.node] + edge.seconds
+			if known, ok := seconds[edge.to]; ok && known <= s {
+				continue
+			}
+			seconds[edge.to] = s
+			heap.Push(queue, roadQueueItem{node: edge.to, priority: s + e.nodes[edge.to].DistanceTo(target)/e.maxSpeedMps})
+		}
+	}
+	return 0, ErrNoRoute
+}
+
+type roadQueueItem struct {
+	node     int32
+	priority float64
+}
+
+// roadQueue is a min heap of the nodes by the priority
+type roadQueue []roadQueueItem
+
+func (q roadQueue) Len() int            { return len(q) }
+func (q roadQueue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q roadQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *roadQueue) Push(x interface{}) { *q = append(*q, x.(roadQueueItem)) }
+func (q *roadQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
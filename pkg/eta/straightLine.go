@@ -0,0 +1,60 @@
+package eta
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/config"
+)
+
+// StraightLineEstimator estimates the straight-line distance lengthened by the detour factor,
+// at the average speed of the service type
+type StraightLineEstimator struct {
+	speedKmh     float64
+	detourFactor float64
+	serviceTypes map[int32]float64 // speed in km/h by service type id
+	timeOfDay    *timeOfDay
+}
+
+func NewStraightLineEstimator(etaConfig *config.ETAConfig) (*StraightLineEstimator, error) {
+	timeOfDay, err := newTimeOfDay(etaConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &StraightLineEstimator{
+		speedKmh:     etaConfig.SpeedKmh,
+		detourFactor: etaConfig.DetourFactor,
+		serviceTypes: map[int32]float64{},
+		timeOfDay:    timeOfDay,
+	}
+	if e.speedKmh <= 0 {
+		e.speedKmh = ETA_Speed_Kmh
+	}
+	if e.detourFactor < 1 {
+		e.detourFactor = ETA_Detour_Factor
+	}
+	for _, s := range etaConfig.ServiceTypes {
+		if s.SpeedKmh <= 0 {
+			return nil, errors.New("ETA service type speed must be positive")
+		}
+		e.serviceTypes[s.ServiceTypeID] = s.SpeedKmh
+	}
+	return e, nil
+}
+
+func (e *StraightLineEstimator) Estimate(from common.GeoPoint, to common.GeoPoint, serviceTypeID int32, at time.Time) (int64, error) {
+	seconds := e.seconds(from.DistanceTo(to), serviceTypeID)
+	return int64(math.Ceil(seconds * e.timeOfDay.multiplier(at))), nil
+}
+
+// seconds returns the travel time of the straight-line distance in meters, without the time of day
+func (e *StraightLineEstimator) seconds(distance float64, serviceTypeID int32) float64 {
+	speedKmh, ok := e.serviceTypes[serviceTypeID]
+	if !ok {
+		speedKmh = e.speedKmh
+	}
+	return distance * e.detourFactor / (speedKmh / 3.6)
+}
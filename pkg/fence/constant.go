@@ -0,0 +1,28 @@
+package fence
+
+// FenceEvent_Type
+type FenceEvent_Type string
+
+const (
+	FenceEvent_Type_DriverArriving FenceEvent_Type = "DriverArriving"
+	FenceEvent_Type_DriverArrived  FenceEvent_Type = "DriverArrived"
+)
+
+// Transport_Type
+type Transport_Type string
+
+const (
+	Transport_Type_Webhook Transport_Type = "webhook" // Tile38 posts the events to the fence hook route
+	Transport_Type_Memory  Transport_Type = "memory"  // events of the in-memory geo store of the process
+)
+
+// Sink_Type
+type Sink_Type string
+
+const (
+	Sink_Type_Log     Sink_Type = "log"
+	Sink_Type_Webhook Sink_Type = "webhook"
+)
+
+// max wait of the webhook sink for the endpoint to answer
+const Sink_Webhook_Timeout_Second = 10
@@ -0,0 +1,141 @@
+package fence
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/iknowhtml/locationtracker/pkg/config"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+// Consumer parses the raw Tile38 fence payloads of the driver hooks into fence events,
+// and fans them out to every sink
+type Consumer struct {
+	transport Transport
+	mu        sync.RWMutex
+	sinks     []Sink
+}
+
+// Init creates the transport and the sink set in the configuration
+func (c *Consumer) Init() error {
+	// load system configuration based on environment, singleton pattern
+	configuration, err := config.GetInstance("")
+	if configuration == nil {
+		return err
+	}
+
+	transport, err := NewTransport(Transport_Type(configuration.Fenceconsumer.Transport))
+	if err != nil {
+		return err
+	}
+	sink, err := NewSink(Sink_Type(configuration.Fenceconsumer.Sink), configuration.Fenceconsumer.SinkEndpoint)
+	if err != nil {
+		return err
+	}
+
+	c.InitWith(transport, sink)
+	return nil
+}
+
+// InitWith sets the transport and the sinks without configuration
+func (c *Consumer) InitWith(transport Transport, sinks ...Sink) {
+	c.transport = transport
+	c.sinks = nil
+	for _, s := range sinks {
+		c.AddSink(s)
+	}
+}
+
+func (c *Consumer) AddSink(sink Sink) {
+	if sink == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sinks = append(c.sinks, sink)
+}
+
+// Run consumes the payloads of the transport until stop is closed
+func (c *Consumer) Run(stop <-chan struct{}) error {
+	if c.transport == nil {
+		return errors.New("Fence transport is not set")
+	}
+
+	err := c.transport.Start(c.handle)
+	if err != nil {
+		return err
+	}
+	log.Printf("Fence consumer started\n")
+
+	<-stop
+
+	log.Printf("Fence consumer stopped\n")
+	return c.transport.Stop()
+}
+
+func (c *Consumer) handle(topic string, payload []byte) {
+	event, err := ParseFenceEvent(payload)
+	if err != nil {
+		log.Printf("Fence consumer: payload to %s skipped: %v\n", topic, err)
+		return
+	}
+
+	c.mu.RLock()
+	sinks := c.sinks
+	c.mu.RUnlock()
+
+	for _, s := range sinks {
+		err := s.Publish(event)
+		if err != nil {
+			log.Printf("Fence consumer: failed to publish %s of driver %d: %v\n", event.Event, event.DriverID, err)
+		}
+	}
+}
+
+// ParseFenceEvent parses the payload of the arriving and arrived driver hooks,
+// the payloads of other hooks return an error
+func ParseFenceEvent(payload []byte) (*FenceEventObject, error) {
+	var hookEvent location.HookFenceEventObject
+	err := json.Unmarshal(payload, &hookEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	var eventType FenceEvent_Type
+	switch {
+	case strings.HasPrefix(hookEvent.Hook, location.HookTopic(location.Hook_Type_Arriving)+"_"):
+		eventType = FenceEvent_Type_DriverArriving
+	case strings.HasPrefix(hookEvent.Hook, location.HookTopic(location.Hook_Type_Arrived)+"_"):
+		eventType = FenceEvent_Type_DriverArrived
+	default:
+		return nil, errors.New("Hook is not a driver hook: " + hookEvent.Hook)
+	}
+
+	// only enter and inside are detected by the driver hooks
+	if hookEvent.Detect != string(location.LocationDetect_Type_Enter) && hookEvent.Detect != string(location.LocationDetect_Type_Inside) {
+		return nil, errors.New("Detect type not supported: " + hookEvent.Detect)
+	}
+
+	driverID, err := strconv.ParseInt(hookEvent.ID, 10, 32)
+	if err != nil {
+		return nil, errors.New("Driver ID is invalid: " + hookEvent.ID)
+	}
+
+	p := hookEvent.Object.Point()
+	return &FenceEventObject{
+		Event:      eventType,
+		DriverID:   int32(driverID),
+		ProviderID: int32(hookEvent.Fields["providerid"]),
+		JobID:      int32(hookEvent.Fields["jobid"]),
+		Distance:   hookEvent.Distance,
+		Detect:     hookEvent.Detect,
+		Lat:        p.Lat,
+		Lng:        p.Lng,
+		Hook:       hookEvent.Hook,
+		Time:       hookEvent.Time,
+	}, nil
+}
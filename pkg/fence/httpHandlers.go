@@ -0,0 +1,35 @@
+package fence
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+// Tile38 posts the hook payloads to the hook endpoint joined with the topic
+// url: topic ("topic") (required)
+// post: Tile38 fence event (required)
+func HandleReceiveFenceHook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	vars := mux.Vars(r)
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil || len(payload) == 0 {
+		common.HandleStatus400Response(w, "Fence event is missing, but required")
+		return
+	}
+
+	if !getWebhookTransport().deliver(vars["topic"], payload) {
+		// send a internal server error back to the caller, so the event is sent again
+		common.HandleServerErrorResponse(w, errors.New("Fence consumer is not running"))
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &FenceHookResultObject{Received: true})
+}
@@ -0,0 +1,14 @@
+package fence
+
+import (
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+func NewRouter() []common.Route {
+
+	fenceRouter := []common.Route{
+		common.Route{"ReceiveFenceHook", "POST", "/fence/hook/{topic}", HandleReceiveFenceHook},
+	}
+
+	return fenceRouter
+}
@@ -0,0 +1,23 @@
+package fence
+
+// FenceEventObject is a driver fence event parsed from a Tile38 hook payload
+type FenceEventObject struct {
+	Event      FenceEvent_Type `json:"event"`
+	DriverID   int32           `json:"driverid"`
+	ProviderID int32           `json:"providerid,omitempty"`
+	JobID      int32           `json:"jobid,omitempty"`
+	Distance   float64         `json:"distance"` // in meters from the fence center
+	Detect     string          `json:"detect"`   // enter or inside
+	Lat        float64         `json:"lat"`
+	Lng        float64         `json:"lng"`
+	Hook       string          `json:"hook"`
+	Time       string          `json:"time"`
+}
+
+type FenceHookResultObject struct {
+	Received interface{} `json:"received"`
+}
+
+func (o *FenceHookResultObject) SetResult(result interface{}) {
+	o.Received = result
+}
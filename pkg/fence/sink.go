@@ -0,0 +1,60 @@
+package fence
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+// Sink receives the parsed fence events
+type Sink interface {
+	Publish(event *FenceEventObject) error
+}
+
+// SinkFunc turns a function into a Sink
+type SinkFunc func(event *FenceEventObject) error
+
+func (f SinkFunc) Publish(event *FenceEventObject) error {
+	return f(event)
+}
+
+func NewSink(sinkType Sink_Type, endpoint string) (Sink, error) {
+	switch sinkType {
+	case Sink_Type_Log:
+		return new(LogSink), nil
+	case Sink_Type_Webhook:
+		if endpoint == "" {
+			return nil, errors.New("Fence sink endpoint is empty")
+		}
+		return &WebhookSink{Endpoint: endpoint}, nil
+	default:
+		return nil, errors.New("Fence sink not supported: " + string(sinkType))
+	}
+}
+
+// LogSink writes the events to the log
+type LogSink struct{}
+
+func (s *LogSink) Publish(event *FenceEventObject) error {
+	log.Printf("Fence event: %s driver %d job %d, %s at %.0f meters\n", event.Event, event.DriverID, event.JobID, event.Detect, event.Distance)
+	return nil
+}
+
+// WebhookSink posts the events in JSON to the endpoint
+type WebhookSink struct {
+	Endpoint string
+}
+
+func (s *WebhookSink) Publish(event *FenceEventObject) error {
+	res, _, errs := gorequest.New().Timeout(Sink_Webhook_Timeout_Second * time.Second).Post(s.Endpoint).SendStruct(event).End()
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return errors.New("Error in sending request: " + s.Endpoint + ", status: " + res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package fence
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+// PayloadHandler receives the raw hook payloads with the topic they are sent to
+type PayloadHandler func(topic string, payload []byte)
+
+// Transport delivers the raw hook payloads to the consumer
+type Transport interface {
+	// Start delivers the payloads to the handler until Stop is called
+	Start(handler PayloadHandler) error
+	Stop() error
+}
+
+func NewTransport(transportType Transport_Type) (Transport, error) {
+	switch transportType {
+	case Transport_Type_Webhook:
+		return getWebhookTransport(), nil
+	case Transport_Type_Memory:
+		return new(MemoryTransport), nil
+	default:
+		return nil, errors.New("Fence transport not supported: " + string(transportType))
+	}
+}
+
+// MemoryTransport receives the fence events of the in-memory geo store of the process.
+// Payloads can also be published directly, so the consumer runs without Tile38 or Kafka
+type MemoryTransport struct {
+	mu      sync.RWMutex
+	handler PayloadHandler
+}
+
+func (t *MemoryTransport) Start(handler PayloadHandler) error {
+	if handler == nil {
+		return errors.New("Fence payload handler is not set")
+	}
+	t.mu.Lock()
+	t.handler = handler
+	t.mu.Unlock()
+
+	location.SetMemoryHookHandler(func(endpoint string, payload []byte) {
+		t.Publish(endpoint, payload)
+	})
+	return nil
+}
+
+func (t *MemoryTransport) Stop() error {
+	t.mu.Lock()
+	t.handler = nil
+	t.mu.Unlock()
+	return nil
+}
+
+// Publish delivers the payload to the consumer, it is dropped when the transport is stopped
+func (t *MemoryTransport) Publish(topic string, payload []byte) {
+	t.mu.RLock()
+	handler := t.handler
+	t.mu.RUnlock()
+
+	if handler == nil {
+		log.Printf("Fence memory transport is stopped, payload dropped: %s\n", string(payload))
+		return
+	}
+	handler(topic, payload)
+}
+
+// webhookTransport receives the payloads posted by Tile38 to the fence hook route,
+// the hook endpoints are set to the url of the route
+type webhookTransport struct {
+	mu      sync.RWMutex
+	handler PayloadHandler
+}
+
+var wt *webhookTransport
+var wtOnce sync.Once
+
+// the route handler is shared by the process, so is the webhook transport
+func getWebhookTransport() *webhookTransport {
+	wtOnce.Do(func() {
+		wt = &webhookTransport{}
+	})
+	return wt
+}
+
+func (t *webhookTransport) Start(handler PayloadHandler) error {
+	if handler == nil {
+		return errors.New("Fence payload handler is not set")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handler = handler
+	return nil
+}
+
+func (t *webhookTransport) Stop() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handler = nil
+	return nil
+}
+
+// deliver returns false when no consumer is running
+func (t *webhookTransport) deliver(topic string, payload []byte) bool {
+	t.mu.RLock()
+	handler := t.handler
+	t.mu.RUnlock()
+
+	if handler == nil {
+		return false
+	}
+	handler(topic, payload)
+	return true
+}
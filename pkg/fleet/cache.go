@@ -0,0 +1,162 @@
+package fleet
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/config"
+)
+
+// FleetInfoCacheStatsObject counts the lookups of the fleet info cache since the start of the server
+type FleetInfoCacheStatsObject struct {
+	Hits         int64 `json:"hits"`
+	NegativeHits int64 `json:"negativehits"` // drivers not found or without an active service
+	Misses       int64 `json:"misses"`       // lookups asking the fleet server
+	Coalesced    int64 `json:"coalesced"`    // lookups waiting for the answer of the same lookup in flight
+	Stale        int64 `json:"stale"`        // lookups answered by a stale entry as the fleet server failed
+	Errors       int64 `json:"errors"`       // failures of the fleet server
+	Drivers      int32 `json:"drivers"`      // drivers with a cached entry
+}
+
+// fleetInfoEntry is the last answer of the fleet server for a driver
+type fleetInfoEntry struct {
+	info        *DriverFleetResponseObj // nil for a driver not found or without an active service
+	err         error                   // the reason of a nil info
+	fetchedTime time.Time
+}
+
+// fleetInfoCall is a lookup in flight, the lookups of the same driver wait for its answer
+type fleetInfoCall struct {
+	done chan struct{}
+	info *DriverFleetResponseObj
+	err  error
+}
+
+// fleetInfoFetcher asks the fleet server, found is false when the fleet server did not answer
+type fleetInfoFetcher func(driverID int32) (info *DriverFleetResponseObj, found bool, err error)
+
+var fleetInfoEntries = map[int32]*fleetInfoEntry{}
+var fleetInfoCalls = map[int32]*fleetInfoCall{}
+var fleetInfoStats FleetInfoCacheStatsObject
+var fleetInfoPruneTime time.Time
+var fleetInfoMu sync.Mutex
+
+// GetFleetInfoCacheStats returns a copy of the counts of the fleet info cache
+func GetFleetInfoCacheStats() *FleetInfoCacheStatsObject {
+	fleetInfoMu.Lock()
+	defer fleetInfoMu.Unlock()
+
+	stats := fleetInfoStats
+	stats.Drivers = int32(len(fleetInfoEntries))
+	return &stats
+}
+
+// InvalidateDriverFleetInfo removes the fleet info of the driver from the cache, the next lookup asks the fleet server.
+// A lookup in flight still answers its callers, but its answer is not cached
+func InvalidateDriverFleetInfo(driverID int32) {
+	fleetInfoMu.Lock()
+	defer fleetInfoMu.Unlock()
+
+	delete(fleetInfoEntries, driverID)
+	delete(fleetInfoCalls, driverID)
+}
+
+// InvalidateAllDriverFleetInfo removes the fleet info of all drivers from the cache
+func InvalidateAllDriverFleetInfo() {
+	fleetInfoMu.Lock()
+	defer fleetInfoMu.Unlock()
+
+	fleetInfoEntries = map[int32]*fleetInfoEntry{}
+	fleetInfoCalls = map[int32]*fleetInfoCall{}
+}
+
+// getCachedDriverFleetInfo returns the cached fleet info of the driver, or asks the fleet server once for all the
+// concurrent lookups of the driver. When the fleet server fails, the last fleet info is used up to the max stale age.
+// The returned fleet info is shared and must not be changed
+func getCachedDriverFleetInfo(cacheConfig *config.FleetServerConfig, driverID int32, fetch fleetInfoFetcher) (*DriverFleetResponseObj, error) {
+	if cacheConfig.CacheTTLSecond <= 0 {
+		info, _, err := fetch(driverID)
+		return info, err
+	}
+
+	timeNow := time.Now()
+	fleetInfoMu.Lock()
+	pruneFleetInfo(cacheConfig, timeNow)
+
+	entry, ok := fleetInfoEntries[driverID]
+	if ok && entry.fresh(cacheConfig, timeNow) {
+		if entry.info != nil {
+			fleetInfoStats.Hits++
+		} else {
+			fleetInfoStats.NegativeHits++
+		}
+		fleetInfoMu.Unlock()
+		return entry.info, entry.err
+	}
+
+	call, ok := fleetInfoCalls[driverID]
+	if ok {
+		fleetInfoStats.Coalesced++
+		fleetInfoMu.Unlock()
+		<-call.done
+		return call.info, call.err
+	}
+	call = &fleetInfoCall{done: make(chan struct{})}
+	fleetInfoCalls[driverID] = call
+	fleetInfoStats.Misses++
+	fleetInfoMu.Unlock()
+
+	info, found, err := fetch(driverID)
+
+	fleetInfoMu.Lock()
+	if !found {
+		fleetInfoStats.Errors++
+	}
+	// the answer of a lookup invalidated in flight is not cached
+	if fleetInfoCalls[driverID] == call {
+		delete(fleetInfoCalls, driverID)
+		if found {
+			fleetInfoEntries[driverID] = &fleetInfoEntry{info: info, err: err, fetchedTime: time.Now()}
+		} else if entry, ok := fleetInfoEntries[driverID]; ok && entry.usableStale(cacheConfig, time.Now()) {
+			log.Printf("Fleet Info: fleet server failed for driver %d, the fleet info of %s is used: %v\n",
+				driverID, entry.fetchedTime.Format(time.RFC3339), err)
+			fleetInfoStats.Stale++
+			info, err = entry.info, nil
+		}
+	}
+	call.info, call.err = info, err
+	fleetInfoMu.Unlock()
+	close(call.done)
+
+	return info, err
+}
+
+// fresh is true when the entry is within its ttl, the negative ttl for a driver not found
+func (e *fleetInfoEntry) fresh(cacheConfig *config.FleetServerConfig, timeNow time.Time) bool {
+	ttl := cacheConfig.CacheTTLSecond
+	if e.info == nil {
+		ttl = cacheConfig.CacheNegativeTTLSecond
+	}
+	return timeNow.Sub(e.fetchedTime) < time.Duration(ttl)*time.Second
+}
+
+// usableStale is true when the entry has fleet info within the max stale age
+func (e *fleetInfoEntry) usableStale(cacheConfig *config.FleetServerConfig, timeNow time.Time) bool {
+	return e.info != nil && cacheConfig.CacheMaxStaleSecond > 0 &&
+		timeNow.Sub(e.fetchedTime) <= time.Duration(cacheConfig.CacheMaxStaleSecond)*time.Second
+}
+
+// pruneFleetInfo removes the entries neither fresh nor usable stale, at most once per ttl. Caller must hold the lock
+func pruneFleetInfo(cacheConfig *config.FleetServerConfig, timeNow time.Time) {
+	if timeNow.Sub(fleetInfoPruneTime) < time.Duration(cacheConfig.CacheTTLSecond)*time.Second {
+		return
+	}
+	fleetInfoPruneTime = timeNow
+
+	for driverID, entry := range fleetInfoEntries {
+		if !entry.fresh(cacheConfig, timeNow) && !entry.usableStale(cacheConfig, timeNow) {
+			delete(fleetInfoEntries, driverID)
+		}
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"net/http"
 
 	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/config"
 )
 
 type FleetService struct {
 	fleetClient *FleetClient
+	cacheConfig config.FleetServerConfig
 }
 
 func (fs *FleetService) Init() error {
@@ -19,37 +21,51 @@ func (fs *FleetService) Init() error {
 	if err != nil {
 		return err
 	}
+
+	// load system configuration based on environment, singleton pattern
+	configuration, err := config.GetInstance("")
+	if configuration == nil || err != nil {
+		return err
+	}
+	fs.cacheConfig = configuration.Fleetserver
 	return nil
 }
 
+// GetDriverFleetInfo returns the fleet info of the driver, read through the cache of the fleet info
 func (fs *FleetService) GetDriverFleetInfo(driverID int32) (*DriverFleetResponseObj, error) {
-	respObj := DriverFleetResponseObj{}
-
 	// check if driverID is zero
 	if driverID == 0 {
 		return nil, errors.New("Driver ID is zero.")
 	}
 
+	return getCachedDriverFleetInfo(&fs.cacheConfig, driverID, fs.fetchDriverFleetInfo)
+}
+
+// fetchDriverFleetInfo asks the fleet server for the fleet info of the driver.
+// The answer is found when the fleet server answered, even if the driver is invalid or has no active service
+func (fs *FleetService) fetchDriverFleetInfo(driverID int32) (res *DriverFleetResponseObj, found bool, err error) {
+	respObj := DriverFleetResponseObj{}
+
 	requestURI := common.Concate(fs.fleetClient.remoteAddr, API_STRING_DRIVERFLEET, "/", common.String(driverID))
 	log.Printf("Request URI: %s \n", requestURI)
-	res, body, _ := fs.fleetClient.request.Get(requestURI).End()
-	if res.StatusCode != http.StatusOK {
-		return nil, errors.New("Error in sending request: " + requestURI)
+	resp, body, errs := fs.fleetClient.request.Get(requestURI).End()
+	if len(errs) > 0 || resp == nil || resp.StatusCode != http.StatusOK {
+		return nil, false, errors.New("Error in sending request: " + requestURI)
 	}
 
-	err := json.Unmarshal([]byte(body), &respObj)
+	err = json.Unmarshal([]byte(body), &respObj)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	log.Printf("Request Driver Fleet Info: %d, result: %v\n", driverID, respObj)
 
 	if respObj.Data.DriverID == 0 || respObj.Data.ProviderID == 0 {
-		return nil, errors.New("Driver Fleet info invalid or not found for id: " + common.String(driverID))
+		return nil, true, errors.New("Driver Fleet info invalid or not found for id: " + common.String(driverID))
 	}
 
 	if respObj.Data.ActiveServiceTypeID == 0 || respObj.Data.ActiveServiceID == 0 {
-		return nil, errors.New("No active service configured for id: " + common.String(driverID))
+		return nil, true, errors.New("No active service configured for id: " + common.String(driverID))
 	}
 
-	return &respObj, nil
+	return &respObj, true, nil
 }
@@ -0,0 +1,27 @@
+package heatmap
+
+// Heatmap_Grid
+type Heatmap_Grid string
+
+const (
+	Heatmap_Grid_Hex     Heatmap_Grid = "hex"     // default, hexagonal cells of the edge size
+	Heatmap_Grid_Geohash Heatmap_Grid = "geohash" // geohash cells of the precision
+)
+
+// Heatmap_Format
+type Heatmap_Format string
+
+const (
+	Heatmap_Format_JSON    Heatmap_Format = "json"    // default
+	Heatmap_Format_GeoJSON Heatmap_Format = "geojson" // FeatureCollection of the cells, without the response wrapper
+)
+
+const (
+	Heatmap_Edge_Meter        float64 = 500 // default edge of the hex cells
+	Heatmap_Min_Edge_Meter    float64 = 50
+	Heatmap_Geohash_Precision int     = 6     // default, cells of about 1.2 x 0.6 km
+	Heatmap_Max_Objects       int32   = 10000 // max drivers and max jobs aggregated, the counts are partial beyond
+	// jobs created within the window are counted as demand
+	Heatmap_Demand_Window_Second     int64 = 3600
+	Heatmap_Max_Demand_Window_Second int64 = 7 * 86400
+)
@@ -0,0 +1,190 @@
+package heatmap
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/job"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+type HeatmapController struct {
+	locationService    *location.LocationService
+	locationController *location.LocationController
+}
+
+func (hc *HeatmapController) Init() error {
+	hc.locationService = new(location.LocationService)
+	err := hc.locationService.Init(nil)
+	if err != nil {
+		return err
+	}
+	hc.locationController = new(location.LocationController)
+	err = hc.locationController.Init()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Aggregate the drivers of the filter within the bounds into the cells of the grid, by status, service type and provider.
+// The jobs created within the demand window are overlaid by the pickup, only the fleet and the service type of the filter apply to them
+func (hc *HeatmapController) GetHeatmap(req *HeatmapRequestObject) (*HeatmapObject, error) {
+
+	area, err := boundsGeoJSON(req.Bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	heatmap := &HeatmapObject{
+		Grid:   req.Grid,
+		Bounds: req.Bounds,
+		grid:   newGrid(req),
+		cells:  map[string]*HeatmapCellObject{},
+	}
+	if req.Grid == Heatmap_Grid_Geohash {
+		heatmap.Precision = req.Precision
+	} else {
+		heatmap.EdgeMeter = req.EdgeMeter
+	}
+
+	// Supply, drivers with the same filters as the driver searches
+	filter := req.Filter
+	locationController, err := hc.locationController.ForTenant(filter.Fleet)
+	if err != nil {
+		return nil, err
+	}
+	drivers, err := locationController.SearchAreaDriver(
+		Heatmap_Max_Objects, location.LocationSearch_Type_Within, area,
+		filter.ProviderID, filter.ServiceTypeID, filter.ServiceID, filter.Availability, filter.Priority, &filter.Motion)
+	if err != nil {
+		return nil, err
+	}
+	if drivers.Ok == false {
+		return nil, errors.New(drivers.Error)
+	}
+	if int32(len(drivers.Objects)) >= Heatmap_Max_Objects {
+		heatmap.Truncated = true
+	}
+	for _, o := range drivers.Objects {
+		if filter.Exclude.Excludes(o.Fields) {
+			continue
+		}
+		c := heatmap.cell(o.Object.Point())
+		c.Supply.Drivers++
+		c.Supply.ByStatus[o.Fields.Status]++
+		c.Supply.ByServiceType[o.Fields.ActiveServiceTypeID]++
+		c.Supply.ByProvider[o.Fields.ProviderID]++
+		heatmap.Drivers++
+	}
+
+	// Demand, jobs of the tenant created within the window
+	tenantID := locationController.Tenant().ID
+	whereList := []location.WhereConditionFieldObject{{
+		FieldName: "createdtime",
+		Min:       time.Now().Unix() - req.DemandWindowSecond,
+		Max:       "+inf",
+	}, {
+		FieldName: "tenantid",
+		Min:       tenantID,
+		Max:       tenantID,
+	}}
+	whereInList := []location.WhereInConditionFieldObject{}
+	if filter.ServiceTypeID != 0 {
+		whereInList = append(whereInList, location.WhereInConditionFieldObject{FieldName: "servicetypeid", Values: []interface{}{filter.ServiceTypeID}})
+	}
+	jobs, err := hc.locationService.SearchAreaObject(location.Object_Collection_Job, location.LocationSearch_Type_Within, area, Heatmap_Max_Objects, whereList, whereInList)
+	if err != nil {
+		return nil, err
+	}
+	if jobs.Ok == false {
+		return nil, errors.New(jobs.Error)
+	}
+	if int32(len(jobs.Objects)) >= Heatmap_Max_Objects {
+		heatmap.Truncated = true
+	}
+	for i, o := range jobs.Objects {
+		fields := jobs.FieldMap(i)
+		c := heatmap.cell(o.Object.Point())
+		c.Demand.Jobs++
+		c.Demand.ByStatus[job.Job_Status(fields["jobstatus"])]++
+		c.Demand.ByServiceType[int32(fields["servicetypeid"])]++
+		heatmap.Jobs++
+	}
+
+	heatmap.Cells = make([]*HeatmapCellObject, 0, len(heatmap.cells))
+	for _, c := range heatmap.cells {
+		heatmap.Cells = append(heatmap.Cells, c)
+	}
+	sort.Slice(heatmap.Cells, func(i, j int) bool { return heatmap.Cells[i].Cell < heatmap.Cells[j].Cell })
+	heatmap.Count = int32(len(heatmap.Cells))
+
+	log.Printf("Heatmap: %d drivers, %d jobs in %d cells\n", heatmap.Drivers, heatmap.Jobs, heatmap.Count)
+	return heatmap, nil
+}
+
+// boundsGeoJSON returns the GeoJSON area of the bounds, a box across the antimeridian is split in two polygons
+func boundsGeoJSON(b common.BoundingBox) (json.RawMessage, error) {
+	box := func(minLng float64, maxLng float64) [][][2]float64 {
+		return [][][2]float64{{
+			{minLng, b.MinLat},
+			{maxLng, b.MinLat},
+			{maxLng, b.MaxLat},
+			{minLng, b.MaxLat},
+			{minLng, b.MinLat},
+		}}
+	}
+
+	geometry := location.GeometryObject{Type: location.LocationObject_Type_Polygon}
+	var coordinates interface{} = box(b.MinLng, b.MaxLng)
+	if b.CrossesAntimeridian() {
+		geometry.Type = location.LocationObject_Type_MultiPolygon
+		coordinates = [][][][2]float64{box(b.MinLng, 180), box(-180, b.MaxLng)}
+	}
+
+	var err error
+	geometry.Coordinates, err = json.Marshal(coordinates)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(geometry)
+}
+
+// ValidateHeatmapRequest checks the bounds, the cell size of the grid and the demand window
+func ValidateHeatmapRequest(req *HeatmapRequestObject) error {
+	b := req.Bounds
+	if err := (common.GeoPoint{Lat: b.MinLat, Lng: b.MinLng}).Validate(); err != nil && err != common.ErrGeoPointNullIsland {
+		return errors.New("Heatmap bounds are invalid: " + err.Error())
+	}
+	if err := (common.GeoPoint{Lat: b.MaxLat, Lng: b.MaxLng}).Validate(); err != nil && err != common.ErrGeoPointNullIsland {
+		return errors.New("Heatmap bounds are invalid: " + err.Error())
+	}
+	if b.MinLat >= b.MaxLat || b.MinLng == b.MaxLng {
+		return errors.New("Heatmap bounds are empty")
+	}
+
+	switch req.Grid {
+	case Heatmap_Grid_Hex:
+		if req.EdgeMeter < Heatmap_Min_Edge_Meter {
+			return errors.New("Heatmap edge is too small")
+		}
+	case Heatmap_Grid_Geohash:
+		if req.Precision < 1 || req.Precision > common.Geohash_Max_Precision {
+			return common.ErrGeohashPrecision
+		}
+	default:
+		return errors.New("Heatmap grid must be hex or geohash")
+	}
+
+	if req.DemandWindowSecond <= 0 || req.DemandWindowSecond > Heatmap_Max_Demand_Window_Second {
+		return errors.New("Heatmap demand window is invalid")
+	}
+	if req.Filter == nil {
+		req.Filter = &location.SearchFilterObject{}
+	}
+	return nil
+}
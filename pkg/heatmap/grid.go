@@ -0,0 +1,70 @@
+package heatmap
+
+import (
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+// grid buckets the points into cells identified by a key
+type grid interface {
+	cellOf(p common.GeoPoint) string
+	// center returns the center of the cell
+	center(cell string) common.GeoPoint
+	// ring returns the closed exterior ring of the cell, in GeoJSON [lng, lat] positions
+	ring(cell string) [][2]float64
+}
+
+func newGrid(req *HeatmapRequestObject) grid {
+	if req.Grid == Heatmap_Grid_Geohash {
+		return &geohashGrid{precision: req.Precision}
+	}
+	return &hexGrid{edge: req.EdgeMeter}
+}
+
+// hexGrid is the hexagonal grid of the edge size in meters, cells are keyed "q:r"
+type hexGrid struct {
+	edge float64
+}
+
+func (g *hexGrid) cellOf(p common.GeoPoint) string {
+	return common.HexCellOf(p, g.edge).String()
+}
+
+func (g *hexGrid) center(cell string) common.GeoPoint {
+	c, _ := common.ParseHexCell(cell)
+	return c.Center(g.edge)
+}
+
+func (g *hexGrid) ring(cell string) [][2]float64 {
+	c, _ := common.ParseHexCell(cell)
+	ring := [][2]float64{}
+	for _, p := range c.Boundary(g.edge) {
+		ring = append(ring, p.GeoJSON())
+	}
+	return append(ring, ring[0])
+}
+
+// geohashGrid is the geohash grid of the precision, cells are keyed by the geohash
+type geohashGrid struct {
+	precision int
+}
+
+func (g *geohashGrid) cellOf(p common.GeoPoint) string {
+	hash, _ := common.EncodeGeohash(p, g.precision)
+	return hash
+}
+
+func (g *geohashGrid) center(cell string) common.GeoPoint {
+	center, _, _ := common.DecodeGeohash(cell)
+	return center
+}
+
+func (g *geohashGrid) ring(cell string) [][2]float64 {
+	_, b, _ := common.DecodeGeohash(cell)
+	return [][2]float64{
+		{b.MinLng, b.MinLat},
+		{b.MaxLng, b.MinLat},
+		{b.MaxLng, b.MaxLat},
+		{b.MinLng, b.MaxLat},
+		{b.MinLng, b.MinLat},
+	}
+}
@@ -0,0 +1,132 @@
+package heatmap
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+// Url Param: bounds (minlat, minlng, maxlat, maxlng) (required), minlng greater than maxlng crosses the antimeridian
+// grid (grid = hex|geohash) (optional, hex by default)
+// edge of the hex cells in meters (edge = 500) (optional)
+// precision of the geohash cells (precision = 6) (optional)
+// jobs created within the window in seconds are the demand (window = 3600) (optional)
+// format (format = json|geojson) (optional), geojson returns a FeatureCollection of the cells without the response wrapper
+// the drivers are filtered as the driver searches:
+// fleet, tenant of the drivers (fleet = towing) (optional, the default tenant), also filters the jobs
+// providerid (provider) (optional)
+// availability (avail = 1|0|2|4|5|6|7|8) (optional)
+// service type id (srvtype = 0) (optional), also filters the jobs
+// service id (srv = 0) (optional)
+// priority (priority = 1|0) (optional)
+// motion (motion = 1|2|3, comma separated) (optional)
+// max speed in km/h (maxspeed) (optional)
+func HandleGetHeatmap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	// get Url Param
+	queryValues := r.URL.Query()
+	log.Println(queryValues)
+
+	req, err := parseHeatmapRequest(queryValues)
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+	if err := ValidateHeatmapRequest(req); err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	format := Heatmap_Format(queryValues.Get("format"))
+	if format != "" && format != Heatmap_Format_JSON && format != Heatmap_Format_GeoJSON {
+		common.HandleStatus400Response(w, "Format must be json or geojson")
+		return
+	}
+
+	heatmapController := new(HeatmapController)
+	err = heatmapController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := heatmapController.GetHeatmap(req)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	if format == Heatmap_Format_GeoJSON {
+		common.HandleGeoJSONResponse(w, res.FeatureCollection())
+		return
+	}
+	common.HandleStatusOKResponse(w, &HeatmapResultObject{Heatmap: res})
+}
+
+func parseHeatmapRequest(queryValues url.Values) (*HeatmapRequestObject, error) {
+	req := &HeatmapRequestObject{
+		Grid:               Heatmap_Grid_Hex,
+		EdgeMeter:          Heatmap_Edge_Meter,
+		Precision:          Heatmap_Geohash_Precision,
+		DemandWindowSecond: Heatmap_Demand_Window_Second,
+	}
+
+	bounds := []*float64{&req.Bounds.MinLat, &req.Bounds.MinLng, &req.Bounds.MaxLat, &req.Bounds.MaxLng}
+	for i, name := range []string{"minlat", "minlng", "maxlat", "maxlng"} {
+		if queryValues.Get(name) == "" {
+			return nil, errors.New("Heatmap bounds (" + name + ") is missing, but required")
+		}
+		v, err := strconv.ParseFloat(queryValues.Get(name), 64)
+		if err != nil {
+			return nil, errors.New("Heatmap bounds (" + name + ") is invalid")
+		}
+		*bounds[i] = v
+	}
+
+	if queryValues.Get("grid") != "" {
+		req.Grid = Heatmap_Grid(queryValues.Get("grid"))
+	}
+
+	if queryValues.Get("edge") != "" {
+		edge, err := strconv.ParseFloat(queryValues.Get("edge"), 64)
+		if err != nil {
+			return nil, errors.New("Heatmap edge is invalid")
+		}
+		req.EdgeMeter = edge
+	}
+
+	if queryValues.Get("precision") != "" {
+		precision, err := strconv.Atoi(queryValues.Get("precision"))
+		if err != nil {
+			return nil, errors.New("Heatmap precision is invalid")
+		}
+		req.Precision = precision
+	}
+
+	if queryValues.Get("window") != "" {
+		window, err := strconv.ParseInt(queryValues.Get("window"), 10, 64)
+		if err != nil {
+			return nil, errors.New("Heatmap demand window is invalid")
+		}
+		req.DemandWindowSecond = window
+	}
+
+	filter, err := location.ParseSearchFilter(queryValues)
+	if err != nil {
+		return nil, err
+	}
+	req.Filter = filter
+
+	return req, nil
+}
@@ -0,0 +1,14 @@
+package heatmap
+
+import (
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+func NewRouter() []common.Route {
+
+	heatmapRouter := []common.Route{
+		common.Route{"GetHeatmap", "GET", "/heatmap", HandleGetHeatmap},
+	}
+
+	return heatmapRouter
+}
@@ -0,0 +1,123 @@
+package heatmap
+
+import (
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/job"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+// HeatmapRequestObject aggregates the drivers of the filter and the jobs created within the demand window
+// in the cells of the grid over the bounds
+type HeatmapRequestObject struct {
+	Bounds             common.BoundingBox
+	Grid               Heatmap_Grid
+	EdgeMeter          float64 // hex only
+	Precision          int     // geohash only
+	DemandWindowSecond int64
+	Filter             *location.SearchFilterObject
+}
+
+// HeatmapObject is the cells with a driver or a job, ordered by cell key
+type HeatmapObject struct {
+	Grid      Heatmap_Grid         `json:"grid"`
+	EdgeMeter float64              `json:"edge,omitempty"`
+	Precision int                  `json:"precision,omitempty"`
+	Bounds    common.BoundingBox   `json:"bounds"`
+	Drivers   int32                `json:"drivers"`
+	Jobs      int32                `json:"jobs"`
+	Truncated bool                 `json:"truncated"` // more drivers or jobs in the bounds than aggregated, the counts are partial
+	Count     int32                `json:"count"`
+	Cells     []*HeatmapCellObject `json:"cells"`
+	grid      grid
+	cells     map[string]*HeatmapCellObject
+}
+
+type HeatmapCellObject struct {
+	Cell   string              `json:"cell"` // "q:r" of the hex grid, or the geohash
+	Center common.GeoPoint     `json:"center"`
+	Supply HeatmapSupplyObject `json:"supply"`
+	Demand HeatmapDemandObject `json:"demand"`
+}
+
+// HeatmapSupplyObject counts the drivers of the cell
+type HeatmapSupplyObject struct {
+	Drivers       int32                           `json:"drivers"`
+	ByStatus      map[location.DriverStatus]int32 `json:"bystatus"`
+	ByServiceType map[int32]int32                 `json:"byservicetype"` // by activeservicetypeid
+	ByProvider    map[int32]int32                 `json:"byprovider"`
+}
+
+// HeatmapDemandObject counts the jobs of the cell by the pickup
+type HeatmapDemandObject struct {
+	Jobs          int32                    `json:"jobs"`
+	ByStatus      map[job.Job_Status]int32 `json:"bystatus"`
+	ByServiceType map[int32]int32          `json:"byservicetype"`
+}
+
+// cell returns the cell of the point, created when the cell has no driver or job yet
+func (o *HeatmapObject) cell(p common.GeoPoint) *HeatmapCellObject {
+	key := o.grid.cellOf(p)
+	if c, ok := o.cells[key]; ok {
+		return c
+	}
+	c := &HeatmapCellObject{
+		Cell:   key,
+		Center: o.grid.center(key),
+		Supply: HeatmapSupplyObject{
+			ByStatus:      map[location.DriverStatus]int32{},
+			ByServiceType: map[int32]int32{},
+			ByProvider:    map[int32]int32{},
+		},
+		Demand: HeatmapDemandObject{
+			ByStatus:      map[job.Job_Status]int32{},
+			ByServiceType: map[int32]int32{},
+		},
+	}
+	o.cells[key] = c
+	return c
+}
+
+// FeatureCollectionObject is a GeoJSON FeatureCollection of the heatmap cells
+type FeatureCollectionObject struct {
+	Type     location.LocationObject_Type `json:"type"`
+	Features []*CellFeatureObject         `json:"features"`
+}
+
+// CellFeatureObject is the polygon of a cell with the counts of the cell as properties
+type CellFeatureObject struct {
+	Type       location.LocationObject_Type `json:"type"`
+	Geometry   PolygonGeometryObject        `json:"geometry"`
+	Properties *HeatmapCellObject           `json:"properties"`
+}
+
+type PolygonGeometryObject struct {
+	Type        location.LocationObject_Type `json:"type"`
+	Coordinates [][][2]float64               `json:"coordinates"`
+}
+
+// FeatureCollection returns the cells as polygon features
+func (o *HeatmapObject) FeatureCollection() *FeatureCollectionObject {
+	fc := &FeatureCollectionObject{
+		Type:     location.LocationObject_Type_FeatureCollection,
+		Features: make([]*CellFeatureObject, len(o.Cells)),
+	}
+	for i, c := range o.Cells {
+		fc.Features[i] = &CellFeatureObject{
+			Type: location.LocationObject_Type_Feature,
+			Geometry: PolygonGeometryObject{
+				Type:        location.LocationObject_Type_Polygon,
+				Coordinates: [][][2]float64{o.grid.ring(c.Cell)},
+			},
+			Properties: c,
+		}
+	}
+	return fc
+}
+
+type HeatmapResultObject struct {
+	Heatmap interface{} `json:"heatmap"`
+}
+
+func (o *HeatmapResultObject) SetResult(result interface{}) {
+	o.Heatmap = result
+}
@@ -0,0 +1,26 @@
+package history
+
+// HistoryTrailLimit
+const (
+	History_Trail_Limit int32 = 10000
+)
+
+// HistoryStoreType
+type HistoryStore_Type string
+
+const (
+	HistoryStore_Type_File HistoryStore_Type = "file" // default, append-only files on local disk
+	HistoryStore_Type_None HistoryStore_Type = "none" // history is not recorded
+)
+
+// HistoryEvent is the update of the driver which recorded the point
+type History_Event string
+
+const (
+	History_Event_Location     History_Event = "location"     // location update of the driver app (udp)
+	History_Event_Availability History_Event = "availability" // driver set availability
+	History_Event_Status       History_Event = "status"       // driver status set by the job system
+	History_Event_JobStart     History_Event = "jobstart"     // driver set busy on a job
+	History_Event_JobEnd       History_Event = "jobend"       // job of the driver completed or cancelled
+	History_Event_Unreachable  History_Event = "unreachable"  // no update of the driver within the heartbeat timeout
+)
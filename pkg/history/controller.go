@@ -0,0 +1,91 @@
+package history
+
+import (
+	"errors"
+	"log"
+)
+
+type HistoryController struct {
+	store HistoryStore
+}
+
+func (hc *HistoryController) Init() error {
+	store, err := GetStore()
+	if err != nil {
+		return err
+	}
+	hc.store = store
+	return nil
+}
+
+// Record the point of the driver, recording failure is logged and does not fail the driver update
+func (hc *HistoryController) RecordDriverPoint(
+	driverID int32,
+	lat float64,
+	lng float64,
+	driverStatus int32,
+	jobID int32,
+	event History_Event,
+	timestamp int64) {
+
+	record := &HistoryRecordObject{
+		DriverID:     driverID,
+		JobID:        jobID,
+		Lat:          lat,
+		Lng:          lng,
+		DriverStatus: driverStatus,
+		Event:        event,
+		Timestamp:    timestamp,
+	}
+
+	err := hc.store.Append(record)
+	if err != nil {
+		log.Printf("Failed to record history of driver %d: %v\n", driverID, err)
+	}
+}
+
+// Get the trail of the driver between from and to (unix time, inclusive)
+func (hc *HistoryController) GetDriverTrail(driverID int32, from int64, to int64, limit int32) (*TrailObject, error) {
+	if driverID <= 0 {
+		return nil, errors.New("Driver ID is not set")
+	}
+	if from > to {
+		return nil, errors.New("Trail from time is after to time")
+	}
+
+	points, err := hc.store.DriverTrail(driverID, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TrailObject{
+		DriverID: driverID,
+		From:     from,
+		To:       to,
+		Count:    int32(len(points)),
+		Points:   points,
+	}, nil
+}
+
+// Get the trail of all drivers while on the job
+func (hc *HistoryController) GetJobTrail(jobID int32, limit int32) (*TrailObject, error) {
+	if jobID <= 0 {
+		return nil, errors.New("Job ID is not set")
+	}
+
+	points, err := hc.store.JobTrail(jobID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	trail := &TrailObject{
+		JobID:  jobID,
+		Count:  int32(len(points)),
+		Points: points,
+	}
+	if len(points) > 0 {
+		trail.From = points[0].Timestamp
+		trail.To = points[len(points)-1].Timestamp
+	}
+	return trail, nil
+}
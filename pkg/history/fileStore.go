@@ -0,0 +1,151 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+// max number of trail files kept open for appending
+const fileStoreMaxOpenFiles int = 256
+
+// fileStore implements HistoryStore with one append-only file of JSON lines per driver and per job:
+// <dir>/driver/<driverid>.log and <dir>/job/<jobid>.log
+type fileStore struct {
+	dir   string
+	mu    sync.Mutex
+	files map[string]*os.File
+}
+
+func newFileStore(dir string) (*fileStore, error) {
+	if dir == "" {
+		dir = "history"
+	}
+	for _, sub := range []string{"driver", "job"} {
+		err := os.MkdirAll(filepath.Join(dir, sub), 0755)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &fileStore{dir: dir, files: make(map[string]*os.File)}, nil
+}
+
+func (fs *fileStore) Append(record *HistoryRecordObject) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	err = fs.write(fs.driverPath(record.DriverID), line)
+	if err != nil {
+		return err
+	}
+	if record.JobID != 0 {
+		err = fs.write(fs.jobPath(record.JobID), line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *fileStore) DriverTrail(driverID int32, from int64, to int64, limit int32) ([]HistoryRecordObject, error) {
+	return fs.read(fs.driverPath(driverID), func(r *HistoryRecordObject) bool {
+		return r.Timestamp >= from && r.Timestamp <= to
+	}, limit)
+}
+
+func (fs *fileStore) JobTrail(jobID int32, limit int32) ([]HistoryRecordObject, error) {
+	return fs.read(fs.jobPath(jobID), func(r *HistoryRecordObject) bool {
+		return true
+	}, limit)
+}
+
+func (fs *fileStore) driverPath(driverID int32) string {
+	return filepath.Join(fs.dir, "driver", common.String(driverID)+".log")
+}
+
+func (fs *fileStore) jobPath(jobID int32) string {
+	return filepath.Join(fs.dir, "job", common.String(jobID)+".log")
+}
+
+// write appends the line to the file, the line is written with a single call so readers never see
+// records of different writers mixed. Caller must hold the lock
+func (fs *fileStore) write(path string, line []byte) error {
+	f, ok := fs.files[path]
+	if !ok {
+		// close all files when the limit is reached, they are opened again on next write
+		if len(fs.files) >= fileStoreMaxOpenFiles {
+			for p, of := range fs.files {
+				of.Close()
+				delete(fs.files, p)
+			}
+		}
+
+		var err error
+		f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		fs.files[path] = f
+	}
+
+	_, err := f.Write(line)
+	if err != nil {
+		// drop the handle so the file is opened again on next write
+		f.Close()
+		delete(fs.files, path)
+		return err
+	}
+	return nil
+}
+
+// read returns the matching records of the file ordered by time, a missing file is an empty trail
+func (fs *fileStore) read(path string, match func(r *HistoryRecordObject) bool, limit int32) ([]HistoryRecordObject, error) {
+	records := []HistoryRecordObject{}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r HistoryRecordObject
+		err := json.Unmarshal(scanner.Bytes(), &r)
+		if err != nil {
+			// a line cut by a crash or being written, skip it
+			log.Printf("Skipping invalid history record in %s: %v\n", path, err)
+			continue
+		}
+		if match(&r) {
+			records = append(records, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// records are appended in the order received, which may differ slightly from the record time
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Timestamp < records[j].Timestamp })
+
+	if limit > 0 && int(limit) < len(records) {
+		records = records[:limit]
+	}
+	return records, nil
+}
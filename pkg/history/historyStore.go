@@ -0,0 +1,63 @@
+package history
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/iknowhtml/locationtracker/pkg/config"
+)
+
+// HistoryStore keeps the past points of the drivers. Records are only appended, never updated
+type HistoryStore interface {
+	// Append adds the record to the trail of the driver, and to the trail of the job if the job id is set
+	Append(record *HistoryRecordObject) error
+	// DriverTrail returns the records of the driver between from and to (unix time, inclusive) ordered by time
+	DriverTrail(driverID int32, from int64, to int64, limit int32) ([]HistoryRecordObject, error)
+	// JobTrail returns the records of the job ordered by time
+	JobTrail(jobID int32, limit int32) ([]HistoryRecordObject, error)
+}
+
+var hs HistoryStore
+var hsErr error
+var hsOnce sync.Once
+
+// GetStore returns the history store configured in historystore.store, singleton pattern
+func GetStore() (HistoryStore, error) {
+	hsOnce.Do(func() {
+		// load system configuration based on environment, singleton pattern
+		configuration, err := config.GetInstance("")
+		if configuration == nil {
+			hsErr = err
+			return
+		}
+
+		switch HistoryStore_Type(configuration.Historystore.Store) {
+		case HistoryStore_Type_File, "":
+			log.Printf("Using file history store: %s\n", configuration.Historystore.Dir)
+			hs, hsErr = newFileStore(configuration.Historystore.Dir)
+		case HistoryStore_Type_None:
+			log.Printf("History store is disabled\n")
+			hs = noneStore{}
+		default:
+			hsErr = errors.New("Unknown history store type: " + configuration.Historystore.Store)
+		}
+	})
+
+	return hs, hsErr
+}
+
+// noneStore discards the records
+type noneStore struct{}
+
+func (noneStore) Append(record *HistoryRecordObject) error {
+	return nil
+}
+
+func (noneStore) DriverTrail(driverID int32, from int64, to int64, limit int32) ([]HistoryRecordObject, error) {
+	return []HistoryRecordObject{}, nil
+}
+
+func (noneStore) JobTrail(jobID int32, limit int32) ([]HistoryRecordObject, error) {
+	return []HistoryRecordObject{}, nil
+}
@@ -0,0 +1,133 @@
+package history
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+// url: driverid ("id") (required)
+// Url Param: from unix time (from) (required)
+// to unix time (to) (optional, default now)
+// limit (limit) (optional)
+func HandleGetDriverTrail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	vars := mux.Vars(r)
+	driverID, err := strconv.ParseInt(vars["id"], 10, 32)
+	if err != nil || driverID <= 0 {
+		common.HandleStatus400Response(w, "Driver ID is invalid")
+		return
+	}
+
+	// get Url Param
+	queryValues := r.URL.Query()
+	log.Println(queryValues)
+
+	if queryValues.Get("from") == "" {
+		common.HandleStatus400Response(w, "From time is missing, but required")
+		return
+	}
+	from, err := strconv.ParseInt(queryValues.Get("from"), 10, 64)
+	if err != nil {
+		common.HandleStatus400Response(w, "From time is invalid")
+		return
+	}
+	to := time.Now().Unix()
+	if queryValues.Get("to") != "" {
+		to, err = strconv.ParseInt(queryValues.Get("to"), 10, 64)
+		if err != nil {
+			common.HandleStatus400Response(w, "To time is invalid")
+			return
+		}
+	}
+	if from > to {
+		common.HandleStatus400Response(w, "From time is after to time")
+		return
+	}
+	limit, err := parseLimit(queryValues)
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	historyController := new(HistoryController)
+	err = historyController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := historyController.GetDriverTrail(int32(driverID), from, to, limit)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &TrailResultObject{Trail: res})
+}
+
+// url: jobid ("id") (required)
+// Url Param: limit (limit) (optional)
+func HandleGetJobTrail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 32)
+	if err != nil || jobID <= 0 {
+		common.HandleStatus400Response(w, "Job ID is invalid")
+		return
+	}
+
+	// get Url Param
+	queryValues := r.URL.Query()
+	log.Println(queryValues)
+
+	limit, err := parseLimit(queryValues)
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	historyController := new(HistoryController)
+	err = historyController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := historyController.GetJobTrail(int32(jobID), limit)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &TrailResultObject{Trail: res})
+}
+
+func parseLimit(queryValues url.Values) (int32, error) {
+	if queryValues.Get("limit") == "" {
+		return History_Trail_Limit, nil
+	}
+	l, err := strconv.ParseInt(queryValues.Get("limit"), 10, 32)
+	if err != nil || l <= 0 || int32(l) > History_Trail_Limit {
+		return 0, errors.New("Limit is invalid")
+	}
+	return int32(l), nil
+}
@@ -0,0 +1,15 @@
+package history
+
+import (
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+func NewRouter() []common.Route {
+
+	historyRouter := []common.Route{
+		common.Route{"GetDriverTrail", "GET", "/driver/{id:[0-9]+}/trail", HandleGetDriverTrail},
+		common.Route{"GetJobTrail", "GET", "/job/{id:[0-9]+}/trail", HandleGetJobTrail},
+	}
+
+	return historyRouter
+}
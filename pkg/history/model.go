@@ -0,0 +1,29 @@
+package history
+
+// HistoryRecordObject is a point of the trail of a driver, coordinates are the same as the fleet collection
+type HistoryRecordObject struct {
+	DriverID     int32         `json:"driverid"`
+	JobID        int32         `json:"jobid,omitempty"`
+	Lat          float64       `json:"lat"`
+	Lng          float64       `json:"lng"`
+	DriverStatus int32         `json:"driverstatus"`
+	Event        History_Event `json:"event"`
+	Timestamp    int64         `json:"timestamp"`
+}
+
+type TrailObject struct {
+	DriverID int32                 `json:"driverid,omitempty"`
+	JobID    int32                 `json:"jobid,omitempty"`
+	From     int64                 `json:"from,omitempty"`
+	To       int64                 `json:"to,omitempty"`
+	Count    int32                 `json:"count"`
+	Points   []HistoryRecordObject `json:"points"`
+}
+
+type TrailResultObject struct {
+	Trail interface{} `json:"trail"`
+}
+
+func (o *TrailResultObject) SetResult(result interface{}) {
+	o.Trail = result
+}
@@ -0,0 +1,31 @@
+package job
+
+// JobStatus
+type Job_Status int32
+
+const (
+	Job_Status_Created   Job_Status = 1 // waiting for a driver
+	Job_Status_Assigned  Job_Status = 2
+	Job_Status_Completed Job_Status = 3
+	Job_Status_Cancelled Job_Status = 4
+)
+
+func (status Job_Status) String() string {
+	switch status {
+	case Job_Status_Created:
+		return "Created"
+	case Job_Status_Assigned:
+		return "Assigned"
+	case Job_Status_Completed:
+		return "Completed"
+	case Job_Status_Cancelled:
+		return "Cancelled"
+	default:
+		return "Unknown"
+	}
+}
+
+// IsEnded is true when the job is completed or cancelled, an ended job is not changed anymore
+func (status Job_Status) IsEnded() bool {
+	return status == Job_Status_Completed || status == Job_Status_Cancelled
+}
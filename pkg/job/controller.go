@@ -0,0 +1,355 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/eta"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+var (
+	ErrJobNotFound    = errors.New("Job not found")
+	ErrJobExists      = errors.New("Job already exists")
+	ErrJobNotAssigned = errors.New("Job is not assigned to a driver")
+	ErrDriverNotFound = errors.New("Driver not found")
+	// ErrJobConflict is returned when the job is changed by another request between the check and the update
+	ErrJobConflict = errors.New("Job was changed by another request")
+)
+
+// JobStatusError is returned when the action is not allowed in the status of the job
+type JobStatusError struct {
+	Status Job_Status
+	Action string
+}
+
+func (e *JobStatusError) Error() string {
+	return fmt.Sprintf("Job cannot be %s, job is %s", e.Action, e.Status)
+}
+
+type JobController struct {
+	locationService    *location.LocationService
+	locationController *location.LocationController
+}
+
+func (jc *JobController) Init() error {
+	jc.locationService = new(location.LocationService)
+	err := jc.locationService.Init(nil)
+	if err != nil {
+		return err
+	}
+	jc.locationController = new(location.LocationController)
+	err = jc.locationController.Init()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Create Job waiting for a driver
+// if Job id already used, return ErrJobExists
+func (jc *JobController) CreateJob(reqObj *JobRequestObject) (*JobObject, error) {
+
+	err := ValidateJobRequest(reqObj)
+	if err != nil {
+		return nil, err
+	}
+	tenant, err := location.GetTenant(reqObj.Fleet)
+	if err != nil {
+		return nil, err
+	}
+
+	// Construct LocationObject of the pickup point
+	locationObj := location.NewLocationObject(reqObj.Pickup.Point())
+	timeNow := time.Now().Unix()
+	fields := location.LocationObject_Fields{
+		"jobstatus":       int32(Job_Status_Created),
+		"tenantid":        tenant.ID,
+		"providerid":      reqObj.ProviderID,
+		"servicetypeid":   reqObj.ServiceTypeID,
+		"serviceid":       reqObj.ServiceID,
+		"dropofflat":      reqObj.Dropoff.Lat,
+		"dropofflng":      reqObj.Dropoff.Lng,
+		"createdtime":     timeNow,
+		"lastupdatedtime": timeNow,
+	}
+
+	// Set object only when the job does not exist
+	res, err := jc.locationService.SetObjectIf(location.Object_Collection_Job, reqObj.ID, nil, locationObj, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// check if ok is false
+	if res.Ok == false {
+		if res.Error == location.GeoStore_Error_Conflict {
+			return nil, ErrJobExists
+		}
+		return nil, errors.New(res.Error)
+	}
+
+	log.Printf("Job created: %d\n", reqObj.ID)
+	return &JobObject{
+		ID:                   reqObj.ID,
+		Fleet:                tenant.Name,
+		Status:               Job_Status_Created,
+		ProviderID:           reqObj.ProviderID,
+		ServiceTypeID:        reqObj.ServiceTypeID,
+		ServiceID:            reqObj.ServiceID,
+		Pickup:               reqObj.Pickup,
+		Dropoff:              reqObj.Dropoff,
+		CreatedTimestamp:     timeNow,
+		LastUpdatedTimestamp: timeNow,
+	}, nil
+}
+
+// if Job not found, return ErrJobNotFound
+func (jc *JobController) GetJob(jobID int32) (*JobObject, error) {
+
+	if jobID <= 0 {
+		return nil, errors.New("Job id is not set")
+	}
+
+	res, err := jc.locationService.GetGeoJSONObject(location.Object_Collection_Job, location.GenerateLocationObjectId("", jobID))
+	if err != nil {
+		return nil, err
+	}
+
+	// check if response object is empty
+	if res == nil {
+		return nil, errors.New("response object is empty")
+	}
+	if !res.Ok {
+		return nil, ErrJobNotFound
+	}
+
+	job := &JobObject{}
+	return job.MapFrom(jobID, res.Object, res.Fields)
+}
+
+// Assign the job to the driver, the driver is set busy on the job.
+// if Job is not waiting for a driver, return JobStatusError
+// if Driver cannot be set busy, the job is waiting for a driver again and the error of the driver is returned
+func (jc *JobController) AssignJob(jobID int32, driverID int32) (*JobObject, error) {
+
+	job, err := jc.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != Job_Status_Created {
+		return nil, &JobStatusError{Status: job.Status, Action: "assigned"}
+	}
+
+	// only a driver of the tenant of the job is assigned
+	locationController, err := jc.locationController.ForTenant(job.Fleet)
+	if err != nil {
+		return nil, err
+	}
+	driverObj, err := locationController.GetDriverStatus(driverID)
+	if err != nil {
+		return nil, err
+	}
+	if !driverObj.Ok {
+		return nil, ErrDriverNotFound
+	}
+
+	// the job is assigned first, so the job is not assigned to two drivers
+	timeNow := time.Now().Unix()
+	err = jc.setJobFieldIf(jobID, location.LocationObject_Fields{
+		"jobstatus": int32(Job_Status_Created),
+	}, location.LocationObject_Fields{
+		"jobstatus":        int32(Job_Status_Assigned),
+		"driverid":         driverID,
+		"driverproviderid": driverObj.Fields.ProviderID,
+		"assignedtime":     timeNow,
+		"lastupdatedtime":  timeNow,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = locationController.SetAvailabilityBusy(driverID, jobID)
+	if err != nil {
+		// the driver is not available for the job, the job waits for another driver
+		rollbackErr := jc.setJobFieldIf(jobID, location.LocationObject_Fields{
+			"jobstatus": int32(Job_Status_Assigned),
+			"driverid":  driverID,
+		}, location.LocationObject_Fields{
+			"jobstatus":        int32(Job_Status_Created),
+			"driverid":         0,
+			"driverproviderid": 0,
+			"assignedtime":     0,
+			"lastupdatedtime":  time.Now().Unix(),
+		})
+		if rollbackErr != nil {
+			log.Printf("Job %d: failed to unassign driver %d: %v\n", jobID, driverID, rollbackErr)
+		}
+		return nil, err
+	}
+
+	job.Status = Job_Status_Assigned
+	job.DriverID = driverID
+	job.DriverProviderID = driverObj.Fields.ProviderID
+	job.AssignedTimestamp = timeNow
+	job.LastUpdatedTimestamp = timeNow
+
+	log.Printf("Job assigned: %d, driver: %d\n", jobID, driverID)
+	return job, nil
+}
+
+// Complete the assigned job, the driver of the job is available again
+// if Job is not assigned, return JobStatusError
+func (jc *JobController) CompleteJob(jobID int32) (*JobObject, error) {
+
+	job, err := jc.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != Job_Status_Assigned {
+		return nil, &JobStatusError{Status: job.Status, Action: "completed"}
+	}
+
+	return jc.endJob(job, Job_Status_Completed)
+}
+
+// Cancel the job waiting for a driver or assigned, the driver of the job is available again
+// if Job is completed or cancelled, return JobStatusError
+func (jc *JobController) CancelJob(jobID int32) (*JobObject, error) {
+
+	job, err := jc.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status.IsEnded() {
+		return nil, &JobStatusError{Status: job.Status, Action: "cancelled"}
+	}
+
+	return jc.endJob(job, Job_Status_Cancelled)
+}
+
+// Get the current location of the driver of the job and the distance and the travel time to the pickup
+// if Job is not assigned, return ErrJobNotAssigned
+func (jc *JobController) GetJobDriver(jobID int32) (*JobDriverObject, error) {
+
+	job, err := jc.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != Job_Status_Assigned || job.DriverID == 0 {
+		return nil, ErrJobNotAssigned
+	}
+
+	locationController, err := jc.locationController.ForTenant(job.Fleet)
+	if err != nil {
+		return nil, err
+	}
+	driverObj, err := locationController.GetDriverStatus(job.DriverID)
+	if err != nil {
+		return nil, err
+	}
+	if !driverObj.Ok {
+		return nil, ErrDriverNotFound
+	}
+
+	jobDriver := &JobDriverObject{
+		JobID:                jobID,
+		DriverID:             job.DriverID,
+		DriverStatus:         driverObj.Fields.Status,
+		DriverLocation:       driverObj.Object,
+		Pickup:               job.Pickup,
+		PickupDistance:       driverObj.Object.Point().DistanceTo(job.Pickup.Point()),
+		LastUpdatedTimestamp: driverObj.Fields.LastUpdatedTimestamp,
+	}
+
+	// the job is still returned without the travel time when the estimator is not available
+	estimator, err := eta.GetEstimator()
+	if err != nil {
+		log.Printf("ETA estimator is not available: %v\n", err)
+		return jobDriver, nil
+	}
+	jobDriver.ETASeconds, err = estimator.Estimate(driverObj.Object.Point(), job.Pickup.Point(), job.ServiceTypeID, time.Now())
+	if err != nil {
+		log.Printf("Failed to estimate ETA of job %d: %v\n", jobID, err)
+	}
+	return jobDriver, nil
+}
+
+// ValidateJobRequest checks the id, the points and the fleet of the job, the dropoff is optional
+func ValidateJobRequest(reqObj *JobRequestObject) error {
+	if reqObj.ID <= 0 {
+		return errors.New("Job id is missing, but required")
+	}
+	if reqObj.Pickup.Point().IsZero() {
+		return errors.New("Job pickup is missing, but required")
+	}
+	if err := reqObj.Pickup.Point().Validate(); err != nil {
+		return errors.New("Job pickup is invalid: " + err.Error())
+	}
+	// dropoff not set is (0, 0)
+	if !reqObj.Dropoff.Point().IsZero() {
+		if err := reqObj.Dropoff.Point().Validate(); err != nil {
+			return errors.New("Job dropoff is invalid: " + err.Error())
+		}
+	}
+	if _, err := location.GetTenant(reqObj.Fleet); err != nil {
+		return err
+	}
+	return nil
+}
+
+// endJob completes or cancels the job, then the driver of an assigned job is released.
+// A driver already off the job is not changed
+func (jc *JobController) endJob(job *JobObject, status Job_Status) (*JobObject, error) {
+
+	timeNow := time.Now().Unix()
+	err := jc.setJobFieldIf(job.ID, location.LocationObject_Fields{
+		"jobstatus": int32(job.Status),
+		"driverid":  job.DriverID,
+	}, location.LocationObject_Fields{
+		"jobstatus":       int32(status),
+		"endedtime":       timeNow,
+		"lastupdatedtime": timeNow,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if job.DriverID != 0 {
+		locationController, err := jc.locationController.ForTenant(job.Fleet)
+		if err != nil {
+			return nil, err
+		}
+		_, err = locationController.SetDriverJobCompleteOrCancel(job.DriverID, job.ID)
+		if _, ok := err.(*location.DriverTransitionError); ok {
+			log.Printf("Job %d: driver %d not released: %v\n", job.ID, job.DriverID, err)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	job.Status = status
+	job.EndedTimestamp = timeNow
+	job.LastUpdatedTimestamp = timeNow
+
+	log.Printf("Job %s: %d\n", status, job.ID)
+	return job, nil
+}
+
+// setJobFieldIf sets the fields only when the job is not changed since it was read
+func (jc *JobController) setJobFieldIf(jobID int32, expected location.LocationObject_Fields, fields location.LocationObject_Fields) error {
+	res, err := jc.locationService.SetFieldIf(location.Object_Collection_Job, jobID, expected, fields)
+	if err != nil {
+		return err
+	}
+
+	// check if ok is false
+	if res.Ok == false {
+		if res.Error == location.GeoStore_Error_Conflict {
+			return ErrJobConflict
+		}
+		return errors.New(res.Error)
+	}
+	return nil
+}
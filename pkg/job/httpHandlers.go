@@ -0,0 +1,253 @@
+package job
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+// POST body: { "id": 1, "fleet": "towing", "providerid": 1, "servicetypeid": 1, "serviceid": 1,
+// "pickup": { "lat": 3.1, "lng": 101.6 }, "dropoff": { "lat": 3.2, "lng": 101.7 } }, dropoff is optional
+// fleet is the tenant of the job, the default tenant when not set
+func HandleCreateJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	// reading POST body
+	log.Println("Decoding request json body")
+	decoder := json.NewDecoder(r.Body)
+	var reqObj JobRequestObject
+	err := decoder.Decode(&reqObj)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+	log.Println(reqObj)
+
+	if err := ValidateJobRequest(&reqObj); err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	jobController := new(JobController)
+	err = jobController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := jobController.CreateJob(&reqObj)
+	if err != nil {
+		handleJobErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &JobResultObject{Job: res})
+}
+
+// url: job id ("id") (required)
+func HandleGetJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	jobID, err := parseJobID(mux.Vars(r))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	jobController := new(JobController)
+	err = jobController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := jobController.GetJob(jobID)
+	if err != nil {
+		handleJobErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &JobResultObject{Job: res})
+}
+
+// url: job id ("id") (required)
+// POST body: { "driverid": 1 }
+func HandleAssignJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	jobID, err := parseJobID(mux.Vars(r))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	// reading POST body
+	log.Println("Decoding request json body")
+	decoder := json.NewDecoder(r.Body)
+	var reqObj JobAssignRequestObject
+	err = decoder.Decode(&reqObj)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+	log.Println(reqObj)
+
+	if reqObj.DriverID <= 0 {
+		common.HandleStatus400Response(w, "Driver ID is missing, but required")
+		return
+	}
+
+	jobController := new(JobController)
+	err = jobController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := jobController.AssignJob(jobID, reqObj.DriverID)
+	if err != nil {
+		handleJobErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &JobResultObject{Job: res})
+}
+
+// url: job id ("id") (required)
+func HandleCompleteJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	jobID, err := parseJobID(mux.Vars(r))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	jobController := new(JobController)
+	err = jobController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := jobController.CompleteJob(jobID)
+	if err != nil {
+		handleJobErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &JobResultObject{Job: res})
+}
+
+// url: job id ("id") (required)
+func HandleCancelJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	jobID, err := parseJobID(mux.Vars(r))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	jobController := new(JobController)
+	err = jobController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := jobController.CancelJob(jobID)
+	if err != nil {
+		handleJobErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &JobResultObject{Job: res})
+}
+
+// url: job id ("id") (required)
+func HandleGetJobDriver(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	jobID, err := parseJobID(mux.Vars(r))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	jobController := new(JobController)
+	err = jobController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := jobController.GetJobDriver(jobID)
+	if err != nil {
+		handleJobErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &JobDriverResultObject{JobDriver: res})
+}
+
+func parseJobID(vars map[string]string) (int32, error) {
+	id, err := strconv.ParseInt(vars["id"], 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("Job id is invalid")
+	}
+	return int32(id), nil
+}
+
+func handleJobErrorResponse(w http.ResponseWriter, err error) {
+	switch err.(type) {
+	case *JobStatusError, *location.DriverTransitionError:
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	switch err {
+	case ErrJobNotFound, ErrDriverNotFound:
+		common.HandleStatusNotFoundResponse(w, err.Error())
+	case ErrJobExists, ErrJobNotAssigned:
+		common.HandleStatus400Response(w, err.Error())
+	case ErrJobConflict, location.ErrDriverStatusConflict:
+		// the job or the driver is changed by another request, the caller may read the job and try again
+		common.HandleStatusConflictResponse(w, err.Error())
+	default:
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+	}
+}
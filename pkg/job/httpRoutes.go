@@ -0,0 +1,19 @@
+package job
+
+import (
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+func NewRouter() []common.Route {
+
+	jobRouter := []common.Route{
+		common.Route{"CreateJob", "POST", "/jobs", HandleCreateJob},
+		common.Route{"GetJob", "GET", "/jobs/{id:[0-9]+}", HandleGetJob},
+		common.Route{"AssignJob", "POST", "/jobs/{id:[0-9]+}/assign", HandleAssignJob},
+		common.Route{"CompleteJob", "POST", "/jobs/{id:[0-9]+}/complete", HandleCompleteJob},
+		common.Route{"CancelJob", "POST", "/jobs/{id:[0-9]+}/cancel", HandleCancelJob},
+		common.Route{"GetJobDriver", "GET", "/jobs/{id:[0-9]+}/driver", HandleGetJobDriver},
+	}
+
+	return jobRouter
+}
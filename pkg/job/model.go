@@ -0,0 +1,111 @@
+package job
+
+import (
+	"encoding/json"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+type JobPointObject struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Point returns the location of the job point
+func (p JobPointObject) Point() common.GeoPoint {
+	return common.GeoPoint{Lat: p.Lat, Lng: p.Lng}
+}
+
+type JobRequestObject struct {
+	ID            int32          `json:"id"`
+	Fleet         string         `json:"fleet"` // tenant of the job, only its drivers take the job, the default tenant when empty
+	ProviderID    int32          `json:"providerid"`
+	ServiceTypeID int32          `json:"servicetypeid"`
+	ServiceID     int32          `json:"serviceid"`
+	Pickup        JobPointObject `json:"pickup"`
+	Dropoff       JobPointObject `json:"dropoff"`
+}
+
+type JobAssignRequestObject struct {
+	DriverID int32 `json:"driverid"`
+}
+
+// JobObject is a job of the job collection, the object of the job is the pickup point.
+// The other properties are kept in the fields, since Tile38 fields are numeric only
+type JobObject struct {
+	ID                   int32          `json:"id"`
+	Fleet                string         `json:"fleet"` // tenant of the job, kept as the tenant id in the fields
+	Status               Job_Status     `json:"status"`
+	ProviderID           int32          `json:"providerid"`
+	ServiceTypeID        int32          `json:"servicetypeid"`
+	ServiceID            int32          `json:"serviceid"`
+	Pickup               JobPointObject `json:"pickup"`
+	Dropoff              JobPointObject `json:"dropoff"`
+	DriverID             int32          `json:"driverid,omitempty"`
+	DriverProviderID     int32          `json:"driverproviderid,omitempty"` // provider of the driver when assigned
+	CreatedTimestamp     int64          `json:"createdtime"`
+	AssignedTimestamp    int64          `json:"assignedtime,omitempty"`
+	EndedTimestamp       int64          `json:"endedtime,omitempty"` // completed or cancelled
+	LastUpdatedTimestamp int64          `json:"lastupdatedtime"`
+}
+
+// MapFrom maps the pickup point and the fields of the job
+func (o *JobObject) MapFrom(id int32, object json.RawMessage, fields map[string]float64) (*JobObject, error) {
+	var pickup location.LocationResponseObject
+	err := json.Unmarshal(object, &pickup)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := location.GetTenantByID(int32(fields["tenantid"]))
+	if err != nil {
+		return nil, err
+	}
+
+	point := pickup.Point()
+	return &JobObject{
+		ID:                   id,
+		Fleet:                tenant.Name,
+		Status:               Job_Status(fields["jobstatus"]),
+		ProviderID:           int32(fields["providerid"]),
+		ServiceTypeID:        int32(fields["servicetypeid"]),
+		ServiceID:            int32(fields["serviceid"]),
+		Pickup:               JobPointObject{Lat: point.Lat, Lng: point.Lng},
+		Dropoff:              JobPointObject{Lat: fields["dropofflat"], Lng: fields["dropofflng"]},
+		DriverID:             int32(fields["driverid"]),
+		DriverProviderID:     int32(fields["driverproviderid"]),
+		CreatedTimestamp:     int64(fields["createdtime"]),
+		AssignedTimestamp:    int64(fields["assignedtime"]),
+		EndedTimestamp:       int64(fields["endedtime"]),
+		LastUpdatedTimestamp: int64(fields["lastupdatedtime"]),
+	}, nil
+}
+
+// JobDriverObject is the current location of the driver of the job and the distance and the travel time to the pickup
+type JobDriverObject struct {
+	JobID                int32                           `json:"jobid"`
+	DriverID             int32                           `json:"driverid"`
+	DriverStatus         location.DriverStatus           `json:"driverstatus"`
+	DriverLocation       location.LocationResponseObject `json:"driverlocation"`
+	Pickup               JobPointObject                  `json:"pickup"`
+	PickupDistance       float64                         `json:"pickupdistance"` // meter
+	ETASeconds           int64                           `json:"eta_seconds"`    // travel time to the pickup
+	LastUpdatedTimestamp int64                           `json:"lastupdatedtime"`
+}
+
+type JobResultObject struct {
+	Job interface{} `json:"job"`
+}
+
+func (o *JobResultObject) SetResult(result interface{}) {
+	o.Job = result
+}
+
+type JobDriverResultObject struct {
+	JobDriver interface{} `json:"jobdriver"`
+}
+
+func (o *JobDriverResultObject) SetResult(result interface{}) {
+	o.JobDriver = result
+}
@@ -15,7 +15,12 @@ const (
 
 // SearchLimit
 const (
-	Search_Limit int32 = 20
+	Search_Limit     int32 = 20
+	Search_Max_Limit int32 = 500 // max page size of a paged search
+	Search_Max_Pages int32 = 10  // max number of searches to fill a page when drivers are removed from the results
+	// drivers within the bounds of a map viewport
+	Search_Within_Limit     int32 = 5000
+	Search_Within_Max_Limit int32 = 20000
 )
 
 // ObjectCollection
@@ -24,15 +29,17 @@ type Object_Collection string
 const (
 	Object_Collection_Fleet Object_Collection = "fleet"
 	Object_Collection_POI   Object_Collection = "poi"
+	Object_Collection_Zone  Object_Collection = "zone"
+	Object_Collection_Job   Object_Collection = "job"
 )
 
 // LocationSearch_Type
 type LocationSearch_Type string
 
 const (
-	LocationSearch_Type_Nearby     LocationDetect_Type = "nearby"
-	LocationSearch_Type_Within     LocationDetect_Type = "within"
-	LocationSearch_Type_Intersects LocationDetect_Type = "intersects"
+	LocationSearch_Type_Nearby     LocationSearch_Type = "nearby"
+	LocationSearch_Type_Within     LocationSearch_Type = "within"
+	LocationSearch_Type_Intersects LocationSearch_Type = "intersects"
 )
 
 // LocationDetect_Type
@@ -61,16 +68,36 @@ const (
 type LocationObject_Type string
 
 const (
-	LocationObject_Type_Point LocationObject_Type = "point"
+	LocationObject_Type_Point             LocationObject_Type = "point"
+	LocationObject_Type_Polygon           LocationObject_Type = "Polygon"
+	LocationObject_Type_MultiPolygon      LocationObject_Type = "MultiPolygon"
+	LocationObject_Type_GeoJSONPoint      LocationObject_Type = "Point" // type of the GeoJSON geometry, point is used by the location object
+	LocationObject_Type_Feature           LocationObject_Type = "Feature"
+	LocationObject_Type_FeatureCollection LocationObject_Type = "FeatureCollection"
+)
+
+// SearchTier
+type NearbySearch_Tier string
+
+const (
+	NearbySearch_Tier_1      NearbySearch_Tier = "1"
+	NearbySearch_Tier_2      NearbySearch_Tier = "2"
+	NearbySearch_Tier_3      NearbySearch_Tier = "3"
+	NearbySearch_Tier_Expand NearbySearch_Tier = "expand" // All tiers from the nearest until the limit is reached
 )
 
 // SearchAvailability
 type NearbySearch_Availability string
 
 const (
-	NearbySearch_Availability_2   NearbySearch_Availability = "2"   // Busy
+	NearbySearch_Availability_8   NearbySearch_Availability = "8"   // On Trip
+	NearbySearch_Availability_7   NearbySearch_Availability = "7"   // Arrived at Pickup
+	NearbySearch_Availability_6   NearbySearch_Availability = "6"   // En Route to Pickup
+	NearbySearch_Availability_5   NearbySearch_Availability = "5"   // Offered
+	NearbySearch_Availability_4   NearbySearch_Availability = "4"   // On Break
+	NearbySearch_Availability_2   NearbySearch_Availability = "2"   // Busy, any status on job when searching
 	NearbySearch_Availability_1   NearbySearch_Availability = "1"   // Available
-	NearbySearch_Availability_0   NearbySearch_Availability = "0"   // Not Available
+	NearbySearch_Availability_0   NearbySearch_Availability = "0"   // Not Available, any status not available for a job when searching
 	NearbySearch_Availability_All NearbySearch_Availability = "all" // All
 )
 
@@ -94,9 +121,32 @@ const (
 type DriverStatus int
 
 const (
-	DriverStatus_AVAILABLE    DriverStatus = 1
-	DriverStatus_NOTAVAILABLE DriverStatus = 0 // default status
-	DriverStatus_BUSY         DriverStatus = 2
+	DriverStatus_AVAILABLE          DriverStatus = 1
+	DriverStatus_NOTAVAILABLE       DriverStatus = 0 // default status
+	DriverStatus_BUSY               DriverStatus = 2
+	DriverStatus_UNREACHABLE        DriverStatus = 3 // set by the system when no update received within the heartbeat timeout
+	DriverStatus_ON_BREAK           DriverStatus = 4
+	DriverStatus_OFFERED            DriverStatus = 5 // a job is offered to the driver, waiting for the driver to accept
+	DriverStatus_EN_ROUTE_TO_PICKUP DriverStatus = 6
+	DriverStatus_ARRIVED_AT_PICKUP  DriverStatus = 7
+	DriverStatus_ON_TRIP            DriverStatus = 8
+)
+
+// DriverMotion is derived from the positions of the driver on each update
+type DriverMotion int
+
+const (
+	DriverMotion_UNKNOWN DriverMotion = 0 // default motion, before the second position of the driver
+	DriverMotion_MOVING  DriverMotion = 1
+	DriverMotion_IDLE    DriverMotion = 2 // slower than the moving speed, but moved within the stopped time
+	DriverMotion_STOPPED DriverMotion = 3 // not moved within the stopped time
+)
+
+// Motion defaults
+const (
+	Motion_Moving_Kmh     float64 = 5
+	Motion_Stopped_Second int32   = 300
+	Motion_Bearing_Meter  float64 = 10 // min distance moved for the bearing to be changed, the bearing of a small move is noise
 )
 
 func (status DriverStatus) String() string {
@@ -106,10 +156,16 @@ func (status DriverStatus) String() string {
 	names[int(DriverStatus_AVAILABLE)] = "Available"
 	names[int(DriverStatus_NOTAVAILABLE)] = "Not Available"
 	names[int(DriverStatus_BUSY)] = "Busy"
+	names[int(DriverStatus_UNREACHABLE)] = "Unreachable"
+	names[int(DriverStatus_ON_BREAK)] = "On Break"
+	names[int(DriverStatus_OFFERED)] = "Offered"
+	names[int(DriverStatus_EN_ROUTE_TO_PICKUP)] = "En Route to Pickup"
+	names[int(DriverStatus_ARRIVED_AT_PICKUP)] = "Arrived at Pickup"
+	names[int(DriverStatus_ON_TRIP)] = "On Trip"
 
 	// prevent panicking in case of
 	// `day` is out of range of Weekday
-	if status < DriverStatus_NOTAVAILABLE || status > DriverStatus_BUSY {
+	if status < DriverStatus_NOTAVAILABLE || status > DriverStatus_ON_TRIP {
 		return "Unknown"
 	}
 
@@ -129,3 +185,13 @@ func (status DriverStatus) CanAcceptJob() bool {
 		return false
 	}
 }
+
+// OnJob is true for the statuses of a driver with a job, offered or accepted
+func (status DriverStatus) OnJob() bool {
+	for _, s := range onJobStatuses() {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
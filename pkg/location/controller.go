@@ -1,18 +1,28 @@
 package location
 
 import (
+	"encoding/json"
 	"errors"
 	"log"
-	"strings"
 	"time"
 
 	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/config"
+	"github.com/iknowhtml/locationtracker/pkg/eta"
 	"github.com/iknowhtml/locationtracker/pkg/fleet"
+	"github.com/iknowhtml/locationtracker/pkg/history"
+)
+
+var (
+	// ErrDriverStatusConflict is returned when the driver status is changed by another request between the check and the update
+	ErrDriverStatusConflict = errors.New("Driver status was changed by another request")
 )
 
 type LocationController struct {
-	locationService *LocationService
-	fleetService    *fleet.FleetService
+	locationService   *LocationService
+	fleetService      *fleet.FleetService
+	historyController *history.HistoryController
+	tenant            *Tenant // the drivers of the controller are the drivers of the tenant
 }
 
 func (lc *LocationController) Init() error {
@@ -26,12 +36,38 @@ func (lc *LocationController) Init() error {
 	if err != nil {
 		return err
 	}
+	lc.historyController = new(history.HistoryController)
+	err = lc.historyController.Init()
+	if err != nil {
+		return err
+	}
+	lc.tenant, err = GetTenant("")
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
+// ForTenant returns a copy of the controller for the drivers of the tenant of the fleet name,
+// the default tenant for an empty name. The controller itself is left unchanged, so it may be shared
+func (lc *LocationController) ForTenant(fleet string) (*LocationController, error) {
+	tenant, err := GetTenant(fleet)
+	if err != nil {
+		return nil, err
+	}
+	scoped := *lc
+	scoped.tenant = tenant
+	return &scoped, nil
+}
+
+// Tenant returns the tenant of the drivers of the controller
+func (lc *LocationController) Tenant() *Tenant {
+	return lc.tenant
+}
+
 func (lc *LocationController) GetDriverStatus(driverID int32) (*GetObjectResponseObject, error) {
 
-	res, err := lc.locationService.GetObject(Object_Collection_Fleet, driverID)
+	res, err := lc.locationService.GetObject(lc.tenant.Collection, driverID)
 	if err != nil {
 		return nil, err
 	}
@@ -44,16 +80,16 @@ func (lc *LocationController) GetDriverStatus(driverID int32) (*GetObjectRespons
 	return res, nil
 }
 
-// Update Driver Status for existing object which status is available or busy with extra fields
+// Update Driver Status with extra fields, checked against the lifecycle of the driver
 func (lc *LocationController) UpdateDriverStatus(
 	driverID int32,
-	cur_loc_lat float32,
-	cur_loc_lng float32,
+	cur_loc_lat float64,
+	cur_loc_lng float64,
 	available NearbySearch_Availability,
 	jobID int32) (*SetObjectResponseObject, error) {
 
 	// get current driver status
-	driverExistObj, err := lc.locationService.GetObject(Object_Collection_Fleet, driverID)
+	driverExistObj, err := lc.locationService.GetObject(lc.tenant.Collection, driverID)
 	if err != nil {
 		return nil, err
 	}
@@ -63,17 +99,21 @@ func (lc *LocationController) UpdateDriverStatus(
 		return nil, errors.New("response object is empty")
 	}
 
-	var driverStatus DriverStatus
-	switch available {
-	case NearbySearch_Availability_2:
-		driverStatus = DriverStatus_BUSY
-	case NearbySearch_Availability_1:
-		driverStatus = DriverStatus_AVAILABLE
-		jobID = 0 //always set jobID to zero if set driver availability to Available
-	default:
+	driverStatus, ok := available.DriverStatus()
+	if !ok {
 		driverStatus = DriverStatus_NOTAVAILABLE
 	}
 
+	// the job of a driver leaving the job is checked against the job of the driver
+	t := newDriverTransition(driverExistObj, driverID, driverStatus, jobID)
+	err = CheckDriverTransition(t)
+	if err != nil {
+		return nil, err
+	}
+	if !driverStatus.OnJob() {
+		jobID = 0 //always set jobID to zero if the driver is not on job
+	}
+
 	// get fleet info
 	driverFleetInfo, err := lc.fleetService.GetDriverFleetInfo(driverID)
 	if err != nil {
@@ -81,9 +121,7 @@ func (lc *LocationController) UpdateDriverStatus(
 	}
 
 	// Construct LocationObject in GeoJSON format
-	locationObj := new(LocationObject)
-	locationObj.Type = LocationObject_Type_Point
-	locationObj.Coordinates = [2]float32{cur_loc_lat, cur_loc_lng}
+	locationObj := NewLocationObject(common.GeoPoint{Lat: cur_loc_lat, Lng: cur_loc_lng})
 	timeNow := time.Now().Unix()
 	fields := LocationObject_Fields{}
 	// if driver object exist
@@ -93,6 +131,7 @@ func (lc *LocationController) UpdateDriverStatus(
 		fields = LocationObject_Fields{
 			"providerid":          driverFleetInfo.Data.ProviderID,
 			"driverstatus":        int32(driverStatus),
+			"prevdriverstatus":    0,
 			"jobid":               jobID,
 			"activeserviceid":     driverFleetInfo.Data.ActiveServiceID,
 			"activeservicetypeid": driverFleetInfo.Data.ActiveServiceTypeID,
@@ -112,9 +151,10 @@ func (lc *LocationController) UpdateDriverStatus(
 			"lastupdatedtime":     timeNow,
 		}
 	}
+	addMotionFields(driverExistObj, locationObj.Point(), timeNow, fields)
 
-	// Update object
-	res, err := lc.locationService.SetObject(Object_Collection_Fleet, driverID, locationObj, fields)
+	// Update object only when the driver is not changed since it was read, or not created when it did not exist
+	res, err := lc.locationService.SetObjectIf(lc.tenant.Collection, driverID, expectedDriverStatus(driverExistObj), locationObj, fields)
 
 	if err != nil {
 		return nil, err
@@ -122,128 +162,55 @@ func (lc *LocationController) UpdateDriverStatus(
 
 	// check if ok is false
 	if res.Ok == false {
-		return nil, errors.New(res.Error)
+		return nil, driverStatusError(res.Error)
 	}
 
+	// keep the point in the trail of the driver
+	lc.historyController.RecordDriverPoint(driverID, cur_loc_lat, cur_loc_lng, int32(driverStatus), jobID, history.History_Event_Status, timeNow)
+
+	t.Lat, t.Lng, t.Time = cur_loc_lat, cur_loc_lng, timeNow
+	runDriverStateHooks(t)
+
 	log.Printf("Driver status updated: %v\n", res.Ok)
 	return res, nil
 }
 
-// Set Driver Job Cancel
-// if Driver object not found, return error "faield to retrieve object"
-// if Driver status not Busy (not on job), return error "Set Driver job cancel not allowed, driver currently not on job"
-// if Driver Job ID not match, return error "Set Driver job cancel not allowed, driver is on another job"
-
-// Set Driver Job Complete
+// Set Driver Job Complete, called when the job of the driver is completed or cancelled
 // if Driver object not found, return error "faield to retrieve object"
-// if Driver status not Busy (not on job), return error "Set Driver job complete or cancel not allowed, driver currently not on job"
-// if Driver Job ID not match, return error "Set Driver job complete or cancel not allowed, driver is on another job"
+// if Driver status not on job, return DriverTransitionError "Set Driver job complete or cancel not allowed, driver currently not on job"
+// if Driver Job ID not match, return DriverTransitionError "driver is on job"
 func (lc *LocationController) SetDriverJobCompleteOrCancel(
 	driverID int32,
 	jobID int32) (*SetFieldResponseObject, error) {
 
-	// get current driver status
-	driverExistObj, err := lc.locationService.GetObject(Object_Collection_Fleet, driverID)
-	if err != nil {
-		return nil, err
-	}
-
-	// check if response object is empty
-	if driverExistObj == nil {
-		return nil, errors.New("response object is empty")
-	}
-
-	// check if driver object found
-	if driverExistObj.Ok != true {
-		return nil, errors.New("failed to retrieve object")
-	}
-
-	// if Driver status not Busy (not on job), return error "Set Driver job complete not allowed, driver currently not on job"
-	if driverExistObj.Ok && driverExistObj.Fields.Status != DriverStatus_BUSY {
-		return nil, errors.New("Set Driver job complete or cancel not allowed, driver currently not on job")
-	}
-
-	// if Driver status not Busy (not on job), return error "Set Driver job complete not allowed, driver currently not on job"
-	if driverExistObj.Ok && driverExistObj.Fields.Status == DriverStatus_BUSY && driverExistObj.Fields.JobID != jobID {
-		return nil, errors.New("Set Driver job complete or cancel not allowed, driver is on another job")
-	}
-
-	timeNow := time.Now().Unix()
-	fields := LocationObject_Fields{}
-
-	// setup param object
-	fields = LocationObject_Fields{
-		"driverstatus":    int32(DriverStatus_AVAILABLE),
-		"jobid":           0,
-		"lastupdatedtime": timeNow,
-	}
-
-	// Update object
-	res, err := lc.locationService.SetField(Object_Collection_Fleet, driverID, fields)
+	// the job ends from any status on the job, the lifecycle checks the job is the job of the driver
+	res, err := lc.transitionDriverStatus(driverID, DriverStatus_AVAILABLE, jobID, func(t *DriverTransitionObject) error {
+		if !t.From.OnJob() {
+			return errors.New("Set Driver job complete or cancel not allowed, driver currently not on job")
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// check if ok is false
-	if res.Ok == false {
-		return nil, errors.New(res.Error)
-	}
-
 	log.Printf("Driver Job Compelte or Cancel updated: %v\n", res.Ok)
 	return res, nil
 }
 
-// Set Driver Busy and Job ID
+// Set Driver Busy and Job ID, called when the job is assigned to the driver
 // if Driver object not found, return error "faield to retrieve object"
-// if Driver status is Busy, return error "Set Driver busy not allowed, driver currently on job"
-// if Driver status is Not-Available, return error "Set Driver busy not allowed, driver currently not available"
+// if Driver status cannot be changed to Busy in the driver lifecycle, return DriverTransitionError
 func (lc *LocationController) SetAvailabilityBusy(
 	driverID int32,
 	jobID int32) (*SetFieldResponseObject, error) {
 
-	// get current driver status
-	driverExistObj, err := lc.locationService.GetObject(Object_Collection_Fleet, driverID)
-	if err != nil {
-		return nil, err
-	}
-
-	// check if response object is empty
-	if driverExistObj == nil {
-		return nil, errors.New("response object is empty")
-	}
-
-	// check if driver object found
-	if driverExistObj.Ok != true {
-		return nil, errors.New("failed to retrieve object")
-	}
-
-	// if Driver status is Busy, throw error "Set Driver busy not allowed, driver currently on job"
-	// if Driver status is Not-Available, throw error "Set Driver busy not allowed, driver currently not available"
-	if driverExistObj.Ok && driverExistObj.Fields.Status != DriverStatus_AVAILABLE {
-		return nil, errors.New("Set Driver busy not allowed, driver currently on job or currently not available")
-	}
-
-	timeNow := time.Now().Unix()
-	fields := LocationObject_Fields{}
-
-	// setup param object
-	fields = LocationObject_Fields{
-		"driverstatus":    int32(DriverStatus_BUSY),
-		"jobid":           jobID,
-		"lastupdatedtime": timeNow,
-	}
-
-	// Update object
-	res, err := lc.locationService.SetField(Object_Collection_Fleet, driverID, fields)
+	// the lifecycle sets busy only an available driver or a driver offered the job, so the driver is not assigned to two jobs
+	res, err := lc.TransitionDriverStatus(driverID, DriverStatus_BUSY, jobID)
 	if err != nil {
 		return nil, err
 	}
 
-	// check if ok is false
-	if res.Ok == false {
-		return nil, errors.New(res.Error)
-	}
-
 	log.Printf("Driver availability updated: %v\n", res.Ok)
 	return res, nil
 }
@@ -253,12 +220,12 @@ func (lc *LocationController) SetAvailabilityBusy(
 // else update availability and location
 func (lc *LocationController) SetAvailability(
 	driverID int32,
-	cur_loc_lat float32,
-	cur_loc_lng float32,
+	cur_loc_lat float64,
+	cur_loc_lng float64,
 	available NearbySearch_Availability) (*SetObjectResponseObject, error) {
 
 	// get current driver status
-	driverExistObj, err := lc.locationService.GetObject(Object_Collection_Fleet, driverID)
+	driverExistObj, err := lc.locationService.GetObject(lc.tenant.Collection, driverID)
 	if err != nil {
 		return nil, err
 	}
@@ -272,10 +239,19 @@ func (lc *LocationController) SetAvailability(
 	switch available {
 	case NearbySearch_Availability_1:
 		driverStatus = DriverStatus_AVAILABLE
+	case NearbySearch_Availability_4:
+		driverStatus = DriverStatus_ON_BREAK
 	default:
 		driverStatus = DriverStatus_NOTAVAILABLE
 	}
 
+	// a driver on job cannot change availability, the job is completed or cancelled first
+	t := newDriverTransition(driverExistObj, driverID, driverStatus, 0)
+	err = CheckDriverTransition(t)
+	if err != nil {
+		return nil, err
+	}
+
 	// get fleet info
 	driverFleetInfo, err := lc.fleetService.GetDriverFleetInfo(driverID)
 	if err != nil {
@@ -283,22 +259,16 @@ func (lc *LocationController) SetAvailability(
 	}
 
 	// Construct LocationObject in GeoJSON format
-	locationObj := new(LocationObject)
-	locationObj.Type = LocationObject_Type_Point
-	locationObj.Coordinates = [2]float32{cur_loc_lat, cur_loc_lng}
+	locationObj := NewLocationObject(common.GeoPoint{Lat: cur_loc_lat, Lng: cur_loc_lng})
 	timeNow := time.Now().Unix()
 	fields := LocationObject_Fields{}
 	// if driver object exist
 	if driverExistObj.Ok && driverExistObj.Fields.DriverID != 0 {
-		// if driver is currently busy, throw error: cannot set availability when busy on job
-		if driverExistObj.Fields.Status == DriverStatus_BUSY {
-			return nil, errors.New("cannot change driver availability because driver is busy on job")
-		}
-
 		// setup param object
 		fields = LocationObject_Fields{
 			"providerid":          driverFleetInfo.Data.ProviderID,
 			"driverstatus":        int32(driverStatus),
+			"prevdriverstatus":    0,
 			"jobid":               0,
 			"activeserviceid":     driverFleetInfo.Data.ActiveServiceID,
 			"activeservicetypeid": driverFleetInfo.Data.ActiveServiceTypeID,
@@ -318,9 +288,10 @@ func (lc *LocationController) SetAvailability(
 			"lastupdatedtime":     timeNow,
 		}
 	}
+	addMotionFields(driverExistObj, locationObj.Point(), timeNow, fields)
 
-	// Update object
-	res, err := lc.locationService.SetObject(Object_Collection_Fleet, driverID, locationObj, fields)
+	// Update object only when the driver is not changed since it was read, so a driver set busy meanwhile is kept busy
+	res, err := lc.locationService.SetObjectIf(lc.tenant.Collection, driverID, expectedDriverStatus(driverExistObj), locationObj, fields)
 
 	if err != nil {
 		return nil, err
@@ -328,9 +299,15 @@ func (lc *LocationController) SetAvailability(
 
 	// check if ok is false
 	if res.Ok == false {
-		return nil, errors.New(res.Error)
+		return nil, driverStatusError(res.Error)
 	}
 
+	// keep the point in the trail of the driver
+	lc.historyController.RecordDriverPoint(driverID, cur_loc_lat, cur_loc_lng, int32(driverStatus), 0, history.History_Event_Availability, timeNow)
+
+	t.Lat, t.Lng, t.Time = cur_loc_lat, cur_loc_lng, timeNow
+	runDriverStateHooks(t)
+
 	log.Printf("Driver availability updated: %v\n", res.Ok)
 	return res, nil
 }
@@ -338,35 +315,22 @@ func (lc *LocationController) SetAvailability(
 // Update Driver Status for existing object which status is available or busy
 func (lc *LocationController) UpdateDriverLocation(
 	driverID int32,
-	cur_loc_lat float32,
-	cur_loc_lng float32) (interface{}, error) {
+	cur_loc_lat float64,
+	cur_loc_lng float64) (interface{}, error) {
 
 	// get current driver status
-	driverExistObj, err := lc.locationService.GetObject(Object_Collection_Fleet, driverID)
+	driverExistObj, err := lc.locationService.GetObject(lc.tenant.Collection, driverID)
 	if err != nil {
 		return nil, err
 	}
 
-	// check if response object is empty
-	if driverExistObj == nil {
-		return nil, errors.New("response object is empty")
-	}
-
-	// check if ok is false
-	// example: id not found
-	if driverExistObj.Ok == false {
-		return nil, errors.New(driverExistObj.Error)
-	}
-
-	// if driver is currently not available, throw error: cannot update driver location when driver is not available
-	if driverExistObj.Ok && driverExistObj.Fields.DriverID != 0 && driverExistObj.Fields.Status == DriverStatus_NOTAVAILABLE {
-		return nil, errors.New("cannot update driver location when driver is not available")
+	err = checkDriverLocationUpdate(driverExistObj)
+	if err != nil {
+		return nil, err
 	}
 
 	// Construct LocationObject in GeoJSON format
-	locationObj := new(LocationObject)
-	locationObj.Type = LocationObject_Type_Point
-	locationObj.Coordinates = [2]float32{cur_loc_lat, cur_loc_lng}
+	locationObj := NewLocationObject(common.GeoPoint{Lat: cur_loc_lat, Lng: cur_loc_lng})
 	timeNow := time.Now().Unix()
 	fields := LocationObject_Fields{
 		//"driverid":      driverID,
@@ -378,9 +342,17 @@ func (lc *LocationController) UpdateDriverLocation(
 		//"priority": 0,
 		"lastupdatedtime": timeNow,
 	}
-
-	// Update objects of fleet collection, with fleet type and driver id
-	res, err := lc.locationService.SetObject(Object_Collection_Fleet, driverID, locationObj, fields)
+	addMotionFields(driverExistObj, locationObj.Point(), timeNow, fields)
+	driverStatus := restoreReachable(driverExistObj, fields)
+
+	// Update objects of fleet collection, with fleet type and driver id.
+	// A restored status is set only when the driver is still unreachable
+	var res *SetObjectResponseObject
+	if expected := expectedUnreachable(driverExistObj); expected != nil {
+		res, err = lc.locationService.SetObjectIf(lc.tenant.Collection, driverID, expected, locationObj, fields)
+	} else {
+		res, err = lc.locationService.SetObject(lc.tenant.Collection, driverID, locationObj, fields)
+	}
 
 	if err != nil {
 		return nil, err
@@ -388,200 +360,366 @@ func (lc *LocationController) UpdateDriverLocation(
 
 	// check if ok is false
 	if res.Ok == false {
-		return nil, errors.New(res.Error)
+		return nil, driverStatusError(res.Error)
 	}
 
+	// keep the point in the trail of the driver, and of the job if on job
+	lc.historyController.RecordDriverPoint(
+		driverID, cur_loc_lat, cur_loc_lng, int32(driverStatus), driverExistObj.Fields.JobID, history.History_Event_Location, timeNow)
+
 	log.Printf("Driver location updated: %v\n", res.Ok)
 	return res, nil
 }
 
-func (lc *LocationController) SearchNearbyDriver(
-	limit int32,
-	from_lat float32,
-	from_lng float32,
-	search_tier int32,
-	search_service_type_id int32,
-	search_service_id int32,
-	search_avail NearbySearch_Availability,
-	search_priority NearbySearch_Priority) (*NearbyObjectMapObject, error) {
+// Update the location of many drivers with one pipelined GET and one pipelined SET.
+// Same rules as UpdateDriverLocation, the error of each update is returned in the order of the updates.
+// The positions go through the position filter, rejected positions are returned as errors and accepted ones are smoothed.
+// When a driver has many updates in the batch, only the last one is set, all are kept in the trail
+func (lc *LocationController) UpdateDriverLocations(updates []DriverLocationUpdateObject) ([]error, error) {
+	errs := make([]error, len(updates))
+	if len(updates) == 0 {
+		return errs, nil
+	}
 
-	// Where Conditions
-	whereList := []WhereConditionFieldObject{}
-	whereInList := []WhereInConditionFieldObject{}
+	// get current status of the distinct drivers of the batch
+	driverIDs := []int32{}
+	driverIndex := map[int32]int{}
+	for _, u := range updates {
+		if _, ok := driverIndex[u.DriverID]; !ok {
+			driverIndex[u.DriverID] = len(driverIDs)
+			driverIDs = append(driverIDs, u.DriverID)
+		}
+	}
+	driverExistObjs, err := lc.locationService.GetObjects(lc.tenant.Collection, driverIDs)
+	if err != nil {
+		return nil, err
+	}
 
-	// Setup DriverStatus condition
-	switch search_avail {
-	case NearbySearch_Availability_1:
-		var vals []interface{}
-		vals = append(vals, int32(DriverStatus_AVAILABLE))
+	// last accepted update of each driver
+	timeNow := time.Now().Unix()
+	filterConfig := positionFilterConfig()
+	pending := positionUpdates{}
+	points := make([]common.GeoPoint, len(updates))
+	lastUpdate := map[int32]int{}
+	for i := range updates {
+		u := &updates[i]
+		errs[i] = common.GeoPoint{Lat: u.Lat, Lng: u.Lng}.Validate()
+		if errs[i] == nil {
+			errs[i] = checkDriverLocationUpdate(driverExistObjs[driverIndex[u.DriverID]])
+		}
+		if errs[i] == nil {
+			points[i], errs[i] = filterPosition(filterConfig, lc.tenant, u, timeNow, pending)
+		}
+		if errs[i] == nil {
+			lastUpdate[u.DriverID] = i
+		}
+	}
 
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "driverstatus", Values: vals})
-	case NearbySearch_Availability_0:
-		var vals []interface{}
-		vals = append(vals, int32(DriverStatus_NOTAVAILABLE))
-		vals = append(vals, int32(DriverStatus_BUSY))
+	driverStatus := map[int32]DriverStatus{}
+	setDriverIDs := []int32{}
+	setErrs := map[int32]error{}
+	objs := []SetObjectRequestObject{}
+	for _, driverID := range driverIDs {
+		i, ok := lastUpdate[driverID]
+		if !ok {
+			continue
+		}
+		driverExistObj := driverExistObjs[driverIndex[driverID]]
+
+		// Construct LocationObject in GeoJSON format
+		locationObj := NewLocationObject(points[i])
+
+		fields := LocationObject_Fields{"lastupdatedtime": timeNow}
+		addMotionFields(driverExistObj, points[i], timeNow, fields)
+		driverStatus[driverID] = restoreReachable(driverExistObj, fields)
+
+		// a restored status is set on its own, only when the driver is still unreachable
+		if expected := expectedUnreachable(driverExistObj); expected != nil {
+			r, err := lc.locationService.SetObjectIf(lc.tenant.Collection, driverID, expected, locationObj, fields)
+			if err != nil {
+				setErrs[driverID] = err
+			} else if r.Ok == false {
+				setErrs[driverID] = driverStatusError(r.Error)
+			}
+			continue
+		}
 
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "driverstatus", Values: vals})
-	default:
-		// for case search all, do nothing
+		setDriverIDs = append(setDriverIDs, driverID)
+		objs = append(objs, SetObjectRequestObject{
+			ObjID:  GenerateLocationObjectId("", driverID),
+			Object: locationObj,
+			Fields: fields,
+		})
 	}
 
-	// Setup ActiveServiceTypeID condition
-	if search_service_type_id != 0 {
-		var vals []interface{}
-		vals = append(vals, search_service_type_id)
+	// Update objects of fleet collection, with fleet type and driver id
+	if len(objs) > 0 {
+		res, err := lc.locationService.SetObjects(lc.tenant.Collection, objs)
+		if err != nil {
+			return nil, err
+		}
 
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "activeservicetypeid", Values: vals})
+		for j, r := range res {
+			if r.Ok == false {
+				setErrs[setDriverIDs[j]] = errors.New(r.Error)
+			}
+		}
 	}
 
-	// Setup ActiveServiceID condition
-	if search_service_id != 0 {
-		var vals []interface{}
-		vals = append(vals, search_service_id)
+	// only the positions set in the store become the last positions of the drivers
+	for driverID := range setErrs {
+		delete(pending, lc.tenant.DriverKey(driverID))
+	}
+	commitPositions(pending)
 
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "activeserviceid", Values: vals})
+	updated := 0
+	for i, u := range updates {
+		if errs[i] != nil {
+			continue
+		}
+		if err, ok := setErrs[u.DriverID]; ok {
+			errs[i] = err
+			continue
+		}
+		updated++
+
+		// keep the point in the trail of the driver, and of the job if on job
+		driverExistObj := driverExistObjs[driverIndex[u.DriverID]]
+		lc.historyController.RecordDriverPoint(
+			u.DriverID, points[i].Lat, points[i].Lng, int32(driverStatus[u.DriverID]), driverExistObj.Fields.JobID, history.History_Event_Location, timeNow)
 	}
 
-	// Setup Priority condition
-	switch search_priority {
-	case NearbySearch_Priority_1:
-		var vals []interface{}
-		vals = append(vals, int32(DriverPriority_YES))
+	log.Printf("Driver locations updated: %d of %d\n", updated, len(updates))
+	return errs, nil
+}
 
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "priority", Values: vals})
-	case NearbySearch_Priority_0:
-		var vals []interface{}
-		vals = append(vals, int32(DriverPriority_NO))
+// restoreReachable adds the fields to restore the status of an unreachable driver which sends an update again,
+// and returns the status of the driver after the update
+func restoreReachable(driverExistObj *GetObjectResponseObject, fields LocationObject_Fields) DriverStatus {
+	if driverExistObj.Fields.Status != DriverStatus_UNREACHABLE {
+		return driverExistObj.Fields.Status
+	}
 
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "priority", Values: vals})
-	default:
-		// for case search all, do nothing
+	fields["driverstatus"] = int32(driverExistObj.Fields.PreviousStatus)
+	fields["prevdriverstatus"] = 0
+	log.Printf("Driver reachable again: %d, status restored to %s\n", driverExistObj.Fields.DriverID, driverExistObj.Fields.PreviousStatus)
+	return driverExistObj.Fields.PreviousStatus
+}
+
+// expectedUnreachable returns the fields expected by the update restoring the status of an unreachable driver,
+// nil when the driver is not unreachable
+func expectedUnreachable(driverExistObj *GetObjectResponseObject) LocationObject_Fields {
+	if driverExistObj.Fields.Status != DriverStatus_UNREACHABLE {
+		return nil
+	}
+	return LocationObject_Fields{
+		"driverstatus":     int32(DriverStatus_UNREACHABLE),
+		"prevdriverstatus": int32(driverExistObj.Fields.PreviousStatus),
 	}
+}
 
-	// Search nearby fleet objects from a point (lat, lng) with a radius
-	nearbyObj, err := lc.locationService.NearbyObject(Object_Collection_Fleet, from_lat, from_lng, search_tier, limit, whereList, whereInList)
+// checkDriverLocationUpdate returns the reason the location of the driver cannot be updated
+func checkDriverLocationUpdate(driverExistObj *GetObjectResponseObject) error {
+
+	// check if response object is empty
+	if driverExistObj == nil {
+		return errors.New("response object is empty")
+	}
+
+	// check if ok is false
+	// example: id not found
+	if driverExistObj.Ok == false {
+		return errors.New(driverExistObj.Error)
+	}
+
+	// if driver is currently not available, throw error: cannot update driver location when driver is not available
+	if driverExistObj.Ok && driverExistObj.Fields.DriverID != 0 && driverExistObj.Fields.Status == DriverStatus_NOTAVAILABLE {
+		return errors.New("cannot update driver location when driver is not available")
+	}
+
+	return nil
+}
 
+// SearchNearbyDriver searches the drivers around the point, the excluded drivers are removed before the limit is applied
+func (lc *LocationController) SearchNearbyDriver(
+	limit int32,
+	from_lat float64,
+	from_lng float64,
+	search_tier int32,
+	search_service_type_id int32,
+	search_service_id int32,
+	search_avail NearbySearch_Availability,
+	search_priority NearbySearch_Priority,
+	exclude *SearchExcludeObject,
+	motion *SearchMotionObject) (*NearbyObjectMapObject, error) {
+
+	// Where Conditions
+	whereList, whereInList := searchConditions(0, search_service_type_id, search_service_id, search_avail, search_priority)
+	whereList, whereInList = motion.appendConditions(whereList, whereInList)
+	// exclude drivers without update within the heartbeat timeout
+	whereList = appendHeartbeatCondition(whereList, time.Now().Unix())
+
+	// Search nearby fleet objects from a point (lat, lng) with a radius
+	res, err := lc.searchNearbyPages(limit, 0, from_lat, from_lng, search_tier, whereList, whereInList, func(o ObjectsMapObject) bool {
+		return !exclude.Excludes(o.Fields) && !motion.Excludes(&o)
+	})
 	if err != nil {
 		return nil, err
 	}
-	/*
-		// calculate distance in meters for each objects
-		for i, o := range res.Objects {
-			res.Objects[i].Distance = common.Distance(float64(from_lat), float64(from_lng), float64(o.Object.Coordinates[1]), float64(o.Object.Coordinates[0]))
-			log.Printf("The distance of %s is %f\n", res.Objects[i].ID, res.Objects[i].Distance)
-		}
-	*/
-	res := &NearbyObjectMapObject{}
-	res = res.MapFrom(nearbyObj, from_lat, from_lng, nil)
 
 	log.Printf("Search nearby: %v\n", res)
 	return res, nil
 }
 
+// SearchNearbyDriverByProviderId searches a page of drivers between the filter tier and the search tier,
+// starting from the cursor returned by the previous page, 0 for the first page.
+// The stale drivers are the drivers stale at the query time of the first page, so the cursor keeps its position.
+// The excluded drivers are removed before the limit is applied
 func (lc *LocationController) SearchNearbyDriverByProviderId(
 	limit int32,
-	from_lat float32,
-	from_lng float32,
+	cursor int32,
+	query_time int64,
+	from_lat float64,
+	from_lng float64,
 	search_tier int32,
 	filter_tier int32,
 	providerID int32,
 	search_service_type_id int32,
 	search_service_id int32,
 	search_avail NearbySearch_Availability,
-	search_priority NearbySearch_Priority) (*NearbyObjectMapObject, error) {
+	search_priority NearbySearch_Priority,
+	exclude *SearchExcludeObject,
+	motion *SearchMotionObject) (*NearbyObjectMapObject, error) {
 
 	// Where Conditions
-	whereList := []WhereConditionFieldObject{}
-	whereInList := []WhereInConditionFieldObject{}
-
-	// Setup ProviderID condition
-	if providerID != 0 {
-		var vals []interface{}
-		vals = append(vals, providerID)
-
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "providerid", Values: vals})
+	whereList, whereInList := searchConditions(providerID, search_service_type_id, search_service_id, search_avail, search_priority)
+	whereList, whereInList = motion.appendConditions(whereList, whereInList)
+	// exclude drivers without update within the heartbeat timeout of the query time
+	whereList = appendHeartbeatCondition(whereList, query_time)
+
+	// drivers within the filter tier are found by the lower tier search,
+	// they are removed by distance so the page keeps the cursor of the search tier
+	res, err := lc.searchNearbyPages(limit, cursor, from_lat, from_lng, search_tier, whereList, whereInList, func(o ObjectsMapObject) bool {
+		return o.Distance > float64(filter_tier) && !exclude.Excludes(o.Fields) && !motion.Excludes(&o)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Setup DriverStatus condition
-	switch search_avail {
-	case NearbySearch_Availability_1:
-		var vals []interface{}
-		vals = append(vals, int32(DriverStatus_AVAILABLE))
-
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "driverstatus", Values: vals})
-	case NearbySearch_Availability_0:
-		var vals []interface{}
-		vals = append(vals, int32(DriverStatus_NOTAVAILABLE))
-		vals = append(vals, int32(DriverStatus_BUSY))
+	log.Printf("Search nearby: %v\n", res)
+	return res, nil
+}
 
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "driverstatus", Values: vals})
-	default:
-		// for case search all, do nothing
-	}
+// SearchDriverCandidates searches the available drivers around the point which can be offered a job,
+// the drivers are limited to the providers when providerIDs is set and the excluded drivers are left out
+func (lc *LocationController) SearchDriverCandidates(
+	limit int32,
+	from_lat float64,
+	from_lng float64,
+	radius int32,
+	providerIDs []int32,
+	search_service_type_id int32,
+	search_service_id int32,
+	search_priority NearbySearch_Priority,
+	exclude *SearchExcludeObject,
+	motion *SearchMotionObject) (*NearbyObjectMapObject, error) {
 
-	// Setup ActiveServiceTypeID condition
-	if search_service_type_id != 0 {
+	// Where Conditions
+	whereList, whereInList := searchConditions(0, search_service_type_id, search_service_id, NearbySearch_Availability_1, search_priority)
+	if len(providerIDs) > 0 {
 		var vals []interface{}
-		vals = append(vals, search_service_type_id)
+		for _, providerID := range providerIDs {
+			vals = append(vals, providerID)
+		}
+		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "providerid", Values: vals})
+	}
+	whereList, whereInList = motion.appendConditions(whereList, whereInList)
+	// exclude drivers without update within the heartbeat timeout
+	whereList = appendHeartbeatCondition(whereList, time.Now().Unix())
 
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "activeservicetypeid", Values: vals})
+	res, err := lc.searchNearbyPages(limit, 0, from_lat, from_lng, radius, whereList, whereInList, func(o ObjectsMapObject) bool {
+		return !exclude.Excludes(o.Fields) && !motion.Excludes(&o)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Setup ActiveServiceID condition
-	if search_service_id != 0 {
-		var vals []interface{}
-		vals = append(vals, search_service_id)
+	log.Printf("Search driver candidates: %d\n", res.Count)
+	return res, nil
+}
 
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "activeserviceid", Values: vals})
-	}
+// SearchExpandingNearbyDriver searches the configured tiers from the nearest one,
+// and stops at the first tier where the limit of drivers is reached.
+// Each driver is reported with the tier it is found in, the excluded drivers are not counted to the limit
+func (lc *LocationController) SearchExpandingNearbyDriver(
+	limit int32,
+	from_lat float64,
+	from_lng float64,
+	providerID int32,
+	search_service_type_id int32,
+	search_service_id int32,
+	search_avail NearbySearch_Availability,
+	search_priority NearbySearch_Priority,
+	exclude *SearchExcludeObject,
+	motion *SearchMotionObject) (*NearbyObjectMapObject, error) {
 
-	// Setup Priority condition
-	switch search_priority {
-	case NearbySearch_Priority_1:
-		var vals []interface{}
-		vals = append(vals, int32(DriverPriority_YES))
+	tiers := lc.tenant.SearchTiers()
 
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "priority", Values: vals})
-	case NearbySearch_Priority_0:
-		var vals []interface{}
-		vals = append(vals, int32(DriverPriority_NO))
+	// Where Conditions
+	whereList, whereInList := searchConditions(providerID, search_service_type_id, search_service_id, search_avail, search_priority)
+	whereList, whereInList = motion.appendConditions(whereList, whereInList)
+	// exclude drivers without update within the heartbeat timeout
+	whereList = appendHeartbeatCondition(whereList, time.Now().Unix())
 
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "priority", Values: vals})
-	default:
-		// for case search all, do nothing
-	}
-	filterObj := &NearbyObjectResponseObject{}
-	if filter_tier > 0 {
-		// Search nearby fleet objects from a point (lat, lng) with a radius
-		var err error
-		filterObj, err = lc.locationService.NearbyObject(Object_Collection_Fleet, from_lat, from_lng, filter_tier, limit, whereList, whereInList)
+	res := &NearbyObjectMapObject{}
+	found := map[string]bool{}
+	objs := []ObjectsMapObject{}
+	searched := false
+	for i, tier := range tiers {
+		// the tier is not used when no range is defined
+		if tier <= 0 {
+			continue
+		}
+		searched = true
+
+		// the nearest drivers first, the drivers of the inner tiers found again are skipped
+		tierObj, err := lc.searchNearbyPages(limit-int32(len(objs)), 0, from_lat, from_lng, tier, whereList, whereInList, func(o ObjectsMapObject) bool {
+			return !found[o.ID] && !exclude.Excludes(o.Fields) && !motion.Excludes(&o)
+		})
 		if err != nil {
 			return nil, err
 		}
+		if tierObj.Ok == false {
+			return tierObj, nil
+		}
+		res.Ok = tierObj.Ok
+		res.ObjectCollection = tierObj.ObjectCollection
+
+		for _, o := range tierObj.Objects {
+			found[o.ID] = true
+			o.Tier = int32(i + 1)
+			objs = append(objs, o)
+		}
+
+		log.Printf("Search expanding: tier %d (%d meters), %d drivers\n", i+1, tier, len(objs))
+		if int32(len(objs)) >= limit {
+			break
+		}
 	}
-	// Search nearby fleet objects from a point (lat, lng) with a radius
-	nearbyObj, err := lc.locationService.NearbyObject(Object_Collection_Fleet, from_lat, from_lng, search_tier, limit, whereList, whereInList)
-	if err != nil {
-		return nil, err
+	if !searched {
+		return nil, errors.New("No search tier range is defined")
 	}
-	/*
-		// calculate distance in meters for each objects
-		for i, o := range res.Objects {
-			res.Objects[i].Distance = common.Distance(float64(from_lat), float64(from_lng), float64(o.Object.Coordinates[1]), float64(o.Object.Coordinates[0]))
-			log.Printf("The distance of %s is %f\n", res.Objects[i].ID, res.Objects[i].Distance)
-		}
-	*/
-	res := &NearbyObjectMapObject{}
-	res = res.MapFrom(nearbyObj, from_lat, from_lng, filterObj)
+	res.Objects = objs
+	res.Count = int32(len(objs))
 
-	log.Printf("Search nearby: %v\n", res)
+	log.Printf("Search expanding: %v\n", res)
 	return res, nil
 }
 
 func (lc *LocationController) DetectNearbyDriver(
 	id int32,
-	from_lat float32,
-	from_lng float32,
+	from_lat float64,
+	from_lng float64,
 	hookType Hook_Type,
 	endPoints []string,
 	fence_radius int32,
@@ -591,24 +729,274 @@ func (lc *LocationController) DetectNearbyDriver(
 	search_priority NearbySearch_Priority) (*HookFenceResponseObject, error) {
 
 	// Where Conditions
+	whereList, whereInList := searchConditions(0, search_service_type_id, search_service_id, search_avail, search_priority)
+
+	// Detect List
+	detectList := map[string]string{}                                                   // initialize detect list
+	detectList[string(LocationDetect_Type_Inside)] = string(LocationDetect_Type_Inside) // add detect: inside
+	detectList[string(LocationDetect_Type_Enter)] = string(LocationDetect_Type_Enter)   // add detect: enter
+
+	// Command List
+	commandList := map[string]string{} // initialize command list
+
+	// Detect nearby fleet objects from a point (lat, lng) with a radius
+	res, err := lc.locationService.SetHookSearchFence(
+		endPoints,
+		HookTopic(hookType), string(LocationSearch_Type_Nearby),
+		lc.tenant.Collection, id, from_lat, from_lng, fence_radius, detectList, commandList, whereList, whereInList)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Start detect nearby: %v\n", res)
+	return res, nil
+}
+
+func (lc *LocationController) StopDetectNearbyDriver(
+	id int32,
+	hookType Hook_Type) (*HookFenceResponseObject, error) {
+
+	// Stop Detect nearby fleet objects
+	res, err := lc.locationService.DelHookSearchFence(HookTopic(hookType), string(LocationSearch_Type_Nearby), lc.tenant.Collection, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Stop detect nearby: %v\n", res)
+	return res, nil
+}
+
+// SearchAreaDriver searches drivers within or intersecting a GeoJSON area, with the same filters as nearby search
+func (lc *LocationController) SearchAreaDriver(
+	limit int32,
+	searchType LocationSearch_Type,
+	area json.RawMessage,
+	providerID int32,
+	search_service_type_id int32,
+	search_service_id int32,
+	search_avail NearbySearch_Availability,
+	search_priority NearbySearch_Priority,
+	motion *SearchMotionObject) (*NearbyObjectMapObject, error) {
+
+	// Where Conditions
+	whereList, whereInList := searchConditions(providerID, search_service_type_id, search_service_id, search_avail, search_priority)
+	whereList, whereInList = motion.appendConditions(whereList, whereInList)
+	// exclude drivers without update within the heartbeat timeout
+	whereList = appendHeartbeatCondition(whereList, time.Now().Unix())
+
+	// Search fleet objects in the area
+	areaObj, err := lc.locationService.SearchAreaObject(lc.tenant.Collection, searchType, area, limit, whereList, whereInList)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &NearbyObjectMapObject{}
+	res = res.MapWithinFrom(areaObj)
+
+	log.Printf("Search %s: %v\n", searchType, res)
+	return res, nil
+}
+
+// SearchBoundsDriver searches the drivers within the bounds of a map viewport, with the same filters as nearby search.
+// Bounds across the antimeridian are searched as two boxes. One more driver than the limit is searched
+// to tell whether the result is truncated
+func (lc *LocationController) SearchBoundsDriver(
+	limit int32,
+	bounds common.BoundingBox,
+	providerID int32,
+	search_service_type_id int32,
+	search_service_id int32,
+	search_avail NearbySearch_Availability,
+	search_priority NearbySearch_Priority,
+	exclude *SearchExcludeObject,
+	motion *SearchMotionObject) (*DriverMarkersMapObject, error) {
+
+	// Where Conditions
+	whereList, whereInList := searchConditions(providerID, search_service_type_id, search_service_id, search_avail, search_priority)
+	whereList, whereInList = motion.appendConditions(whereList, whereInList)
+	// exclude drivers without update within the heartbeat timeout
+	whereList = appendHeartbeatCondition(whereList, time.Now().Unix())
+
+	boxes := []common.BoundingBox{bounds}
+	if bounds.CrossesAntimeridian() {
+		east, west := bounds, bounds
+		east.MaxLng = 180
+		west.MinLng = -180
+		boxes = []common.BoundingBox{east, west}
+	}
+
+	res := &DriverMarkersMapObject{}
+	for _, box := range boxes {
+		boundsObj, err := lc.locationService.SearchBoundsObject(lc.tenant.Collection, box, limit+1, whereList, whereInList)
+		if err != nil {
+			return nil, err
+		}
+		res = res.MapMarkersFrom(boundsObj, exclude)
+		// the excluded drivers are removed after the search, so a full search is truncated whatever is left
+		if int32(len(boundsObj.Objects)) > limit || res.Count > limit {
+			res.Truncated = true
+		}
+		if res.Ok == false || res.Truncated {
+			break
+		}
+	}
+	if res.Count > limit {
+		res.Drivers = res.Drivers[:limit]
+		res.Count = limit
+	}
+
+	log.Printf("Search bounds: %d drivers, truncated: %v\n", res.Count, res.Truncated)
+	return res, nil
+}
+
+// searchNearbyPages searches the drivers from the cursor page by page until the limit of drivers kept by the filter
+// is reached, so the removed drivers do not shorten the page. Each page is searched with the number of drivers left,
+// so the cursor of the result starts the next page right after the last driver searched
+func (lc *LocationController) searchNearbyPages(
+	limit int32,
+	cursor int32,
+	from_lat float64,
+	from_lng float64,
+	radius int32,
+	whereList []WhereConditionFieldObject,
+	whereInList []WhereInConditionFieldObject,
+	keep func(o ObjectsMapObject) bool) (*NearbyObjectMapObject, error) {
+
+	res := &NearbyObjectMapObject{}
+	objs := []ObjectsMapObject{}
+	for page := int32(0); page < Search_Max_Pages; page++ {
+		nearbyObj, err := lc.locationService.NearbyObject(lc.tenant.Collection, from_lat, from_lng, radius, limit-int32(len(objs)), cursor, whereList, whereInList)
+		if err != nil {
+			return nil, err
+		}
+
+		pageObj := (&NearbyObjectMapObject{}).MapFrom(nearbyObj, from_lat, from_lng, nil)
+		if pageObj.Ok == false {
+			return pageObj, nil
+		}
+		res.Ok = pageObj.Ok
+		res.ObjectCollection = pageObj.ObjectCollection
+		res.Elapsed = pageObj.Elapsed
+
+		for _, o := range pageObj.Objects {
+			if keep(o) {
+				objs = append(objs, o)
+			}
+		}
+
+		cursor = pageObj.Cursor
+		if cursor == 0 || int32(len(objs)) >= limit {
+			break
+		}
+	}
+	setETA(objs, common.GeoPoint{Lat: from_lat, Lng: from_lng})
+	res.Objects = objs
+	res.Count = int32(len(objs))
+	res.Cursor = cursor
+
+	return res, nil
+}
+
+// setETA sets the travel time of each driver to the point, by the active service type of the driver.
+// The nearest drivers get a road ETA when routed on the road graph, the others a straight-line estimate.
+// The drivers are still returned without the travel time when the estimator is not available
+func setETA(objs []ObjectsMapObject, p common.GeoPoint) {
+	if len(objs) == 0 {
+		return
+	}
+	nearest, others, max, err := eta.GetSearchEstimators()
+	if err != nil {
+		log.Printf("ETA estimator is not available: %v\n", err)
+		return
+	}
+
+	at := time.Now()
+	for i := range objs {
+		// the drivers are sorted from the nearest
+		estimator := nearest
+		if max > 0 && i >= max {
+			estimator = others
+		}
+		seconds, err := estimator.Estimate(objs[i].Object.Point(), p, objs[i].Fields.ActiveServiceTypeID, at)
+		if err != nil {
+			log.Printf("Failed to estimate ETA of %s: %v\n", objs[i].ID, err)
+			continue
+		}
+		objs[i].ETASeconds = seconds
+	}
+}
+
+// appendHeartbeatCondition appends the condition on lastupdatedtime which excludes stale drivers from searches.
+// Not for hooks, since the condition is relative to the time of the search
+func appendHeartbeatCondition(whereList []WhereConditionFieldObject, timeNow int64) []WhereConditionFieldObject {
+	timeout := heartbeatTimeout()
+	if timeout <= 0 {
+		return whereList
+	}
+
+	return append(whereList, WhereConditionFieldObject{
+		FieldName: "lastupdatedtime",
+		Min:       timeNow - int64(timeout),
+		Max:       "+inf",
+	})
+}
+
+// heartbeatTimeout returns the heartbeat timeout in second, 0 if disabled
+func heartbeatTimeout() int32 {
+	// load system configuration based on environment, singleton pattern
+	configuration, err := config.GetInstance("")
+	if configuration == nil {
+		log.Printf("Failed to load configuration: %v\n", err)
+		return 0
+	}
+	return configuration.Locationremoteserver.HeartbeatTimeoutSecond
+}
+
+// expectedDriverStatus returns the status and job of the driver as read, to check they are not changed when updating,
+// nil when the driver does not exist
+func expectedDriverStatus(driverExistObj *GetObjectResponseObject) LocationObject_Fields {
+	if !driverExistObj.Ok {
+		return nil
+	}
+	return LocationObject_Fields{
+		"driverstatus": int32(driverExistObj.Fields.Status),
+		"jobid":        driverExistObj.Fields.JobID,
+	}
+}
+
+// driverStatusError returns ErrDriverStatusConflict when the driver is changed by another request
+func driverStatusError(storeError string) error {
+	if storeError == GeoStore_Error_Conflict {
+		return ErrDriverStatusConflict
+	}
+	return errors.New(storeError)
+}
+
+// searchConditions builds the Where conditions shared by driver searches and hooks
+func searchConditions(
+	providerID int32,
+	search_service_type_id int32,
+	search_service_id int32,
+	search_avail NearbySearch_Availability,
+	search_priority NearbySearch_Priority) ([]WhereConditionFieldObject, []WhereInConditionFieldObject) {
+
 	whereList := []WhereConditionFieldObject{}
 	whereInList := []WhereInConditionFieldObject{}
 
-	// Setup DriverStatus condition
-	switch search_avail {
-	case NearbySearch_Availability_1:
+	// Setup ProviderID condition
+	if providerID != 0 {
 		var vals []interface{}
-		vals = append(vals, int32(DriverStatus_AVAILABLE))
+		vals = append(vals, providerID)
 
-		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "driverstatus", Values: vals})
-	case NearbySearch_Availability_0:
-		var vals []interface{}
-		vals = append(vals, int32(DriverStatus_NOTAVAILABLE))
-		vals = append(vals, int32(DriverStatus_BUSY))
+		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "providerid", Values: vals})
+	}
 
+	// Setup DriverStatus condition
+	// for case search all, do nothing
+	if vals := search_avail.searchStatuses(); len(vals) > 0 {
 		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "driverstatus", Values: vals})
-	default:
-		// for case search all, do nothing
 	}
 
 	// Setup ActiveServiceTypeID condition
@@ -643,39 +1031,5 @@ func (lc *LocationController) DetectNearbyDriver(
 		// for case search all, do nothing
 	}
 
-	// Detect List
-	detectList := map[string]string{}                                                   // initialize detect list
-	detectList[string(LocationDetect_Type_Inside)] = string(LocationDetect_Type_Inside) // add detect: inside
-	detectList[string(LocationDetect_Type_Enter)] = string(LocationDetect_Type_Enter)   // add detect: enter
-
-	// Command List
-	commandList := map[string]string{} // initialize command list
-
-	// Detect nearby fleet objects from a point (lat, lng) with a radius
-	res, err := lc.locationService.SetHookSearchFence(
-		endPoints,
-		common.Concate(HookPrefix, strings.Title(string(hookType))), string(LocationSearch_Type_Nearby),
-		Object_Collection_Fleet, id, from_lat, from_lng, fence_radius, detectList, commandList, whereList, whereInList)
-
-	if err != nil {
-		return nil, err
-	}
-
-	log.Printf("Start detect nearby: %v\n", res)
-	return res, nil
-}
-
-func (lc *LocationController) StopDetectNearbyDriver(
-	id int32,
-	hookType Hook_Type) (*HookFenceResponseObject, error) {
-
-	// Stop Detect nearby fleet objects
-	res, err := lc.locationService.DelHookSearchFence(common.Concate(HookPrefix, strings.Title(string(hookType))), string(LocationSearch_Type_Nearby), Object_Collection_Fleet, id)
-
-	if err != nil {
-		return nil, err
-	}
-
-	log.Printf("Stop detect nearby: %v\n", res)
-	return res, nil
+	return whereList, whereInList
 }
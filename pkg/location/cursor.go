@@ -0,0 +1,57 @@
+package location
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncodeSearchCursor returns the token of the next page of a paged search.
+// The token carries the time of the first page, so every page excludes the same stale drivers and the position
+// stays valid, and a hash of the query, so it is only accepted back for the same query and filters
+func EncodeSearchCursor(cursor int32, queryTime int64, query string) string {
+	if cursor <= 0 {
+		return ""
+	}
+	token := fmt.Sprintf("%d:%d:%08x", cursor, queryTime, searchQueryHash(query))
+	return base64.RawURLEncoding.EncodeToString([]byte(token))
+}
+
+// DecodeSearchCursor returns the cursor and the query time of the token, 0 and the current time for an empty token
+func DecodeSearchCursor(token string, query string) (int32, int64, error) {
+	if token == "" {
+		return 0, time.Now().Unix(), nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, 0, errors.New("Cursor is invalid")
+	}
+	parts := strings.Split(string(b), ":")
+	if len(parts) != 3 {
+		return 0, 0, errors.New("Cursor is invalid")
+	}
+	cursor, err := strconv.ParseInt(parts[0], 10, 32)
+	if err != nil || cursor <= 0 {
+		return 0, 0, errors.New("Cursor is invalid")
+	}
+	queryTime, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || queryTime <= 0 {
+		return 0, 0, errors.New("Cursor is invalid")
+	}
+	if parts[2] != fmt.Sprintf("%08x", searchQueryHash(query)) {
+		return 0, 0, errors.New("Cursor does not belong to this search")
+	}
+
+	return int32(cursor), queryTime, nil
+}
+
+func searchQueryHash(query string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(query))
+	return h.Sum32()
+}
@@ -0,0 +1,84 @@
+package location
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/iknowhtml/locationtracker/pkg/config"
+)
+
+// GeoStore defines the minimum contract a spatial backend must satisfy to serve the
+// LocationService. Object IDs are passed already generated (see GenerateLocationObjectId)
+type GeoStore interface {
+	// Get returns the object and its fields (GET key id WITHFIELDS)
+	Get(key Object_Collection, objID string) (*GetObjectResponseObject, error)
+	// Set creates or replaces a point object with its fields (SET key id FIELD ... POINT lat lng)
+	Set(key Object_Collection, objID string, obj *LocationObject, fields LocationObject_Fields) (*SetObjectResponseObject, error)
+	// GetMany returns the objects in one pipelined round trip, responses are in the order of the ids
+	GetMany(key Object_Collection, objIDs []string) ([]*GetObjectResponseObject, error)
+	// SetMany creates or replaces the point objects in one pipelined round trip, responses are in the order of the objects
+	SetMany(key Object_Collection, objs []SetObjectRequestObject) ([]*SetObjectResponseObject, error)
+	// FSet updates fields of an existing object (FSET key id field value ...)
+	FSet(key Object_Collection, objID string, fields LocationObject_Fields) (*SetFieldResponseObject, error)
+	// FSetIf is FSet only when the current fields are equal to the expected ones, a missing field is 0.
+	// The check and the update are atomic, a failed check returns ok false with GeoStore_Error_Conflict
+	FSetIf(key Object_Collection, objID string, expected LocationObject_Fields, fields LocationObject_Fields) (*SetFieldResponseObject, error)
+	// SetIf is Set with the same check as FSetIf, nil expected fields only creates the object when it does not exist
+	SetIf(key Object_Collection, objID string, expected LocationObject_Fields, obj *LocationObject, fields LocationObject_Fields) (*SetObjectResponseObject, error)
+	// Nearby searches objects around a point ordered by distance (NEARBY key ... POINT lat lng radius),
+	// a radius of zero searches without distance limit
+	Nearby(key Object_Collection, query *NearbyQueryObject) (*NearbyObjectResponseObject, error)
+	// NearbyGeoJSON is the same as Nearby but returns the objects in GeoJSON format
+	NearbyGeoJSON(key Object_Collection, query *NearbyQueryObject) (*ScanObjectResponseObject, error)
+	// GetGeoJSON returns the object in GeoJSON format with its fields (GET key id WITHFIELDS)
+	GetGeoJSON(key Object_Collection, objID string) (*GetGeoJSONResponseObject, error)
+	// SetGeoJSON creates or replaces a GeoJSON object with its fields (SET key id FIELD ... OBJECT geojson)
+	SetGeoJSON(key Object_Collection, objID string, object json.RawMessage, fields LocationObject_Fields) (*SetObjectResponseObject, error)
+	// SetGeoJSONIf is SetGeoJSON with the same check as SetIf, nil expected fields only creates the object when it does not exist
+	SetGeoJSONIf(key Object_Collection, objID string, expected LocationObject_Fields, object json.RawMessage, fields LocationObject_Fields) (*SetObjectResponseObject, error)
+	// Del removes an object (DEL key id)
+	Del(key Object_Collection, objID string) (*DelObjectResponseObject, error)
+	// Scan returns the objects of a collection ordered by id (SCAN key LIMIT limit WHERE ...)
+	Scan(key Object_Collection, query *ScanQueryObject) (*ScanObjectResponseObject, error)
+	// SearchArea searches point objects within or intersecting an area (WITHIN|INTERSECTS key ... OBJECT geojson)
+	SearchArea(key Object_Collection, query *AreaQueryObject) (*NearbyObjectResponseObject, error)
+	// SearchAreaGeoJSON is the same as SearchArea but returns the objects in GeoJSON format
+	SearchAreaGeoJSON(key Object_Collection, query *AreaQueryObject) (*ScanObjectResponseObject, error)
+	// SetHook creates or replaces a geofence hook (SETHOOK name endpoint NEARBY|WITHIN|INTERSECTS key ... FENCE ...)
+	SetHook(hook *HookFenceObject) (*HookFenceResponseObject, error)
+	// DelHook removes a geofence hook (DELHOOK name)
+	DelHook(hookName string) (*HookFenceResponseObject, error)
+}
+
+// error of a failed check of FSetIf, SetIf and SetGeoJSONIf
+const GeoStore_Error_Conflict = "conflict"
+
+// GeoStore_Type
+type GeoStore_Type string
+
+const (
+	GeoStore_Type_Tile38 GeoStore_Type = "tile38"
+	GeoStore_Type_Memory GeoStore_Type = "memory"
+)
+
+// NewGeoStore creates the backend configured in locationremoteserver.store
+func NewGeoStore(c *LocationClient) (GeoStore, error) {
+
+	// load system configuration based on environment, singleton pattern
+	configuration, err := config.GetInstance("")
+	if configuration == nil || err != nil {
+		return nil, err
+	}
+
+	switch GeoStore_Type(configuration.Locationremoteserver.Store) {
+	case GeoStore_Type_Memory:
+		log.Printf("Using in-memory geo store...\n")
+		return newMemoryStore(), nil
+	case GeoStore_Type_Tile38, "":
+		log.Printf("Using Tile38 geo store: %s\n", configuration.Locationremoteserver.RemoteAddr)
+		return newTile38Store(c)
+	default:
+		return nil, errors.New("Unknown geo store type: " + configuration.Locationremoteserver.Store)
+	}
+}
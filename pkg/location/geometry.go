@@ -0,0 +1,88 @@
+package location
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+// GeometryObject is a GeoJSON geometry with its coordinates left undecoded
+type GeometryObject struct {
+	Type        LocationObject_Type `json:"type"`
+	Coordinates json.RawMessage     `json:"coordinates"`
+}
+
+// FeatureObject is a GeoJSON Feature with its properties left undecoded
+type FeatureObject struct {
+	Type       LocationObject_Type `json:"type"`
+	Geometry   GeometryObject      `json:"geometry"`
+	Properties json.RawMessage     `json:"properties,omitempty"`
+}
+
+// Polygon is a list of linear rings of [lng, lat] positions, the first ring is the
+// exterior and the others are holes
+type Polygon common.Polygon
+
+// ParsePolygons decodes a GeoJSON Polygon or MultiPolygon into a list of polygons,
+// validating the coordinate ranges and that every ring is closed
+func ParsePolygons(raw json.RawMessage) ([]Polygon, error) {
+	var geometry GeometryObject
+	err := json.Unmarshal(raw, &geometry)
+	if err != nil {
+		return nil, err
+	}
+
+	var polygons []Polygon
+	switch geometry.Type {
+	case LocationObject_Type_Polygon:
+		var polygon Polygon
+		err = json.Unmarshal(geometry.Coordinates, &polygon)
+		if err != nil {
+			return nil, err
+		}
+		polygons = []Polygon{polygon}
+	case LocationObject_Type_MultiPolygon:
+		err = json.Unmarshal(geometry.Coordinates, &polygons)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("Geometry type must be Polygon or MultiPolygon")
+	}
+
+	if len(polygons) == 0 {
+		return nil, errors.New("Geometry has no polygon")
+	}
+	for _, polygon := range polygons {
+		if len(polygon) == 0 {
+			return nil, errors.New("Polygon has no ring")
+		}
+		for _, ring := range polygon {
+			if len(ring) < 4 {
+				return nil, errors.New("Polygon ring must have at least 4 positions")
+			}
+			if ring[0] != ring[len(ring)-1] {
+				return nil, errors.New("Polygon ring is not closed")
+			}
+			for _, p := range ring {
+				if p[0] < -180 || p[0] > 180 || p[1] < -90 || p[1] > 90 {
+					return nil, errors.New("Polygon position is out of range")
+				}
+			}
+		}
+	}
+
+	return polygons, nil
+}
+
+// Contains reports whether the point is inside the exterior ring and outside all holes
+func (p Polygon) Contains(lat float64, lng float64) bool {
+	return common.Polygon(p).Contains(common.GeoPoint{Lat: lat, Lng: lng})
+}
+
+// Bounds returns the bounding box of the exterior ring
+func (p Polygon) Bounds() (minLat float64, minLng float64, maxLat float64, maxLng float64) {
+	b := common.Polygon(p).Bounds()
+	return b.MinLat, b.MinLng, b.MaxLat, b.MaxLng
+}
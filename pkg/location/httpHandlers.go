@@ -2,17 +2,23 @@ package location
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/iknowhtml/locationtracker/pkg/common"
 	"github.com/iknowhtml/locationtracker/pkg/config"
+	"github.com/iknowhtml/locationtracker/pkg/fleet"
 )
 
 // driver id (id)
-// POST body: { "avail": [1|0], "lat": 50.1000, "lng": 101.1000}
+// POST body: { "avail": [1|0|4], "lat": 50.1000, "lng": 101.1000}, 4 is on break
+// fleet, tenant of the driver (fleet = towing) (optional, the default tenant)
 func HandleSetDriverAvailability(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		common.HandleMethodNotAllowedResponse(w, "")
@@ -42,15 +48,10 @@ func HandleSetDriverAvailability(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Println(reqObj)
 
-	if reqObj.Lat == 0 {
+	// the equator and the prime meridian are valid, only (0, 0) is taken as missing
+	if err := (common.GeoPoint{Lat: reqObj.Lat, Lng: reqObj.Lng}).Validate(); err != nil {
 		// send a internal server error back to the caller
-		common.HandleStatus400Response(w, "Driver Location (Latitude) is missing, but required")
-		return
-	}
-
-	if reqObj.Lng == 0 {
-		// send a internal server error back to the caller
-		common.HandleStatus400Response(w, "Driver Location (Longitude) is missing, but required")
+		common.HandleStatus400Response(w, "Driver "+err.Error())
 		return
 	}
 
@@ -60,6 +61,8 @@ func HandleSetDriverAvailability(w http.ResponseWriter, r *http.Request) {
 		available = NearbySearch_Availability_1
 	case "0":
 		available = NearbySearch_Availability_0
+	case "4":
+		available = NearbySearch_Availability_4
 	default:
 		// send a internal server error back to the caller
 		common.HandleStatus400Response(w, "Driver Availability is missing, but required")
@@ -73,8 +76,24 @@ func HandleSetDriverAvailability(w http.ResponseWriter, r *http.Request) {
 		common.HandleServerErrorResponse(w, err)
 		return
 	}
+	// the drivers of the tenant of the fleet, the default tenant when not set
+	locController, err = locController.ForTenant(r.URL.Query().Get("fleet"))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
 
 	res, err := locController.SetAvailability(int32(driverID), reqObj.Lat, reqObj.Lng, available)
+	if err == ErrDriverStatusConflict {
+		// the driver is changed by another request, the caller may read the status and try again
+		common.HandleStatusConflictResponse(w, err.Error())
+		return
+	}
+	if _, ok := err.(*DriverTransitionError); ok {
+		// the driver lifecycle does not allow the change from the current status
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
 	if err != nil {
 		// send a internal server error back to the caller'
 		common.HandleServerErrorResponse(w, err)
@@ -89,6 +108,7 @@ func HandleSetDriverAvailability(w http.ResponseWriter, r *http.Request) {
 }
 
 // query param: driver id (id) (required)
+// fleet, tenant of the driver (fleet = towing) (optional, the default tenant)
 func HandleGetDriverStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		common.HandleMethodNotAllowedResponse(w, "")
@@ -113,6 +133,12 @@ func HandleGetDriverStatus(w http.ResponseWriter, r *http.Request) {
 		common.HandleServerErrorResponse(w, err)
 		return
 	}
+	// the drivers of the tenant of the fleet, the default tenant when not set
+	locController, err = locController.ForTenant(r.URL.Query().Get("fleet"))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
 
 	res, err := locController.GetDriverStatus(int32(driverID))
 	if err != nil {
@@ -131,8 +157,10 @@ func HandleGetDriverStatus(w http.ResponseWriter, r *http.Request) {
 // url: driver id ("id") (required)
 // post: lat (lat) (required)
 // post: lng (lng) (required)
-// post: availability (avail = 1|0) (required)
-// post: job id ("jobid") (required) -- if avail = 1, jobid is always zero
+// post: availability (avail = 1|0|2|4|5|6|7|8) (required)
+// -- 1 available, 0 not available, 4 on break, 2 busy, 5 offered, 6 en route to pickup, 7 arrived at pickup, 8 on trip
+// post: job id ("jobid") (required) -- required for the statuses on job (2|5|6|7|8) and to leave the job, always zero for the others
+// fleet, tenant of the driver (fleet = towing) (optional, the default tenant)
 func HandleSetDriverStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		common.HandleMethodNotAllowedResponse(w, "")
@@ -162,36 +190,27 @@ func HandleSetDriverStatus(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Println(reqObj)
 
-	if reqObj.Lat == 0 {
+	// the equator and the prime meridian are valid, only (0, 0) is taken as missing
+	if err := (common.GeoPoint{Lat: reqObj.Lat, Lng: reqObj.Lng}).Validate(); err != nil {
 		// send a internal server error back to the caller
-		common.HandleStatus400Response(w, "Location (Latitude) is missing, but required")
+		common.HandleStatus400Response(w, err.Error())
 		return
 	}
 
-	if reqObj.Lng == 0 {
+	searchAvail := NearbySearch_Availability(reqObj.Availability)
+	driverStatus, ok := searchAvail.DriverStatus()
+	if !ok {
 		// send a internal server error back to the caller
-		common.HandleStatus400Response(w, "Location (Longitude) is missing, but required")
+		common.HandleStatus400Response(w, "Driver Availability is missing, but required")
 		return
 	}
 
-	if reqObj.JobId == 0 && reqObj.Availability != "1" {
+	if reqObj.JobId == 0 && driverStatus.OnJob() {
 		// send a internal server error back to the caller
 		common.HandleStatus400Response(w, "Job ID is missing, but required")
 		return
 	}
 
-	var searchAvail NearbySearch_Availability
-	switch reqObj.Availability {
-	case "2":
-		searchAvail = NearbySearch_Availability_2
-	case "1":
-		searchAvail = NearbySearch_Availability_1
-	case "0":
-		searchAvail = NearbySearch_Availability_0
-	default:
-		searchAvail = NearbySearch_Availability_All
-	}
-
 	locController := new(LocationController)
 	err = locController.Init()
 	if err != nil {
@@ -199,8 +218,24 @@ func HandleSetDriverStatus(w http.ResponseWriter, r *http.Request) {
 		common.HandleServerErrorResponse(w, err)
 		return
 	}
+	// the drivers of the tenant of the fleet, the default tenant when not set
+	locController, err = locController.ForTenant(r.URL.Query().Get("fleet"))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
 
 	res, err := locController.UpdateDriverStatus(int32(driverID), reqObj.Lat, reqObj.Lng, searchAvail, reqObj.JobId)
+	if err == ErrDriverStatusConflict {
+		// the driver is changed by another request, the caller may read the status and try again
+		common.HandleStatusConflictResponse(w, err.Error())
+		return
+	}
+	if _, ok := err.(*DriverTransitionError); ok {
+		// the driver lifecycle does not allow the change from the current status
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
 	if err != nil {
 		// send a internal server error back to the caller
 		common.HandleServerErrorResponse(w, err)
@@ -214,14 +249,21 @@ func HandleSetDriverStatus(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// tier (tier = 1|2|3) (required)
+// tier (tier = 1|2|3|expand) (required), expand searches from tier 1 outwards until limit drivers are found
 // Url Param: lat (e_lat) (required)
 // Url Param: lng (e_lng) (required)
+// fleet, tenant of the drivers (fleet = towing) (optional, the default tenant)
 // providerid (provider) (optional)
-// availability (avail = 1|0) (optional)
+// availability (avail = 1|0|2|4|5|6|7|8) (optional)
 // service type id (srvtype = 0) (optional)
 // service id (srv = 0) (optional)
 // priority (priority = 1|0) (optional)
+// page size, or number of drivers to find by expanding search (limit) (optional, default 20)
+// next page token (cursor) (optional, nextcursor of the previous page)
+// excluded driver ids (exclude = 1,2,3) (optional)
+// excluded provider ids (excludeprovider = 1,2,3) (optional)
+// motion of the drivers (motion = 1|2|3, 1 moving, 2 idle, 3 stopped, comma separated) (optional)
+// max speed of the drivers in km/h (maxspeed) (optional)
 func HandleGetNearby(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		common.HandleMethodNotAllowedResponse(w, "")
@@ -239,103 +281,161 @@ func HandleGetNearby(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// load system configuration based on environment, singleton pattern
-	configuration, err := config.GetInstance("")
-	if configuration == nil {
-		log.Panicf("Failed to load configuration: %s\n", err.Error())
+	// the tiers of the tenant of the fleet
+	tenant, err := GetTenant(queryValues.Get("fleet"))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
 	}
+	tiers := tenant.SearchTiers()
 	var filterTier int32
-	switch queryValues.Get("tier") {
-	case "1":
+	expanding := false
+	switch NearbySearch_Tier(queryValues.Get("tier")) {
+	case NearbySearch_Tier_1:
 		filterTier = 0 // default 0 to turn off filter
-		searchTier = configuration.Locationremoteserver.SearchTier1Meter
-	case "2":
-		filterTier = configuration.Locationremoteserver.SearchTier1Meter
-		searchTier = configuration.Locationremoteserver.SearchTier2Meter
-	case "3":
-		filterTier = configuration.Locationremoteserver.SearchTier2Meter
-		searchTier = configuration.Locationremoteserver.SearchTier3Meter
+		searchTier = tiers[0]
+	case NearbySearch_Tier_2:
+		filterTier = tiers[0]
+		searchTier = tiers[1]
+	case NearbySearch_Tier_3:
+		filterTier = tiers[1]
+		searchTier = tiers[2]
+	case NearbySearch_Tier_Expand:
+		expanding = true
 	default:
 		// send a internal server error back to the caller
 		common.HandleStatus400Response(w, "Scan Tier is missing or invalid")
 		return
 	}
 
-	if searchTier == 0 {
+	if !expanding && searchTier == 0 {
 		common.HandleStatus400Response(w, "Scan Tier is set but no range is defined")
 		return
 	}
 
-	if queryValues.Get("e_lat") == "" {
+	e_point, err := common.ParseGeoPoint(queryValues.Get("e_lat"), queryValues.Get("e_lng"))
+	if err != nil {
 		// send a internal server error back to the caller
-		common.HandleStatus400Response(w, "Latitude is missing, but required")
+		common.HandleStatus400Response(w, err.Error())
 		return
 	}
-	if queryValues.Get("e_lng") == "" {
+	e_lat, e_lng := e_point.Lat, e_point.Lng
+	filter, err := ParseSearchFilter(queryValues)
+	if err != nil {
 		// send a internal server error back to the caller
-		common.HandleStatus400Response(w, "Longitude is missing, but required")
+		common.HandleStatus400Response(w, err.Error())
 		return
 	}
-	e_lat, err := strconv.ParseFloat(queryValues.Get("e_lat"), 32)
+	limit := Search_Limit
+	if queryValues.Get("limit") != "" {
+		l, err := strconv.ParseInt(queryValues.Get("limit"), 10, 32)
+		if err != nil || l <= 0 || int32(l) > Search_Max_Limit {
+			common.HandleStatus400Response(w, "Limit is invalid")
+			return
+		}
+		limit = int32(l)
+	}
+	// the cursor is bound to the query and filters, the page size may change between pages
+	query := fmt.Sprintf("%s|%s|%s|%s|%d|%d|%d|%s|%s|%v|%v|%v|%v",
+		filter.Fleet, queryValues.Get("tier"), queryValues.Get("e_lat"), queryValues.Get("e_lng"),
+		filter.ProviderID, filter.ServiceTypeID, filter.ServiceID, filter.Availability, filter.Priority,
+		filter.Exclude.DriverIDs, filter.Exclude.ProviderIDs, filter.Motion.Motions, filter.Motion.MaxSpeedKmh)
+	cursor, queryTime, err := DecodeSearchCursor(queryValues.Get("cursor"), query)
 	if err != nil {
-		// send a internal server error back to the caller
 		common.HandleStatus400Response(w, err.Error())
 		return
 	}
-	e_lng, err := strconv.ParseFloat(queryValues.Get("e_lng"), 32)
+	if expanding && cursor > 0 {
+		common.HandleStatus400Response(w, "Cursor is not supported by expanding search")
+		return
+	}
+
+	locController := new(LocationController)
+	err = locController.Init()
 	if err != nil {
 		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+	// the drivers of the tenant of the fleet, the default tenant when not set
+	locController, err = locController.ForTenant(filter.Fleet)
+	if err != nil {
 		common.HandleStatus400Response(w, err.Error())
 		return
 	}
-	var searchAvail NearbySearch_Availability
-	switch queryValues.Get("avail") {
-	case "1":
-		searchAvail = NearbySearch_Availability_1
-	case "0":
-		searchAvail = NearbySearch_Availability_0
-	default:
-		searchAvail = NearbySearch_Availability_All
+
+	var res *NearbyObjectMapObject
+	if expanding {
+		res, err = locController.SearchExpandingNearbyDriver(limit, e_lat, e_lng, filter.ProviderID, filter.ServiceTypeID, filter.ServiceID, filter.Availability, filter.Priority, &filter.Exclude, &filter.Motion)
+	} else {
+		res, err = locController.SearchNearbyDriverByProviderId(limit, cursor, queryTime, e_lat, e_lng, searchTier, filterTier, filter.ProviderID, filter.ServiceTypeID, filter.ServiceID, filter.Availability, filter.Priority, &filter.Exclude, &filter.Motion)
+	}
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
 	}
 
-	var serviceTypeID int64
-	if queryValues.Get("srvtype") != "" {
-		serviceTypeID, err = strconv.ParseInt(queryValues.Get("srvtype"), 10, 32)
-		if err != nil {
-			// send a internal server error back to the caller
-			common.HandleStatus400Response(w, err.Error())
-			return
-		}
+	if res != nil && res.Ok {
+		res.NextCursor = EncodeSearchCursor(res.Cursor, queryTime, query)
+		common.HandleStatusOKResponse(w, &NearbyDriversObject{NearbyDrivers: res})
+	} else {
+		common.HandleStatus400Response(w, res.Error)
 	}
+}
 
-	var serviceID int64
-	if queryValues.Get("srv") != "" {
-		serviceID, err = strconv.ParseInt(queryValues.Get("srv"), 10, 32)
-		if err != nil {
-			// send a internal server error back to the caller
-			common.HandleStatus400Response(w, err.Error())
-			return
-		}
+// Url Param: south west corner of the bounds (sw = lat,lng) (required)
+// Url Param: north east corner of the bounds (ne = lat,lng) (required), a west longitude greater than the east crosses the antimeridian
+// fleet, tenant of the drivers (fleet = towing) (optional, the default tenant)
+// providerid (provider) (optional)
+// availability (avail = 1|0|2|4|5|6|7|8) (optional)
+// service type id (srvtype = 0) (optional)
+// service id (srv = 0) (optional)
+// priority (priority = 1|0) (optional)
+// max number of drivers (limit) (optional, default 5000), truncated is set when more drivers are within the bounds
+// excluded driver ids (exclude = 1,2,3) (optional)
+// excluded provider ids (excludeprovider = 1,2,3) (optional)
+// motion of the drivers (motion = 1|2|3, 1 moving, 2 idle, 3 stopped, comma separated) (optional)
+// max speed of the drivers in km/h (maxspeed) (optional)
+func HandleGetWithinDriver(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
 	}
 
-	var searchPriority NearbySearch_Priority
-	switch queryValues.Get("priority") {
-	case "1":
-		searchPriority = NearbySearch_Priority_1
-	case "0":
-		searchPriority = NearbySearch_Priority_0
-	default:
-		searchPriority = NearbySearch_Priority_All
+	// get Url Param
+	queryValues := r.URL.Query()
+	log.Println(queryValues)
+
+	sw, err := parseBoundsCorner(queryValues.Get("sw"), "sw")
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+	ne, err := parseBoundsCorner(queryValues.Get("ne"), "ne")
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+	if sw.Lat >= ne.Lat || sw.Lng == ne.Lng {
+		common.HandleStatus400Response(w, "Bounds are empty")
+		return
 	}
+	bounds := common.BoundingBox{MinLat: sw.Lat, MinLng: sw.Lng, MaxLat: ne.Lat, MaxLng: ne.Lng}
 
-	var providerid int64
-	if queryValues.Get("provider") != "" {
-		providerid, err = strconv.ParseInt(queryValues.Get("provider"), 10, 32)
-		if err != nil {
-			// send a internal server error back to the caller
-			common.HandleStatus400Response(w, err.Error())
+	filter, err := ParseSearchFilter(queryValues)
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+	limit := Search_Within_Limit
+	if queryValues.Get("limit") != "" {
+		l, err := strconv.ParseInt(queryValues.Get("limit"), 10, 32)
+		if err != nil || l <= 0 || int32(l) > Search_Within_Max_Limit {
+			common.HandleStatus400Response(w, "Limit is invalid")
 			return
 		}
+		limit = int32(l)
 	}
 
 	locController := new(LocationController)
@@ -345,8 +445,14 @@ func HandleGetNearby(w http.ResponseWriter, r *http.Request) {
 		common.HandleServerErrorResponse(w, err)
 		return
 	}
+	// the drivers of the tenant of the fleet, the default tenant when not set
+	locController, err = locController.ForTenant(filter.Fleet)
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
 
-	res, err := locController.SearchNearbyDriverByProviderId(Search_Limit, float32(e_lat), float32(e_lng), searchTier, filterTier, int32(providerid), int32(serviceTypeID), int32(serviceID), searchAvail, searchPriority)
+	res, err := locController.SearchBoundsDriver(limit, bounds, filter.ProviderID, filter.ServiceTypeID, filter.ServiceID, filter.Availability, filter.Priority, &filter.Exclude, &filter.Motion)
 	if err != nil {
 		// send a internal server error back to the caller
 		common.HandleServerErrorResponse(w, err)
@@ -354,7 +460,7 @@ func HandleGetNearby(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if res != nil && res.Ok {
-		common.HandleStatusOKResponse(w, &NearbyDriversObject{NearbyDrivers: res})
+		common.HandleStatusOKResponse(w, &WithinDriversObject{WithinDrivers: res})
 	} else {
 		common.HandleStatus400Response(w, res.Error)
 	}
@@ -363,10 +469,11 @@ func HandleGetNearby(w http.ResponseWriter, r *http.Request) {
 // post: lat (e_lat) (required)
 // post: lng (e_lng) (required)
 // post: driver id ("id") (optional)
-// post: availability (avail = 1|0) (optional)
+// post: availability (avail = 1|0|2|4|5|6|7|8) (optional)
 // post: service type id (srvtype = 0) (optional)
 // post: service id (srv = 0) (optional)
 // post: priority (priority = 1|0) (optional)
+// fleet, tenant of the driver (fleet = towing) (optional, the default tenant)
 func HandleStartDetectArriving(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		common.HandleMethodNotAllowedResponse(w, "")
@@ -396,27 +503,14 @@ func HandleStartDetectArriving(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Println(reqObj)
 
-	if reqObj.E_lat == 0 {
-		// send a internal server error back to the caller
-		common.HandleStatus400Response(w, "Location (Latitude) is missing, but required")
-		return
-	}
-
-	if reqObj.E_lng == 0 {
+	// the equator and the prime meridian are valid, only (0, 0) is taken as missing
+	if err := (common.GeoPoint{Lat: reqObj.E_lat, Lng: reqObj.E_lng}).Validate(); err != nil {
 		// send a internal server error back to the caller
-		common.HandleStatus400Response(w, "Location (Longitude) is missing, but required")
+		common.HandleStatus400Response(w, err.Error())
 		return
 	}
 
-	var searchAvail NearbySearch_Availability
-	switch reqObj.Availability {
-	case "1":
-		searchAvail = NearbySearch_Availability_1
-	case "0":
-		searchAvail = NearbySearch_Availability_0
-	default:
-		searchAvail = NearbySearch_Availability_All
-	}
+	searchAvail := parseSearchAvailability(reqObj.Availability)
 
 	var searchPriority NearbySearch_Priority
 	switch reqObj.Priority {
@@ -435,6 +529,12 @@ func HandleStartDetectArriving(w http.ResponseWriter, r *http.Request) {
 		common.HandleServerErrorResponse(w, err)
 		return
 	}
+	// the drivers of the tenant of the fleet, the default tenant when not set
+	locController, err = locController.ForTenant(r.URL.Query().Get("fleet"))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
 
 	res, err := locController.DetectNearbyDriver(
 		reqObj.ID, reqObj.E_lat, reqObj.E_lng, Hook_Type_Arriving, endPoints, fenceRadius, reqObj.SearchServiceTypeID, reqObj.SearchServiceID, searchAvail, searchPriority)
@@ -452,6 +552,7 @@ func HandleStartDetectArriving(w http.ResponseWriter, r *http.Request) {
 }
 
 // driver id (id) (optional)
+// fleet, tenant of the driver (fleet = towing) (optional, the default tenant)
 func HandleStopDetectArriving(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "DELETE" {
 		common.HandleMethodNotAllowedResponse(w, "")
@@ -479,6 +580,12 @@ func HandleStopDetectArriving(w http.ResponseWriter, r *http.Request) {
 		common.HandleServerErrorResponse(w, err)
 		return
 	}
+	// the drivers of the tenant of the fleet, the default tenant when not set
+	locController, err = locController.ForTenant(r.URL.Query().Get("fleet"))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
 
 	res, err := locController.StopDetectNearbyDriver(reqObj.ID, Hook_Type_Arriving)
 	if err != nil {
@@ -497,10 +604,11 @@ func HandleStopDetectArriving(w http.ResponseWriter, r *http.Request) {
 // post: lat (e_lat) (required)
 // post: lng (e_lng) (required)
 // post: driver id (id) (optional)
-// post: availability (avail = 1|0) (optional)
+// post: availability (avail = 1|0|2|4|5|6|7|8) (optional)
 // post: service type id (srvtype = 0) (optional)
 // post: service id (srv = 0) (optional)
 // post: priority (priority = 1|0) (optional)
+// fleet, tenant of the driver (fleet = towing) (optional, the default tenant)
 func HandleStartDetectArrived(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		common.HandleMethodNotAllowedResponse(w, "")
@@ -530,27 +638,14 @@ func HandleStartDetectArrived(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Println(reqObj)
 
-	if reqObj.E_lat == 0 {
+	// the equator and the prime meridian are valid, only (0, 0) is taken as missing
+	if err := (common.GeoPoint{Lat: reqObj.E_lat, Lng: reqObj.E_lng}).Validate(); err != nil {
 		// send a internal server error back to the caller
-		common.HandleStatus400Response(w, "Location (Latitude) is missing, but required")
-		return
-	}
-
-	if reqObj.E_lng == 0 {
-		// send a internal server error back to the caller
-		common.HandleStatus400Response(w, "Location (Longitude) is missing, but required")
+		common.HandleStatus400Response(w, err.Error())
 		return
 	}
 
-	var searchAvail NearbySearch_Availability
-	switch reqObj.Availability {
-	case "1":
-		searchAvail = NearbySearch_Availability_1
-	case "0":
-		searchAvail = NearbySearch_Availability_0
-	default:
-		searchAvail = NearbySearch_Availability_All
-	}
+	searchAvail := parseSearchAvailability(reqObj.Availability)
 
 	var searchPriority NearbySearch_Priority
 	switch reqObj.Priority {
@@ -569,6 +664,12 @@ func HandleStartDetectArrived(w http.ResponseWriter, r *http.Request) {
 		common.HandleServerErrorResponse(w, err)
 		return
 	}
+	// the drivers of the tenant of the fleet, the default tenant when not set
+	locController, err = locController.ForTenant(r.URL.Query().Get("fleet"))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
 
 	res, err := locController.DetectNearbyDriver(
 		reqObj.ID, reqObj.E_lat, reqObj.E_lng, Hook_Type_Arrived, endPoints, fenceRadius, reqObj.SearchServiceTypeID, reqObj.SearchServiceID, searchAvail, searchPriority)
@@ -586,6 +687,7 @@ func HandleStartDetectArrived(w http.ResponseWriter, r *http.Request) {
 }
 
 // driver id (id) (optional)
+// fleet, tenant of the driver (fleet = towing) (optional, the default tenant)
 func HandleStopDetectArrived(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "DELETE" {
 		common.HandleMethodNotAllowedResponse(w, "")
@@ -612,6 +714,12 @@ func HandleStopDetectArrived(w http.ResponseWriter, r *http.Request) {
 		common.HandleServerErrorResponse(w, err)
 		return
 	}
+	// the drivers of the tenant of the fleet, the default tenant when not set
+	locController, err = locController.ForTenant(r.URL.Query().Get("fleet"))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
 
 	res, err := locController.StopDetectNearbyDriver(reqObj.ID, Hook_Type_Arrived)
 	if err != nil {
@@ -626,3 +734,185 @@ func HandleStopDetectArrived(w http.ResponseWriter, r *http.Request) {
 		common.HandleStatus400Response(w, res.Error)
 	}
 }
+
+// counts of the positions accepted and rejected by the position filter since the start of the server
+func HandleGetPositionFilterStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &PositionFilterObject{PositionFilter: GetPositionFilterStats()})
+}
+
+// counts of the lookups of the fleet info cache since the start of the server
+func HandleGetFleetInfoCacheStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &FleetInfoCacheObject{FleetInfoCache: fleet.GetFleetInfoCacheStats()})
+}
+
+// driver id (id) (optional), the cached fleet info of all drivers is removed when not set
+// the next change of availability or status of the driver asks the fleet server again
+func HandleDelDriverFleetInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "DELETE" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	if vars["id"] == "" {
+		fleet.InvalidateAllDriverFleetInfo()
+		common.HandleStatusOKResponse(w, &common.EmptyResultObject{})
+		return
+	}
+	driverID, err := strconv.Atoi(vars["id"])
+	if err != nil || driverID == 0 {
+		common.HandleStatus400Response(w, "Driver ID is invalid")
+		return
+	}
+
+	fleet.InvalidateDriverFleetInfo(int32(driverID))
+	common.HandleStatusOKResponse(w, &common.EmptyResultObject{})
+}
+
+// ParseSearchFilter reads the driver search filters from the url params
+// fleet, tenant of the drivers (fleet = towing) (optional, the default tenant)
+// providerid (provider) (optional)
+// availability (avail = 1|0|2|4|5|6|7|8) (optional)
+// service type id (srvtype = 0) (optional)
+// service id (srv = 0) (optional)
+// priority (priority = 1|0) (optional)
+// motion (motion = 1|2|3, comma separated) (optional)
+// max speed in km/h (maxspeed) (optional)
+func ParseSearchFilter(queryValues url.Values) (*SearchFilterObject, error) {
+	filter := &SearchFilterObject{}
+
+	if _, err := GetTenant(queryValues.Get("fleet")); err != nil {
+		return nil, err
+	}
+	filter.Fleet = queryValues.Get("fleet")
+
+	filter.Availability = parseSearchAvailability(queryValues.Get("avail"))
+
+	if queryValues.Get("srvtype") != "" {
+		serviceTypeID, err := strconv.ParseInt(queryValues.Get("srvtype"), 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		filter.ServiceTypeID = int32(serviceTypeID)
+	}
+
+	if queryValues.Get("srv") != "" {
+		serviceID, err := strconv.ParseInt(queryValues.Get("srv"), 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		filter.ServiceID = int32(serviceID)
+	}
+
+	switch queryValues.Get("priority") {
+	case "1":
+		filter.Priority = NearbySearch_Priority_1
+	case "0":
+		filter.Priority = NearbySearch_Priority_0
+	default:
+		filter.Priority = NearbySearch_Priority_All
+	}
+
+	if queryValues.Get("provider") != "" {
+		providerID, err := strconv.ParseInt(queryValues.Get("provider"), 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		filter.ProviderID = int32(providerID)
+	}
+
+	driverIDs, err := parseIDList(queryValues.Get("exclude"))
+	if err != nil {
+		return nil, errors.New("Excluded driver ids are invalid")
+	}
+	filter.Exclude.DriverIDs = driverIDs
+
+	providerIDs, err := parseIDList(queryValues.Get("excludeprovider"))
+	if err != nil {
+		return nil, errors.New("Excluded provider ids are invalid")
+	}
+	filter.Exclude.ProviderIDs = providerIDs
+
+	motions, err := parseIDList(queryValues.Get("motion"))
+	if err != nil {
+		return nil, errors.New("Motion is invalid")
+	}
+	for _, m := range motions {
+		motion := DriverMotion(m)
+		if motion != DriverMotion_MOVING && motion != DriverMotion_IDLE && motion != DriverMotion_STOPPED {
+			return nil, errors.New("Motion is invalid")
+		}
+		filter.Motion.Motions = append(filter.Motion.Motions, motion)
+	}
+
+	if queryValues.Get("maxspeed") != "" {
+		maxSpeed, err := strconv.ParseFloat(queryValues.Get("maxspeed"), 64)
+		if err != nil || maxSpeed <= 0 {
+			return nil, errors.New("Max speed is invalid")
+		}
+		filter.Motion.MaxSpeedKmh = maxSpeed
+	}
+
+	return filter, nil
+}
+
+// parseIDList returns the ids of a comma separated list, nil for an empty list
+func parseIDList(list string) ([]int32, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	var ids []int32
+	for _, v := range strings.Split(list, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(v), 10, 32)
+		if err != nil || id <= 0 {
+			return nil, errors.New("Id is invalid: " + v)
+		}
+		ids = append(ids, int32(id))
+	}
+	return ids, nil
+}
+
+// parseBoundsCorner parses a corner of the bounds (lat,lng), the corner may be (0, 0)
+func parseBoundsCorner(corner string, name string) (common.GeoPoint, error) {
+	if corner == "" {
+		return common.GeoPoint{}, errors.New("Bounds (" + name + ") is missing, but required")
+	}
+	latLng := strings.Split(corner, ",")
+	if len(latLng) != 2 {
+		return common.GeoPoint{}, errors.New("Bounds (" + name + ") must be lat,lng")
+	}
+	lat, errLat := strconv.ParseFloat(strings.TrimSpace(latLng[0]), 64)
+	lng, errLng := strconv.ParseFloat(strings.TrimSpace(latLng[1]), 64)
+	if errLat != nil || errLng != nil {
+		return common.GeoPoint{}, errors.New("Bounds (" + name + ") is invalid")
+	}
+	p := common.GeoPoint{Lat: lat, Lng: lng}
+	if err := p.Validate(); err != nil && err != common.ErrGeoPointNullIsland {
+		return common.GeoPoint{}, errors.New("Bounds (" + name + ") is invalid: " + err.Error())
+	}
+	return p, nil
+}
+
+// parseSearchAvailability returns the availability of a driver search, all for an empty or unknown availability
+func parseSearchAvailability(avail string) NearbySearch_Availability {
+	switch NearbySearch_Availability(avail) {
+	case NearbySearch_Availability_1, NearbySearch_Availability_0, NearbySearch_Availability_2,
+		NearbySearch_Availability_4, NearbySearch_Availability_5, NearbySearch_Availability_6,
+		NearbySearch_Availability_7, NearbySearch_Availability_8:
+		return NearbySearch_Availability(avail)
+	default:
+		return NearbySearch_Availability_All
+	}
+}
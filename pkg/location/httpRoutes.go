@@ -10,11 +10,16 @@ func NewRouter() []common.Route {
 		common.Route{"SetDriverAvailability", "POST", "/driver/{id:[0-9]+}/availability", HandleSetDriverAvailability},
 		common.Route{"GetDriverStatus", "GET", "/driver/{id:[0-9]+}/status", HandleGetDriverStatus},
 		common.Route{"GetNearby", "GET", "/driver/nearby", HandleGetNearby},
+		common.Route{"GetWithinDriver", "GET", "/driver/within", HandleGetWithinDriver},
 		common.Route{"SetDetectArriving", "POST", "/driver/startdetectarriving", HandleStartDetectArriving},
 		common.Route{"DelDetectArriving", "DELETE", "/driver/stopdetectarriving", HandleStopDetectArriving},
 		common.Route{"SetDetectArrived", "POST", "/driver/startdetectarrived", HandleStartDetectArrived},
 		common.Route{"DelDetectArrived", "DELETE", "/driver/stopdetectarrived", HandleStopDetectArrived},
 		common.Route{"SetDriverStatus", "POST", "/driver/{id:[0-9]+}/status", HandleSetDriverStatus},
+		common.Route{"GetPositionFilterStats", "GET", "/driver/positionfilter", HandleGetPositionFilterStats},
+		common.Route{"GetFleetInfoCacheStats", "GET", "/driver/fleetinfo", HandleGetFleetInfoCacheStats},
+		common.Route{"DelAllDriverFleetInfo", "DELETE", "/driver/fleetinfo", HandleDelDriverFleetInfo},
+		common.Route{"DelDriverFleetInfo", "DELETE", "/driver/{id:[0-9]+}/fleetinfo", HandleDelDriverFleetInfo},
 	}
 
 	return fleetRouter
@@ -0,0 +1,338 @@
+package location
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/history"
+)
+
+// driverTransitions is the lifecycle of a driver, the statuses a driver can be set to from each status.
+// Unreachable is set only by the sweeper, an unreachable driver is checked as in the status before it became unreachable
+var driverTransitions = map[DriverStatus][]DriverStatus{
+	DriverStatus_NOTAVAILABLE: {
+		DriverStatus_NOTAVAILABLE, DriverStatus_AVAILABLE, DriverStatus_ON_BREAK,
+	},
+	DriverStatus_AVAILABLE: {
+		DriverStatus_AVAILABLE, DriverStatus_NOTAVAILABLE, DriverStatus_ON_BREAK,
+		DriverStatus_OFFERED, DriverStatus_BUSY, DriverStatus_EN_ROUTE_TO_PICKUP, DriverStatus_UNREACHABLE,
+	},
+	DriverStatus_ON_BREAK: {
+		DriverStatus_ON_BREAK, DriverStatus_AVAILABLE, DriverStatus_NOTAVAILABLE,
+	},
+	// the offer is accepted, declined or expired
+	DriverStatus_OFFERED: {
+		DriverStatus_OFFERED, DriverStatus_AVAILABLE, DriverStatus_BUSY, DriverStatus_EN_ROUTE_TO_PICKUP, DriverStatus_UNREACHABLE,
+	},
+	// busy is a job accepted without the progress of the job
+	DriverStatus_BUSY: {
+		DriverStatus_BUSY, DriverStatus_AVAILABLE, DriverStatus_EN_ROUTE_TO_PICKUP, DriverStatus_ARRIVED_AT_PICKUP,
+		DriverStatus_ON_TRIP, DriverStatus_UNREACHABLE,
+	},
+	DriverStatus_EN_ROUTE_TO_PICKUP: {
+		DriverStatus_EN_ROUTE_TO_PICKUP, DriverStatus_ARRIVED_AT_PICKUP, DriverStatus_AVAILABLE, DriverStatus_UNREACHABLE,
+	},
+	DriverStatus_ARRIVED_AT_PICKUP: {
+		DriverStatus_ARRIVED_AT_PICKUP, DriverStatus_ON_TRIP, DriverStatus_AVAILABLE, DriverStatus_UNREACHABLE,
+	},
+	// the job is completed or cancelled
+	DriverStatus_ON_TRIP: {
+		DriverStatus_ON_TRIP, DriverStatus_AVAILABLE, DriverStatus_UNREACHABLE,
+	},
+}
+
+// DriverTransitionObject is a change of the status of a driver
+type DriverTransitionObject struct {
+	DriverID   int32
+	ProviderID int32
+	From       DriverStatus
+	To         DriverStatus
+	FromJobID  int32 // job of the driver before the change
+	JobID      int32 // job of the request, the job of the driver after the change when to status is on job
+	Lat        float64
+	Lng        float64
+	Time       int64
+}
+
+// DriverTransitionError is returned when the change of the status is not allowed by the lifecycle or a guard
+type DriverTransitionError struct {
+	From   DriverStatus
+	To     DriverStatus
+	Reason string
+}
+
+func (e *DriverTransitionError) Error() string {
+	return fmt.Sprintf("Driver status cannot be changed from %s to %s: %s", e.From, e.To, e.Reason)
+}
+
+// DriverStateGuard checks a change into the status, the change is rejected when an error is returned
+type DriverStateGuard func(t *DriverTransitionObject) error
+
+// DriverStateHook runs after the driver is changed into the status, it is called outside of any lock
+type DriverStateHook func(t *DriverTransitionObject)
+
+var driverStateGuards = map[DriverStatus][]DriverStateGuard{}
+var driverStateHooks = map[DriverStatus][]DriverStateHook{}
+var driverStateMu sync.RWMutex
+
+// AddDriverStateGuard registers a guard of the changes into the status
+func AddDriverStateGuard(status DriverStatus, guard DriverStateGuard) {
+	if guard == nil {
+		return
+	}
+	driverStateMu.Lock()
+	defer driverStateMu.Unlock()
+	driverStateGuards[status] = append(driverStateGuards[status], guard)
+}
+
+// AddDriverStateHook registers a hook of the changes into the status
+func AddDriverStateHook(status DriverStatus, hook DriverStateHook) {
+	if hook == nil {
+		return
+	}
+	driverStateMu.Lock()
+	defer driverStateMu.Unlock()
+	driverStateHooks[status] = append(driverStateHooks[status], hook)
+}
+
+// CheckDriverTransition checks the change against the lifecycle, the job of the driver and the guards of the status
+func CheckDriverTransition(t *DriverTransitionObject) error {
+	allowed := false
+	for _, to := range driverTransitions[t.From] {
+		if to == t.To {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return &DriverTransitionError{From: t.From, To: t.To, Reason: "not allowed by the driver lifecycle"}
+	}
+
+	// a driver on job is changed only for the job, so a driver is never on two jobs
+	if t.From.OnJob() && t.JobID != t.FromJobID {
+		return &DriverTransitionError{From: t.From, To: t.To, Reason: fmt.Sprintf("driver is on job %d", t.FromJobID)}
+	}
+	if t.To.OnJob() && t.JobID == 0 {
+		return &DriverTransitionError{From: t.From, To: t.To, Reason: "job id is required"}
+	}
+
+	driverStateMu.RLock()
+	guards := driverStateGuards[t.To]
+	driverStateMu.RUnlock()
+
+	for _, guard := range guards {
+		err := guard(t)
+		if err != nil {
+			return &DriverTransitionError{From: t.From, To: t.To, Reason: err.Error()}
+		}
+	}
+	return nil
+}
+
+func runDriverStateHooks(t *DriverTransitionObject) {
+	log.Printf("Driver status changed: driver %d from %s to %s\n", t.DriverID, t.From, t.To)
+
+	driverStateMu.RLock()
+	hooks := driverStateHooks[t.To]
+	driverStateMu.RUnlock()
+
+	for _, hook := range hooks {
+		hook(t)
+	}
+}
+
+// newDriverTransition returns the change of the driver into the status for the job,
+// an unreachable driver is changed from the status before it became unreachable
+func newDriverTransition(driverExistObj *GetObjectResponseObject, driverID int32, to DriverStatus, jobID int32) *DriverTransitionObject {
+	t := &DriverTransitionObject{
+		DriverID: driverID,
+		From:     DriverStatus_NOTAVAILABLE,
+		To:       to,
+		JobID:    jobID,
+		Time:     time.Now().Unix(),
+	}
+	if driverExistObj.Ok && driverExistObj.Fields.DriverID != 0 {
+		t.ProviderID = driverExistObj.Fields.ProviderID
+		t.From = driverExistObj.Fields.Status
+		if t.From == DriverStatus_UNREACHABLE {
+			t.From = driverExistObj.Fields.PreviousStatus
+		}
+		t.FromJobID = driverExistObj.Fields.JobID
+		p := driverExistObj.Object.Point()
+		t.Lat = p.Lat
+		t.Lng = p.Lng
+	}
+	return t
+}
+
+// fields of the driver object set by the change
+func (t *DriverTransitionObject) fields() LocationObject_Fields {
+	jobID := int32(0)
+	if t.To.OnJob() {
+		jobID = t.JobID
+	}
+	return LocationObject_Fields{
+		"driverstatus":     int32(t.To),
+		"prevdriverstatus": 0,
+		"jobid":            jobID,
+		"lastupdatedtime":  t.Time,
+	}
+}
+
+// historyEvent is the event of the change kept in the trail of the driver
+func (t *DriverTransitionObject) historyEvent() history.History_Event {
+	switch {
+	case t.To.OnJob() && !t.From.OnJob():
+		return history.History_Event_JobStart
+	case t.From.OnJob() && !t.To.OnJob():
+		return history.History_Event_JobEnd
+	default:
+		return history.History_Event_Status
+	}
+}
+
+// DriverStatus returns the status set by the availability, false when the availability is not a status
+func (avail NearbySearch_Availability) DriverStatus() (DriverStatus, bool) {
+	switch avail {
+	case NearbySearch_Availability_0:
+		return DriverStatus_NOTAVAILABLE, true
+	case NearbySearch_Availability_1:
+		return DriverStatus_AVAILABLE, true
+	case NearbySearch_Availability_2:
+		return DriverStatus_BUSY, true
+	case NearbySearch_Availability_4:
+		return DriverStatus_ON_BREAK, true
+	case NearbySearch_Availability_5:
+		return DriverStatus_OFFERED, true
+	case NearbySearch_Availability_6:
+		return DriverStatus_EN_ROUTE_TO_PICKUP, true
+	case NearbySearch_Availability_7:
+		return DriverStatus_ARRIVED_AT_PICKUP, true
+	case NearbySearch_Availability_8:
+		return DriverStatus_ON_TRIP, true
+	default:
+		return DriverStatus_NOTAVAILABLE, false
+	}
+}
+
+// searchStatuses returns the statuses of the drivers found by the availability, nil to find all.
+// Busy finds the drivers on job in any progress, not available finds the drivers which cannot be offered a job
+func (avail NearbySearch_Availability) searchStatuses() []interface{} {
+	var vals []interface{}
+	switch avail {
+	case NearbySearch_Availability_All:
+		return nil
+	case NearbySearch_Availability_0:
+		vals = append(vals, int32(DriverStatus_NOTAVAILABLE))
+		vals = append(vals, int32(DriverStatus_ON_BREAK))
+		for _, status := range onJobStatuses() {
+			vals = append(vals, int32(status))
+		}
+	case NearbySearch_Availability_2:
+		for _, status := range onJobStatuses() {
+			vals = append(vals, int32(status))
+		}
+	default:
+		status, ok := avail.DriverStatus()
+		if !ok {
+			return nil
+		}
+		vals = append(vals, int32(status))
+	}
+	return vals
+}
+
+func onJobStatuses() []DriverStatus {
+	return []DriverStatus{
+		DriverStatus_OFFERED, DriverStatus_BUSY, DriverStatus_EN_ROUTE_TO_PICKUP, DriverStatus_ARRIVED_AT_PICKUP, DriverStatus_ON_TRIP,
+	}
+}
+
+// driverStatusesTo returns the statuses a driver can be changed from into the status
+func driverStatusesTo(status DriverStatus) []interface{} {
+	var from []int
+	for f, tos := range driverTransitions {
+		for _, to := range tos {
+			if to == status {
+				from = append(from, int(f))
+				break
+			}
+		}
+	}
+	sort.Ints(from)
+
+	vals := make([]interface{}, len(from))
+	for i, f := range from {
+		vals[i] = int32(f)
+	}
+	return vals
+}
+
+// Change the status of an existing driver, checked against the lifecycle of the driver.
+// The driver is updated only when it is not changed since it was read
+func (lc *LocationController) TransitionDriverStatus(
+	driverID int32,
+	to DriverStatus,
+	jobID int32) (*SetFieldResponseObject, error) {
+	return lc.transitionDriverStatus(driverID, to, jobID, nil)
+}
+
+// transitionDriverStatus changes the status with the guard of the request checked after the guards of the status
+func (lc *LocationController) transitionDriverStatus(
+	driverID int32,
+	to DriverStatus,
+	jobID int32,
+	guard DriverStateGuard) (*SetFieldResponseObject, error) {
+
+	// get current driver status
+	driverExistObj, err := lc.locationService.GetObject(lc.tenant.Collection, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	// check if response object is empty
+	if driverExistObj == nil {
+		return nil, errors.New("response object is empty")
+	}
+
+	// check if driver object found
+	if driverExistObj.Ok != true {
+		return nil, errors.New("failed to retrieve object")
+	}
+
+	t := newDriverTransition(driverExistObj, driverID, to, jobID)
+	err = CheckDriverTransition(t)
+	if err != nil {
+		return nil, err
+	}
+	if guard != nil {
+		err = guard(t)
+		if err != nil {
+			return nil, &DriverTransitionError{From: t.From, To: t.To, Reason: err.Error()}
+		}
+	}
+
+	res, err := lc.locationService.SetFieldIf(lc.tenant.Collection, driverID, expectedDriverStatus(driverExistObj), t.fields())
+	if err != nil {
+		return nil, err
+	}
+
+	// check if ok is false
+	if res.Ok == false {
+		return nil, driverStatusError(res.Error)
+	}
+
+	// keep the point in the trail of the driver and of the job
+	trailJobID := t.JobID
+	if trailJobID == 0 {
+		trailJobID = t.FromJobID
+	}
+	lc.historyController.RecordDriverPoint(driverID, t.Lat, t.Lng, int32(t.To), trailJobID, t.historyEvent(), t.Time)
+
+	runDriverStateHooks(t)
+	return res, nil
+}
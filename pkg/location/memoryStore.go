@@ -0,0 +1,1234 @@
+package location
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+// size of a grid cell of the in-memory spatial index, in degrees (about 1.1km at the equator)
+const memoryCellDegree float64 = 0.01
+
+// meters per degree of latitude, used to convert a search radius into a cell range
+const meterPerDegree float64 = 111320
+
+// memoryCell is the key of a grid cell in the in-memory spatial index
+type memoryCell struct {
+	x int32
+	y int32
+}
+
+func newMemoryCell(lat float64, lng float64) memoryCell {
+	return memoryCell{
+		x: int32(math.Floor(lng / memoryCellDegree)),
+		y: int32(math.Floor(lat / memoryCellDegree)),
+	}
+}
+
+type memoryObject struct {
+	id     string
+	lat    float64
+	lng    float64
+	cell   memoryCell
+	fields map[string]float64
+	// GeoJSON of objects which are not a point, these objects are not in the grid index
+	shape json.RawMessage
+	// GeoJSON Feature of point objects set with properties, returned as is
+	feature json.RawMessage
+}
+
+type memoryCollection struct {
+	objects map[string]*memoryObject
+	// field names in order of first appearance, as returned by Tile38
+	fieldNames []string
+	cells      map[memoryCell]map[string]*memoryObject
+}
+
+type memoryHook struct {
+	hook     HookFenceObject
+	polygons []Polygon
+	inside   map[string]bool
+}
+
+// memoryStore implements GeoStore with a pure Go grid index kept in process memory.
+// It is shared by every LocationService of the process, so data survives across requests
+type memoryStore struct {
+	mu          sync.RWMutex
+	collections map[Object_Collection]*memoryCollection
+	hooks       map[string]*memoryHook
+	events      *memoryHookQueue
+}
+
+var ms *memoryStore
+var msOnce sync.Once
+
+// MemoryHookHandler receives the fence events raised by the in-memory store, with the
+// endpoint of the hook and the event payload in Tile38 format
+type MemoryHookHandler func(endpoint string, payload []byte)
+
+var memoryHookHandler MemoryHookHandler = func(endpoint string, payload []byte) {
+	log.Printf("Memory hook event to %s: %s\n", endpoint, string(payload))
+}
+var memoryHookMu sync.RWMutex
+
+// SetMemoryHookHandler replaces the handler of fence events raised by the in-memory store,
+// it may be replaced while the store is raising events
+func SetMemoryHookHandler(handler MemoryHookHandler) {
+	if handler == nil {
+		return
+	}
+	memoryHookMu.Lock()
+	defer memoryHookMu.Unlock()
+	memoryHookHandler = handler
+}
+
+func getMemoryHookHandler() MemoryHookHandler {
+	memoryHookMu.RLock()
+	defer memoryHookMu.RUnlock()
+	return memoryHookHandler
+}
+
+// memoryHookEvent is a fence event waiting to be delivered to the hook handler
+type memoryHookEvent struct {
+	endpoint string
+	payload  []byte
+}
+
+// memoryHookQueue delivers the fence events to the hook handler from a single goroutine, in the
+// order they were raised, same as Tile38. Pushing never blocks, so events are queued under the
+// store lock and delivered outside of it
+type memoryHookQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	events []memoryHookEvent
+}
+
+func newMemoryHookQueue() *memoryHookQueue {
+	q := &memoryHookQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	go q.run()
+	return q
+}
+
+func (q *memoryHookQueue) push(endpoint string, payload []byte) {
+	q.mu.Lock()
+	q.events = append(q.events, memoryHookEvent{endpoint: endpoint, payload: payload})
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+func (q *memoryHookQueue) run() {
+	for {
+		q.mu.Lock()
+		for len(q.events) == 0 {
+			q.cond.Wait()
+		}
+		events := q.events
+		q.events = nil
+		q.mu.Unlock()
+
+		for _, e := range events {
+			getMemoryHookHandler()(e.endpoint, e.payload)
+		}
+	}
+}
+
+func newMemoryStore() *memoryStore {
+	msOnce.Do(func() {
+		log.Printf("Creating new in-memory geo store...\n")
+		ms = &memoryStore{
+			collections: make(map[Object_Collection]*memoryCollection),
+			hooks:       make(map[string]*memoryHook),
+			events:      newMemoryHookQueue(),
+		}
+	})
+
+	return ms
+}
+
+func elapsed(start time.Time) string {
+	return time.Since(start).String()
+}
+
+func (m *memoryStore) Get(key Object_Collection, objID string) (*GetObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	respObj, err := m.get(key, objID)
+	if err != nil {
+		return nil, err
+	}
+	respObj.Elapsed = elapsed(start)
+
+	return respObj, nil
+}
+
+func (m *memoryStore) Set(key Object_Collection, objID string, obj *LocationObject, fields LocationObject_Fields) (*SetObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := m.set(key, objID, obj, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetObjectResponseObject{Ok: true, Elapsed: elapsed(start)}, nil
+}
+
+func (m *memoryStore) GetMany(key Object_Collection, objIDs []string) ([]*GetObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	respObjs := make([]*GetObjectResponseObject, len(objIDs))
+	for i, objID := range objIDs {
+		respObj, err := m.get(key, objID)
+		if err != nil {
+			return nil, err
+		}
+		respObj.Elapsed = elapsed(start)
+		respObjs[i] = respObj
+	}
+
+	return respObjs, nil
+}
+
+func (m *memoryStore) SetMany(key Object_Collection, objs []SetObjectRequestObject) ([]*SetObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// same as a Tile38 pipeline, a failed SET does not stop the others
+	respObjs := make([]*SetObjectResponseObject, len(objs))
+	for i, o := range objs {
+		err := m.set(key, o.ObjID, o.Object, o.Fields)
+		if err != nil {
+			respObjs[i] = &SetObjectResponseObject{Ok: false, Error: err.Error(), Elapsed: elapsed(start)}
+			continue
+		}
+		respObjs[i] = &SetObjectResponseObject{Ok: true, Elapsed: elapsed(start)}
+	}
+
+	return respObjs, nil
+}
+
+// get returns the point object and its fields. Caller must hold the read lock
+func (m *memoryStore) get(key Object_Collection, objID string) (*GetObjectResponseObject, error) {
+	col, ok := m.collections[key]
+	if !ok {
+		return &GetObjectResponseObject{Ok: false, Error: "key not found"}, nil
+	}
+	o, ok := col.objects[objID]
+	if !ok {
+		return &GetObjectResponseObject{Ok: false, Error: "id not found"}, nil
+	}
+	if o.shape != nil {
+		return nil, errors.New("Object is not a point: " + objID)
+	}
+
+	props, err := o.properties()
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetObjectResponseObject{
+		Ok:     true,
+		Object: o.response(),
+		Fields: *props}, nil
+}
+
+// set adds or replaces the point object and raises the fence events. Caller must hold the write lock
+func (m *memoryStore) set(key Object_Collection, objID string, obj *LocationObject, fields LocationObject_Fields) error {
+	values, err := toFieldValues(fields)
+	if err != nil {
+		return err
+	}
+
+	p := obj.Point()
+	o := &memoryObject{
+		id:     objID,
+		lat:    p.Lat,
+		lng:    p.Lng,
+		fields: values,
+	}
+	m.collection(key).put(o)
+
+	m.detect(key, o)
+
+	return nil
+}
+
+func (m *memoryStore) FSet(key Object_Collection, objID string, fields LocationObject_Fields) (*SetFieldResponseObject, error) {
+	start := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	respObj, err := m.fset(key, objID, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	respObj.Elapsed = elapsed(start)
+	return respObj, nil
+}
+
+func (m *memoryStore) FSetIf(key Object_Collection, objID string, expected LocationObject_Fields, fields LocationObject_Fields) (*SetFieldResponseObject, error) {
+	start := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, err := m.object(key, objID)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return &SetFieldResponseObject{Ok: false, Error: "id not found", Elapsed: elapsed(start)}, nil
+	}
+	matched, err := o.fieldsEqual(expected)
+	if err != nil {
+		return nil, err
+	}
+	if !matched {
+		return &SetFieldResponseObject{Ok: false, Error: GeoStore_Error_Conflict, Elapsed: elapsed(start)}, nil
+	}
+
+	respObj, err := m.fset(key, objID, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	respObj.Elapsed = elapsed(start)
+	return respObj, nil
+}
+
+func (m *memoryStore) SetIf(key Object_Collection, objID string, expected LocationObject_Fields, obj *LocationObject, fields LocationObject_Fields) (*SetObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, err := m.object(key, objID)
+	if err != nil {
+		return nil, err
+	}
+	matched := o == nil && expected == nil
+	if o != nil && expected != nil {
+		matched, err = o.fieldsEqual(expected)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !matched {
+		return &SetObjectResponseObject{Ok: false, Error: GeoStore_Error_Conflict, Elapsed: elapsed(start)}, nil
+	}
+
+	err = m.set(key, objID, obj, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetObjectResponseObject{Ok: true, Elapsed: elapsed(start)}, nil
+}
+
+// object returns the point object of the id, nil when not exist. Caller must hold the lock
+func (m *memoryStore) object(key Object_Collection, objID string) (*memoryObject, error) {
+	col, ok := m.collections[key]
+	if !ok {
+		return nil, nil
+	}
+	o, ok := col.objects[objID]
+	if !ok {
+		return nil, nil
+	}
+	if o.shape != nil {
+		return nil, errors.New("Object is not a point: " + objID)
+	}
+	return o, nil
+}
+
+// fset updates the fields of the object and raises the fence events. Caller must hold the write lock
+func (m *memoryStore) fset(key Object_Collection, objID string, fields LocationObject_Fields) (*SetFieldResponseObject, error) {
+	values, err := toFieldValues(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	col, ok := m.collections[key]
+	if !ok {
+		return &SetFieldResponseObject{Ok: false, Error: "key not found"}, nil
+	}
+	o, ok := col.objects[objID]
+	if !ok {
+		return &SetFieldResponseObject{Ok: false, Error: "id not found"}, nil
+	}
+
+	for k, v := range values {
+		o.fields[k] = v
+	}
+	col.addFieldNames(values)
+
+	m.detect(key, o)
+
+	return &SetFieldResponseObject{Ok: true}, nil
+}
+
+func (m *memoryStore) Nearby(key Object_Collection, query *NearbyQueryObject) (*NearbyObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	respObj := &NearbyObjectResponseObject{Ok: true}
+
+	col, ok := m.collections[key]
+	if !ok {
+		respObj.Elapsed = elapsed(start)
+		return respObj, nil
+	}
+
+	objs, cursor, err := col.nearby(query)
+	if err != nil {
+		return nil, err
+	}
+
+	respObj.Fields, respObj.Objects = col.objectsResponse(objs)
+	respObj.Count = int32(len(respObj.Objects))
+	respObj.Cursor = cursor
+	respObj.Elapsed = elapsed(start)
+
+	return respObj, nil
+}
+
+func (m *memoryStore) NearbyGeoJSON(key Object_Collection, query *NearbyQueryObject) (*ScanObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	respObj := &ScanObjectResponseObject{Ok: true}
+
+	col, ok := m.collections[key]
+	if !ok {
+		respObj.Elapsed = elapsed(start)
+		return respObj, nil
+	}
+
+	objs, cursor, err := col.nearby(query)
+	if err != nil {
+		return nil, err
+	}
+
+	respObj.Fields, respObj.Objects = col.geoJSONObjectsResponse(objs)
+	respObj.Count = int32(len(respObj.Objects))
+	respObj.Cursor = cursor
+	respObj.Elapsed = elapsed(start)
+
+	return respObj, nil
+}
+
+func (m *memoryStore) GetGeoJSON(key Object_Collection, objID string) (*GetGeoJSONResponseObject, error) {
+	start := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	col, ok := m.collections[key]
+	if !ok {
+		return &GetGeoJSONResponseObject{Ok: false, Error: "key not found", Elapsed: elapsed(start)}, nil
+	}
+	o, ok := col.objects[objID]
+	if !ok {
+		return &GetGeoJSONResponseObject{Ok: false, Error: "id not found", Elapsed: elapsed(start)}, nil
+	}
+
+	return &GetGeoJSONResponseObject{
+		Ok:      true,
+		Object:  o.geoJSON(),
+		Fields:  o.fieldMap(),
+		Elapsed: elapsed(start)}, nil
+}
+
+func (m *memoryStore) SetGeoJSON(key Object_Collection, objID string, object json.RawMessage, fields LocationObject_Fields) (*SetObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := m.setGeoJSON(key, objID, object, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetObjectResponseObject{Ok: true, Elapsed: elapsed(start)}, nil
+}
+
+func (m *memoryStore) SetGeoJSONIf(key Object_Collection, objID string, expected LocationObject_Fields, object json.RawMessage, fields LocationObject_Fields) (*SetObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// the object may be of any shape
+	var o *memoryObject
+	if col, ok := m.collections[key]; ok {
+		o = col.objects[objID]
+	}
+	matched := o == nil && expected == nil
+	if o != nil && expected != nil {
+		var err error
+		matched, err = o.fieldsEqual(expected)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !matched {
+		return &SetObjectResponseObject{Ok: false, Error: GeoStore_Error_Conflict, Elapsed: elapsed(start)}, nil
+	}
+
+	err := m.setGeoJSON(key, objID, object, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetObjectResponseObject{Ok: true, Elapsed: elapsed(start)}, nil
+}
+
+// setGeoJSON adds or replaces the GeoJSON object and raises the fence events. Caller must hold the write lock
+func (m *memoryStore) setGeoJSON(key Object_Collection, objID string, object json.RawMessage, fields LocationObject_Fields) error {
+	values, err := toFieldValues(fields)
+	if err != nil {
+		return err
+	}
+
+	var geometry GeometryObject
+	err = json.Unmarshal(object, &geometry)
+	if err != nil {
+		return err
+	}
+
+	o := &memoryObject{id: objID, fields: values}
+
+	// a Feature is indexed by its geometry and returned with its properties
+	if geometry.Type == LocationObject_Type_Feature {
+		var feature FeatureObject
+		err = json.Unmarshal(object, &feature)
+		if err != nil {
+			return err
+		}
+		geometry = feature.Geometry
+		if geometry.Type == LocationObject_Type_GeoJSONPoint {
+			o.feature = append(json.RawMessage{}, object...)
+		}
+	}
+
+	if geometry.Type == LocationObject_Type_GeoJSONPoint {
+		var coordinates [2]float64
+		err = json.Unmarshal(geometry.Coordinates, &coordinates)
+		if err != nil {
+			return err
+		}
+		o.lng, o.lat = coordinates[0], coordinates[1]
+	} else {
+		o.shape = append(json.RawMessage{}, object...)
+	}
+	m.collection(key).put(o)
+
+	m.detect(key, o)
+
+	return nil
+}
+
+func (m *memoryStore) Del(key Object_Collection, objID string) (*DelObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if col, ok := m.collections[key]; ok && col.remove(objID) {
+		for _, h := range m.hooks {
+			if h.hook.Key == key {
+				delete(h.inside, objID)
+			}
+		}
+	}
+
+	return &DelObjectResponseObject{Ok: true, Elapsed: elapsed(start)}, nil
+}
+
+func (m *memoryStore) Scan(key Object_Collection, query *ScanQueryObject) (*ScanObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	respObj := &ScanObjectResponseObject{Ok: true}
+
+	col, ok := m.collections[key]
+	if !ok {
+		respObj.Elapsed = elapsed(start)
+		return respObj, nil
+	}
+
+	where, err := newMemoryFilter(query.WhereList, query.WhereInList)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(col.objects))
+	for id, o := range col.objects {
+		if where.match(o) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	// skip the objects of the previous pages, same as CURSOR of Tile38
+	if query.Cursor > 0 {
+		if int(query.Cursor) < len(ids) {
+			ids = ids[query.Cursor:]
+		} else {
+			ids = nil
+		}
+	}
+	if query.Limit > 0 && int(query.Limit) < len(ids) {
+		ids = ids[:query.Limit]
+		respObj.Cursor = query.Cursor + query.Limit
+	}
+
+	objs := make([]*memoryObject, 0, len(ids))
+	for _, id := range ids {
+		objs = append(objs, col.objects[id])
+	}
+	respObj.Fields, respObj.Objects = col.geoJSONObjectsResponse(objs)
+	respObj.Count = int32(len(respObj.Objects))
+	respObj.Elapsed = elapsed(start)
+
+	return respObj, nil
+}
+
+func (m *memoryStore) SearchArea(key Object_Collection, query *AreaQueryObject) (*NearbyObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	respObj := &NearbyObjectResponseObject{Ok: true}
+
+	objs, err := m.searchArea(key, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if col, ok := m.collections[key]; ok {
+		respObj.Fields, respObj.Objects = col.objectsResponse(objs)
+	}
+	respObj.Count = int32(len(respObj.Objects))
+	respObj.Elapsed = elapsed(start)
+
+	return respObj, nil
+}
+
+func (m *memoryStore) SearchAreaGeoJSON(key Object_Collection, query *AreaQueryObject) (*ScanObjectResponseObject, error) {
+	start := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	respObj := &ScanObjectResponseObject{Ok: true}
+
+	objs, err := m.searchArea(key, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if col, ok := m.collections[key]; ok {
+		respObj.Fields, respObj.Objects = col.geoJSONObjectsResponse(objs)
+	}
+	respObj.Count = int32(len(respObj.Objects))
+	respObj.Elapsed = elapsed(start)
+
+	return respObj, nil
+}
+
+// searchArea returns the point objects inside the area ordered by id. Caller must hold the read lock
+func (m *memoryStore) searchArea(key Object_Collection, query *AreaQueryObject) ([]*memoryObject, error) {
+	var polygons []Polygon
+	if query.Bounds == nil {
+		var err error
+		polygons, err = ParsePolygons(query.Object)
+		if err != nil {
+			return nil, err
+		}
+	}
+	where, err := newMemoryFilter(query.WhereList, query.WhereInList)
+	if err != nil {
+		return nil, err
+	}
+
+	col, ok := m.collections[key]
+	if !ok {
+		return nil, nil
+	}
+
+	// for point objects, within and intersects give the same result
+	found := []*memoryObject{}
+	if b := query.Bounds; b != nil {
+		for _, o := range col.candidatesInBounds(b.MinLat, b.MinLng, b.MaxLat, b.MaxLng) {
+			if where.match(o) && b.Contains(common.GeoPoint{Lat: o.lat, Lng: o.lng}) {
+				found = append(found, o)
+			}
+		}
+	}
+	for _, polygon := range polygons {
+		minLat, minLng, maxLat, maxLng := polygon.Bounds()
+		for _, o := range col.candidatesInBounds(minLat, minLng, maxLat, maxLng) {
+			if where.match(o) && polygon.Contains(o.lat, o.lng) {
+				found = append(found, o)
+			}
+		}
+	}
+
+	// ordered by id, same as Tile38, an object inside many polygons is returned once
+	sort.Slice(found, func(i, j int) bool { return found[i].id < found[j].id })
+	objs := []*memoryObject{}
+	for i, o := range found {
+		if i == 0 || found[i-1].id != o.id {
+			objs = append(objs, o)
+		}
+	}
+	if query.Limit > 0 && int(query.Limit) < len(objs) {
+		objs = objs[:query.Limit]
+	}
+
+	return objs, nil
+}
+
+func (m *memoryStore) SetHook(hook *HookFenceObject) (*HookFenceResponseObject, error) {
+	start := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := &memoryHook{hook: *hook, inside: make(map[string]bool)}
+	switch LocationSearch_Type(hook.SearchType) {
+	case LocationSearch_Type_Nearby:
+	case LocationSearch_Type_Within, LocationSearch_Type_Intersects:
+		polygons, err := ParsePolygons(hook.Object)
+		if err != nil {
+			return nil, err
+		}
+		h.polygons = polygons
+	default:
+		return nil, errors.New("Search Type not supported by memory store: " + hook.SearchType)
+	}
+
+	// keep the detected state when the hook is replaced, so enter and exit stay consistent
+	if old, ok := m.hooks[hook.Name]; ok && old.hook.Key == hook.Key {
+		h.inside = old.inside
+	}
+
+	m.hooks[hook.Name] = h
+
+	return &HookFenceResponseObject{Ok: true, Elapsed: elapsed(start)}, nil
+}
+
+func (m *memoryStore) DelHook(hookName string) (*HookFenceResponseObject, error) {
+	start := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.hooks, hookName)
+
+	return &HookFenceResponseObject{Ok: true, Elapsed: elapsed(start)}, nil
+}
+
+// collection returns the collection of the key, creating it if not exist. Caller must hold the write lock
+func (m *memoryStore) collection(key Object_Collection) *memoryCollection {
+	col, ok := m.collections[key]
+	if !ok {
+		col = &memoryCollection{
+			objects: make(map[string]*memoryObject),
+			cells:   make(map[memoryCell]map[string]*memoryObject),
+		}
+		m.collections[key] = col
+	}
+	return col
+}
+
+// detect evaluates the hooks of the key against the updated object and raises fence events.
+// Caller must hold the write lock
+func (m *memoryStore) detect(key Object_Collection, o *memoryObject) {
+	for name, h := range m.hooks {
+		if h.hook.Key != key {
+			continue
+		}
+		if matched, _ := path.Match(h.hook.Match, o.id); !matched {
+			continue
+		}
+		if len(h.hook.CommandList) > 0 && !h.commands(string(LocationCommand_Type_Set)) {
+			continue
+		}
+
+		where, err := newMemoryFilter(h.hook.WhereList, h.hook.WhereInList)
+		if err != nil {
+			log.Printf("Memory hook %s: invalid filter: %v\n", name, err)
+			continue
+		}
+
+		inside := where.match(o) && h.contains(o)
+		wasInside := h.inside[o.id]
+		h.inside[o.id] = inside
+
+		var detect LocationDetect_Type
+		switch {
+		case inside && !wasInside && h.detects(LocationDetect_Type_Enter):
+			detect = LocationDetect_Type_Enter
+		case inside && h.detects(LocationDetect_Type_Inside):
+			detect = LocationDetect_Type_Inside
+		case !inside && wasInside && h.detects(LocationDetect_Type_Exit):
+			detect = LocationDetect_Type_Exit
+		case !inside && h.detects(LocationDetect_Type_Outside):
+			detect = LocationDetect_Type_Outside
+		default:
+			continue
+		}
+
+		event := HookFenceEventObject{
+			Command: string(LocationCommand_Type_Set),
+			Detect:  string(detect),
+			Hook:    name,
+			Key:     key,
+			Time:    time.Now().Format(time.RFC3339Nano),
+			ID:      o.id,
+			Object:  o.response(),
+			Fields:  o.fieldMap(),
+		}
+		if h.polygons == nil {
+			event.Distance = common.Distance(float64(h.hook.Lat), float64(h.hook.Lng), o.lat, o.lng)
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("Memory hook %s: error encoding event: %v\n", name, err)
+			continue
+		}
+
+		// delivered in order outside of the store lock
+		m.events.push(h.hook.Endpoint, payload)
+	}
+}
+
+// contains reports whether the object is inside the fence area of the hook
+func (h *memoryHook) contains(o *memoryObject) bool {
+	if o.shape != nil {
+		return false
+	}
+	if h.polygons != nil {
+		return polygonsContain(h.polygons, o.lat, o.lng)
+	}
+	return common.Distance(float64(h.hook.Lat), float64(h.hook.Lng), o.lat, o.lng) <= float64(h.hook.Radius)
+}
+
+func (h *memoryHook) detects(detect LocationDetect_Type) bool {
+	// no detect list means all detect types, same as Tile38
+	if len(h.hook.DetectList) == 0 {
+		return true
+	}
+	_, ok := h.hook.DetectList[string(detect)]
+	return ok
+}
+
+func (h *memoryHook) commands(command string) bool {
+	_, ok := h.hook.CommandList[command]
+	return ok
+}
+
+// nearby returns the point objects around the point of the query, nearest first,
+// and the cursor of the next page, zero when there are no more objects.
+// A radius of zero searches the whole collection
+func (c *memoryCollection) nearby(query *NearbyQueryObject) ([]*memoryObject, int32, error) {
+	where, err := newMemoryFilter(query.WhereList, query.WhereInList)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	lat, lng := float64(query.Lat), float64(query.Lng)
+	radius := float64(query.Radius)
+
+	var all []*memoryObject
+	if radius > 0 {
+		all = c.candidates(lat, lng, radius)
+	} else {
+		all = c.candidatesInBounds(-90, -180, 90, 180)
+	}
+
+	type candidate struct {
+		obj      *memoryObject
+		distance float64
+	}
+	candidates := []candidate{}
+	for _, o := range all {
+		if !where.match(o) {
+			continue
+		}
+		d := common.Distance(lat, lng, o.lat, o.lng)
+		if radius > 0 && d > radius {
+			continue
+		}
+		candidates = append(candidates, candidate{obj: o, distance: d})
+	}
+
+	// nearest first, same order as Tile38
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance == candidates[j].distance {
+			return candidates[i].obj.id < candidates[j].obj.id
+		}
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	// skip the objects of the previous pages, same as CURSOR of Tile38
+	if query.Cursor > 0 {
+		if int(query.Cursor) < len(candidates) {
+			candidates = candidates[query.Cursor:]
+		} else {
+			candidates = nil
+		}
+	}
+
+	var cursor int32
+	if query.Limit > 0 && int(query.Limit) < len(candidates) {
+		candidates = candidates[:query.Limit]
+		cursor = query.Cursor + query.Limit
+	}
+
+	objs := make([]*memoryObject, len(candidates))
+	for i, cd := range candidates {
+		objs[i] = cd.obj
+	}
+	return objs, cursor, nil
+}
+
+// objectsResponse returns the field names and the point objects in the format of a Tile38 search
+func (c *memoryCollection) objectsResponse(objs []*memoryObject) ([]string, []ObjectsResponseObject) {
+	var fields []string
+	if len(c.fieldNames) > 0 {
+		fields = append([]string{}, c.fieldNames...)
+	}
+	var objects []ObjectsResponseObject
+	for _, o := range objs {
+		objects = append(objects, ObjectsResponseObject{
+			ID:     o.id,
+			Object: o.response(),
+			Fields: o.fieldValues(c.fieldNames),
+		})
+	}
+	return fields, objects
+}
+
+// geoJSONObjectsResponse returns the field names and the objects in GeoJSON format
+func (c *memoryCollection) geoJSONObjectsResponse(objs []*memoryObject) ([]string, []GeoJSONObjectsResponseObject) {
+	var fields []string
+	if len(c.fieldNames) > 0 {
+		fields = append([]string{}, c.fieldNames...)
+	}
+	var objects []GeoJSONObjectsResponseObject
+	for _, o := range objs {
+		objects = append(objects, GeoJSONObjectsResponseObject{
+			ID:     o.id,
+			Object: o.geoJSON(),
+			Fields: o.fieldValues(c.fieldNames),
+		})
+	}
+	return fields, objects
+}
+
+// candidates returns the point objects in the grid cells covering the radius around the point
+func (c *memoryCollection) candidates(lat float64, lng float64, radius float64) []*memoryObject {
+	dLat := radius / meterPerDegree
+	dLng := 360.0
+	if cos := math.Cos(lat * math.Pi / 180); cos > 0.000001 {
+		dLng = dLat / cos
+	}
+	return c.candidatesInBounds(lat-dLat, lng-dLng, lat+dLat, lng+dLng)
+}
+
+// candidatesInBounds returns the point objects in the grid cells covering the bounding box
+func (c *memoryCollection) candidatesInBounds(minLat float64, minLng float64, maxLat float64, maxLng float64) []*memoryObject {
+	lo := newMemoryCell(minLat, minLng)
+	hi := newMemoryCell(maxLat, maxLng)
+
+	// scanning all objects is cheaper when the box covers more cells than there are objects
+	cellCount := (int64(hi.x) - int64(lo.x) + 1) * (int64(hi.y) - int64(lo.y) + 1)
+	objs := []*memoryObject{}
+	if cellCount > int64(len(c.objects)) || maxLng-minLng >= 360 {
+		for _, o := range c.objects {
+			if o.shape == nil {
+				objs = append(objs, o)
+			}
+		}
+		return objs
+	}
+
+	for x := lo.x; x <= hi.x; x++ {
+		for y := lo.y; y <= hi.y; y++ {
+			for _, o := range c.cells[memoryCell{x: x, y: y}] {
+				objs = append(objs, o)
+			}
+		}
+	}
+	return objs
+}
+
+// put adds or replaces the object, fields of the replaced object not passed with the command are kept, same as Tile38
+func (c *memoryCollection) put(o *memoryObject) {
+	if old, ok := c.objects[o.id]; ok {
+		c.removeFromCell(old)
+		for k, v := range old.fields {
+			if _, set := o.fields[k]; !set {
+				o.fields[k] = v
+			}
+		}
+	}
+	c.addFieldNames(o.fields)
+	if o.shape == nil {
+		c.addToCell(o)
+	}
+	c.objects[o.id] = o
+}
+
+// remove deletes the object from the collection
+func (c *memoryCollection) remove(id string) bool {
+	o, ok := c.objects[id]
+	if !ok {
+		return false
+	}
+	c.removeFromCell(o)
+	delete(c.objects, id)
+	return true
+}
+
+func (c *memoryCollection) addToCell(o *memoryObject) {
+	o.cell = newMemoryCell(o.lat, o.lng)
+	cell, ok := c.cells[o.cell]
+	if !ok {
+		cell = make(map[string]*memoryObject)
+		c.cells[o.cell] = cell
+	}
+	cell[o.id] = o
+}
+
+func (c *memoryCollection) removeFromCell(o *memoryObject) {
+	if cell, ok := c.cells[o.cell]; ok {
+		delete(cell, o.id)
+		if len(cell) == 0 {
+			delete(c.cells, o.cell)
+		}
+	}
+}
+
+func (c *memoryCollection) addFieldNames(values map[string]float64) {
+	names := []string{}
+	for k := range values {
+		found := false
+		for _, f := range c.fieldNames {
+			if f == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	c.fieldNames = append(c.fieldNames, names...)
+}
+
+// geoJSON returns the object in GeoJSON format
+func (o *memoryObject) geoJSON() json.RawMessage {
+	if o.shape != nil {
+		return o.shape
+	}
+	if o.feature != nil {
+		return o.feature
+	}
+	b, _ := json.Marshal(o.response())
+	return b
+}
+
+// response returns the point object in GeoJSON format, coordinates are [lng, lat]
+func (o *memoryObject) response() LocationResponseObject {
+	return LocationResponseObject{
+		Type:        "Point",
+		Coordinates: common.GeoPoint{Lat: o.lat, Lng: o.lng}.GeoJSON(),
+	}
+}
+
+func (o *memoryObject) properties() (*LocationObject_Properties, error) {
+	var props LocationObject_Properties
+
+	// round trip through JSON so the fields are decoded the same way as the Tile38 response
+	b, err := json.Marshal(o.fields)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &props)
+	if err != nil {
+		return nil, err
+	}
+	return &props, nil
+}
+
+// fieldMap returns a copy of the fields of the object
+func (o *memoryObject) fieldMap() map[string]float64 {
+	fields := make(map[string]float64, len(o.fields))
+	for k, v := range o.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// fieldValues returns the field values in the order of the collection field names, missing fields are zero
+func (o *memoryObject) fieldValues(names []string) []interface{} {
+	if len(names) == 0 {
+		return nil
+	}
+	values := make([]interface{}, len(names))
+	for i, n := range names {
+		values[i] = o.fields[n]
+	}
+	return values
+}
+
+// fieldsEqual reports whether the fields of the object are equal to the expected ones, missing fields are zero
+func (o *memoryObject) fieldsEqual(expected LocationObject_Fields) (bool, error) {
+	values, err := toFieldValues(expected)
+	if err != nil {
+		return false, err
+	}
+	for k, v := range values {
+		if o.fields[k] != v {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// memoryFilter evaluates the WHERE and WHEREIN conditions against an object
+type memoryFilter struct {
+	where   []memoryRange
+	whereIn []memorySet
+}
+
+type memoryRange struct {
+	field string
+	min   float64
+	max   float64
+}
+
+type memorySet struct {
+	field  string
+	values []float64
+}
+
+func newMemoryFilter(whereList []WhereConditionFieldObject, whereInList []WhereInConditionFieldObject) (*memoryFilter, error) {
+	f := &memoryFilter{}
+	for _, w := range whereList {
+		min, err := toFloat(w.Min)
+		if err != nil {
+			return nil, err
+		}
+		max, err := toFloat(w.Max)
+		if err != nil {
+			return nil, err
+		}
+		f.where = append(f.where, memoryRange{field: w.FieldName, min: min, max: max})
+	}
+	for _, wi := range whereInList {
+		if len(wi.Values) == 0 {
+			continue
+		}
+		s := memorySet{field: wi.FieldName}
+		for _, v := range wi.Values {
+			fv, err := toFloat(v)
+			if err != nil {
+				return nil, err
+			}
+			s.values = append(s.values, fv)
+		}
+		f.whereIn = append(f.whereIn, s)
+	}
+	return f, nil
+}
+
+func (f *memoryFilter) match(o *memoryObject) bool {
+	for _, w := range f.where {
+		v := o.fields[w.field]
+		if v < w.min || v > w.max {
+			return false
+		}
+	}
+	for _, wi := range f.whereIn {
+		v := o.fields[wi.field]
+		found := false
+		for _, s := range wi.values {
+			if v == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func polygonsContain(polygons []Polygon, lat float64, lng float64) bool {
+	for _, polygon := range polygons {
+		if polygon.Contains(lat, lng) {
+			return true
+		}
+	}
+	return false
+}
+
+func toFieldValues(fields LocationObject_Fields) (map[string]float64, error) {
+	values := make(map[string]float64, len(fields))
+	for k, v := range fields {
+		fv, err := toFloat(v)
+		if err != nil {
+			return nil, errors.New("Invalid value of field " + k + ": " + err.Error())
+		}
+		values[k] = fv
+	}
+	return values, nil
+}
+
+// toFloat converts a field or condition value to float64, as Tile38 only supports numeric fields
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case float32:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		switch n {
+		case "-inf":
+			return math.Inf(-1), nil
+		case "+inf", "inf":
+			return math.Inf(1), nil
+		}
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, errors.New("unsupported value type")
+	}
+}
@@ -0,0 +1,420 @@
+package location
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+const testMemoryKey Object_Collection = "test"
+
+func newTestMemoryStore() *memoryStore {
+	return &memoryStore{
+		collections: make(map[Object_Collection]*memoryCollection),
+		hooks:       make(map[string]*memoryHook),
+		events:      newMemoryHookQueue(),
+	}
+}
+
+func setTestObject(t *testing.T, m *memoryStore, id string, lat float64, lng float64, fields LocationObject_Fields) {
+	t.Helper()
+	if _, err := m.Set(testMemoryKey, id, NewLocationObject(common.GeoPoint{Lat: lat, Lng: lng}), fields); err != nil {
+		t.Fatalf("Set(%q) error = %v", id, err)
+	}
+}
+
+func scanIDs(objects []GeoJSONObjectsResponseObject) []string {
+	ids := []string{}
+	for _, o := range objects {
+		ids = append(ids, o.ID)
+	}
+	return ids
+}
+
+func nearbyIDs(objects []ObjectsResponseObject) []string {
+	ids := []string{}
+	for _, o := range objects {
+		ids = append(ids, o.ID)
+	}
+	return ids
+}
+
+func equalIDs(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMemoryStoreScanFilter(t *testing.T) {
+	m := newTestMemoryStore()
+	setTestObject(t, m, "a", 3.10, 101.60, LocationObject_Fields{"driverstatus": 1, "providerid": 10})
+	setTestObject(t, m, "b", 3.11, 101.61, LocationObject_Fields{"driverstatus": 2, "providerid": 10})
+	setTestObject(t, m, "c", 3.12, 101.62, LocationObject_Fields{"driverstatus": 3, "providerid": 10})
+	setTestObject(t, m, "d", 3.13, 101.63, LocationObject_Fields{"driverstatus": 1, "providerid": 20})
+	setTestObject(t, m, "e", 3.14, 101.64, LocationObject_Fields{"providerid": 30})
+
+	tests := []struct {
+		name    string
+		where   []WhereConditionFieldObject
+		whereIn []WhereInConditionFieldObject
+		want    []string
+	}{
+		{"no filter", nil, nil, []string{"a", "b", "c", "d", "e"}},
+		{"where range", []WhereConditionFieldObject{{FieldName: "driverstatus", Min: 1, Max: 2}}, nil, []string{"a", "b", "d"}},
+		{"where open range", []WhereConditionFieldObject{{FieldName: "driverstatus", Min: 2, Max: "+inf"}}, nil, []string{"b", "c"}},
+		{"where missing field is 0", []WhereConditionFieldObject{{FieldName: "driverstatus", Min: 0, Max: 0}}, nil, []string{"e"}},
+		{"wherein", nil, []WhereInConditionFieldObject{{FieldName: "providerid", Values: []interface{}{20, 30}}}, []string{"d", "e"}},
+		{"wherein without values", nil, []WhereInConditionFieldObject{{FieldName: "providerid"}}, []string{"a", "b", "c", "d", "e"}},
+		{
+			"where and wherein",
+			[]WhereConditionFieldObject{{FieldName: "driverstatus", Min: 1, Max: 1}},
+			[]WhereInConditionFieldObject{{FieldName: "providerid", Values: []interface{}{10}}},
+			[]string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := m.Scan(testMemoryKey, &ScanQueryObject{WhereList: tt.where, WhereInList: tt.whereIn})
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if got := scanIDs(res.Objects); !equalIDs(got, tt.want) {
+				t.Errorf("Scan() = %v, want %v", got, tt.want)
+			}
+			if res.Count != int32(len(tt.want)) {
+				t.Errorf("Scan() count = %v, want %v", res.Count, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestMemoryStoreScanCursor(t *testing.T) {
+	m := newTestMemoryStore()
+	for _, id := range []string{"e", "c", "a", "d", "b"} {
+		setTestObject(t, m, id, 3.1, 101.6, LocationObject_Fields{"driverstatus": 1})
+	}
+
+	var pages [][]string
+	var cursor int32
+	for {
+		res, err := m.Scan(testMemoryKey, &ScanQueryObject{Limit: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		pages = append(pages, scanIDs(res.Objects))
+		if res.Cursor == 0 {
+			break
+		}
+		cursor = res.Cursor
+	}
+
+	want := [][]string{{"a", "b"}, {"c", "d"}, {"e"}}
+	if len(pages) != len(want) {
+		t.Fatalf("Scan() pages = %v, want %v", pages, want)
+	}
+	for i := range pages {
+		if !equalIDs(pages[i], want[i]) {
+			t.Errorf("Scan() pages = %v, want %v", pages, want)
+			break
+		}
+	}
+}
+
+func TestMemoryStoreNearby(t *testing.T) {
+	m := newTestMemoryStore()
+	// about 111m per 0.001 degree of latitude
+	setTestObject(t, m, "far", 3.005, 101.6, LocationObject_Fields{"driverstatus": 1})
+	setTestObject(t, m, "near", 3.001, 101.6, LocationObject_Fields{"driverstatus": 1})
+	setTestObject(t, m, "mid", 3.003, 101.6, LocationObject_Fields{"driverstatus": 2})
+	setTestObject(t, m, "out", 3.05, 101.6, LocationObject_Fields{"driverstatus": 1})
+	// same distance as near, ordered by id
+	setTestObject(t, m, "anear", 2.999, 101.6, LocationObject_Fields{"driverstatus": 1})
+
+	tests := []struct {
+		name  string
+		query NearbyQueryObject
+		want  []string
+		next  int32
+	}{
+		{"nearest first", NearbyQueryObject{Lat: 3, Lng: 101.6, Radius: 1000}, []string{"anear", "near", "mid", "far"}, 0},
+		{"no radius", NearbyQueryObject{Lat: 3, Lng: 101.6}, []string{"anear", "near", "mid", "far", "out"}, 0},
+		{"radius", NearbyQueryObject{Lat: 3, Lng: 101.6, Radius: 400}, []string{"anear", "near", "mid"}, 0},
+		{"first page", NearbyQueryObject{Lat: 3, Lng: 101.6, Radius: 1000, Limit: 3}, []string{"anear", "near", "mid"}, 3},
+		{"last page", NearbyQueryObject{Lat: 3, Lng: 101.6, Radius: 1000, Limit: 3, Cursor: 3}, []string{"far"}, 0},
+		{"cursor beyond the end", NearbyQueryObject{Lat: 3, Lng: 101.6, Radius: 1000, Limit: 3, Cursor: 10}, []string{}, 0},
+		{
+			"where",
+			NearbyQueryObject{Lat: 3, Lng: 101.6, Radius: 1000, WhereList: []WhereConditionFieldObject{{FieldName: "driverstatus", Min: 1, Max: 1}}},
+			[]string{"anear", "near", "far"},
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := m.Nearby(testMemoryKey, &tt.query)
+			if err != nil {
+				t.Fatalf("Nearby() error = %v", err)
+			}
+			if got := nearbyIDs(res.Objects); !equalIDs(got, tt.want) {
+				t.Errorf("Nearby() = %v, want %v", got, tt.want)
+			}
+			if res.Cursor != tt.next {
+				t.Errorf("Nearby() cursor = %v, want %v", res.Cursor, tt.next)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreSetIf(t *testing.T) {
+	m := newTestMemoryStore()
+	obj := NewLocationObject(common.GeoPoint{Lat: 3.1, Lng: 101.6})
+
+	tests := []struct {
+		name     string
+		expected LocationObject_Fields
+		fields   LocationObject_Fields
+		ok       bool
+		err      string
+	}{
+		{"create when absent", nil, LocationObject_Fields{"driverstatus": 1, "jobid": 0}, true, ""},
+		{"create when present", nil, LocationObject_Fields{"driverstatus": 2}, false, GeoStore_Error_Conflict},
+		{"expected fields equal", LocationObject_Fields{"driverstatus": 1}, LocationObject_Fields{"driverstatus": 2}, true, ""},
+		{"expected fields differ", LocationObject_Fields{"driverstatus": 1}, LocationObject_Fields{"driverstatus": 3}, false, GeoStore_Error_Conflict},
+		{"expected missing field is 0", LocationObject_Fields{"priority": 0}, LocationObject_Fields{"driverstatus": 3}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := m.SetIf(testMemoryKey, "driver", tt.expected, obj, tt.fields)
+			if err != nil {
+				t.Fatalf("SetIf() error = %v", err)
+			}
+			if res.Ok != tt.ok || res.Error != tt.err {
+				t.Errorf("SetIf() = ok %v err %q, want ok %v err %q", res.Ok, res.Error, tt.ok, tt.err)
+			}
+		})
+	}
+
+	res, err := m.Scan(testMemoryKey, &ScanQueryObject{})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if got := res.FieldMap(0)["driverstatus"]; got != 3 {
+		t.Errorf("driverstatus after SetIf = %v, want 3", got)
+	}
+}
+
+func TestMemoryStoreSetGeoJSONIf(t *testing.T) {
+	m := newTestMemoryStore()
+	square := json.RawMessage(`{"type":"Polygon","coordinates":[[[101.6,3.0],[101.7,3.0],[101.7,3.1],[101.6,3.1],[101.6,3.0]]]}`)
+
+	// concurrent creates of the same object, only one succeeds
+	var wg sync.WaitGroup
+	results := make([]*SetObjectResponseObject, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := m.SetGeoJSONIf(testMemoryKey, "zone", nil, square, LocationObject_Fields{"hookdetect": i})
+			if err != nil {
+				t.Errorf("SetGeoJSONIf() error = %v", err)
+				return
+			}
+			results[i] = res
+		}(i)
+	}
+	wg.Wait()
+
+	created := -1
+	for i, res := range results {
+		if res == nil {
+			continue
+		}
+		if res.Ok {
+			if created >= 0 {
+				t.Fatalf("SetGeoJSONIf() created the object twice, by %v and %v", created, i)
+			}
+			created = i
+		} else if res.Error != GeoStore_Error_Conflict {
+			t.Errorf("SetGeoJSONIf() err = %q, want %q", res.Error, GeoStore_Error_Conflict)
+		}
+	}
+	if created < 0 {
+		t.Fatalf("SetGeoJSONIf() did not create the object")
+	}
+
+	got, err := m.GetGeoJSON(testMemoryKey, "zone")
+	if err != nil {
+		t.Fatalf("GetGeoJSON() error = %v", err)
+	}
+	if got.Fields["hookdetect"] != float64(created) {
+		t.Errorf("hookdetect = %v, want %v of the create", got.Fields["hookdetect"], created)
+	}
+
+	res, err := m.SetGeoJSONIf(testMemoryKey, "zone", LocationObject_Fields{"hookdetect": created}, square, LocationObject_Fields{"hookdetect": 99})
+	if err != nil {
+		t.Fatalf("SetGeoJSONIf() error = %v", err)
+	}
+	if !res.Ok {
+		t.Errorf("SetGeoJSONIf() with equal fields = ok %v err %q, want ok", res.Ok, res.Error)
+	}
+}
+
+func TestMemoryStoreFSetIf(t *testing.T) {
+	m := newTestMemoryStore()
+	setTestObject(t, m, "driver", 3.1, 101.6, LocationObject_Fields{"driverstatus": 1, "jobid": 7})
+
+	tests := []struct {
+		name     string
+		id       string
+		expected LocationObject_Fields
+		fields   LocationObject_Fields
+		ok       bool
+		err      string
+	}{
+		{"id not found", "other", LocationObject_Fields{"driverstatus": 1}, LocationObject_Fields{"driverstatus": 2}, false, "id not found"},
+		{"expected fields differ", "driver", LocationObject_Fields{"driverstatus": 1, "jobid": 8}, LocationObject_Fields{"driverstatus": 2}, false, GeoStore_Error_Conflict},
+		{"expected fields equal", "driver", LocationObject_Fields{"driverstatus": 1, "jobid": 7}, LocationObject_Fields{"driverstatus": 2}, true, ""},
+		{"stale expected fields", "driver", LocationObject_Fields{"driverstatus": 1, "jobid": 7}, LocationObject_Fields{"driverstatus": 4}, false, GeoStore_Error_Conflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := m.FSetIf(testMemoryKey, tt.id, tt.expected, tt.fields)
+			if err != nil {
+				t.Fatalf("FSetIf() error = %v", err)
+			}
+			if res.Ok != tt.ok || res.Error != tt.err {
+				t.Errorf("FSetIf() = ok %v err %q, want ok %v err %q", res.Ok, res.Error, tt.ok, tt.err)
+			}
+		})
+	}
+
+	res, err := m.Scan(testMemoryKey, &ScanQueryObject{})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	fields := res.FieldMap(0)
+	if fields["driverstatus"] != 2 || fields["jobid"] != 7 {
+		t.Errorf("fields after FSetIf = %v, want driverstatus 2 and jobid 7", fields)
+	}
+}
+
+func TestMemoryStoreHookEvents(t *testing.T) {
+	events := make(chan HookFenceEventObject, 100)
+	SetMemoryHookHandler(func(endpoint string, payload []byte) {
+		var event HookFenceEventObject
+		if err := json.Unmarshal(payload, &event); err != nil {
+			t.Errorf("invalid event payload %s: %v", string(payload), err)
+			return
+		}
+		events <- event
+	})
+
+	m := newTestMemoryStore()
+	_, err := m.SetHook(&HookFenceObject{
+		Name:       "fence",
+		Endpoint:   "test",
+		SearchType: string(LocationSearch_Type_Nearby),
+		Key:        testMemoryKey,
+		Match:      "*",
+		Lat:        3,
+		Lng:        101.6,
+		Radius:     500,
+		DetectList: map[string]string{
+			string(LocationDetect_Type_Enter):  string(LocationDetect_Type_Enter),
+			string(LocationDetect_Type_Inside): string(LocationDetect_Type_Inside),
+			string(LocationDetect_Type_Exit):   string(LocationDetect_Type_Exit),
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetHook() error = %v", err)
+	}
+
+	// outside is not detected, the other moves alternate enter, inside and exit
+	moves := []float64{3.01, 3.001, 3.002, 3.02}
+	want := []LocationDetect_Type{LocationDetect_Type_Enter, LocationDetect_Type_Inside, LocationDetect_Type_Exit}
+	for round := 0; round < 50; round++ {
+		for _, lat := range moves {
+			setTestObject(t, m, "driver", lat, 101.6, LocationObject_Fields{"driverstatus": 1})
+		}
+	}
+
+	for round := 0; round < 50; round++ {
+		for _, detect := range want {
+			select {
+			case event := <-events:
+				if event.Detect != string(detect) || event.ID != "driver" || event.Hook != "fence" {
+					t.Fatalf("event %v of round %v = %s %s %s, want %s driver fence", detect, round, event.Detect, event.ID, event.Hook, detect)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("event %v of round %v not delivered", detect, round)
+			}
+		}
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("unexpected event %s of %s", event.Detect, event.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMemoryStoreHookWhere(t *testing.T) {
+	events := make(chan HookFenceEventObject, 10)
+	SetMemoryHookHandler(func(endpoint string, payload []byte) {
+		var event HookFenceEventObject
+		if err := json.Unmarshal(payload, &event); err == nil {
+			events <- event
+		}
+	})
+
+	m := newTestMemoryStore()
+	_, err := m.SetHook(&HookFenceObject{
+		Name:       "available",
+		Endpoint:   "test",
+		SearchType: string(LocationSearch_Type_Nearby),
+		Key:        testMemoryKey,
+		Match:      "*",
+		Lat:        3,
+		Lng:        101.6,
+		Radius:     500,
+		DetectList: map[string]string{string(LocationDetect_Type_Enter): string(LocationDetect_Type_Enter)},
+		WhereList:  []WhereConditionFieldObject{{FieldName: "driverstatus", Min: 1, Max: 1}},
+	})
+	if err != nil {
+		t.Fatalf("SetHook() error = %v", err)
+	}
+
+	// inside the area but filtered out by the WHERE condition, then enters when the status matches
+	setTestObject(t, m, "busy", 3.001, 101.6, LocationObject_Fields{"driverstatus": 2})
+	if _, err := m.FSet(testMemoryKey, "busy", LocationObject_Fields{"driverstatus": 1}); err != nil {
+		t.Fatalf("FSet() error = %v", err)
+	}
+
+	select {
+	case event := <-events:
+		if event.Detect != string(LocationDetect_Type_Enter) || event.ID != "busy" || event.Fields["driverstatus"] != 1 {
+			t.Errorf("event = %s %s %v, want enter busy with driverstatus 1", event.Detect, event.ID, event.Fields)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("enter event not delivered")
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("unexpected event %s of %s", event.Detect, event.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -1,6 +1,7 @@
 package location
 
 import (
+	"encoding/json"
 	"log"
 
 	"github.com/iknowhtml/locationtracker/pkg/common"
@@ -8,9 +9,20 @@ import (
 
 type LocationObject_Fields map[string]interface{}
 
+// LocationObject is the point object to set, coordinates are [lng, lat] as GeoJSON
 type LocationObject struct {
 	Type        LocationObject_Type `json:"type"`
-	Coordinates [2]float32          `json:"coordinates"`
+	Coordinates [2]float64          `json:"coordinates"`
+}
+
+// NewLocationObject returns the point object of the location
+func NewLocationObject(p common.GeoPoint) *LocationObject {
+	return &LocationObject{Type: LocationObject_Type_Point, Coordinates: p.GeoJSON()}
+}
+
+// Point returns the location of the point object
+func (o *LocationObject) Point() common.GeoPoint {
+	return common.GeoPointFromGeoJSON(o.Coordinates)
 }
 
 type LocationObject_Properties struct {
@@ -22,11 +34,38 @@ type LocationObject_Properties struct {
 	ActiveServiceTypeID  int32        `json:"activeservicetypeid"`
 	Priority             int32        `json:"priority"`
 	LastUpdatedTimestamp int64        `json:"lastupdatedtime"`
+	PreviousStatus       DriverStatus `json:"prevdriverstatus,omitempty"` // status before the driver became unreachable
+	Speed                float64      `json:"speed"`                      // km/h, from the last position
+	Bearing              float64      `json:"bearing"`                    // degree clockwise from north
+	Motion               DriverMotion `json:"motion"`
+	LastMovedTimestamp   int64        `json:"lastmovedtime"`
 }
 
+// LocationResponseObject is the point object returned by the store, coordinates are [lng, lat] as GeoJSON
 type LocationResponseObject struct {
 	Type        LocationObject_Type `json:"type"`
-	Coordinates [2]float32          `json:"coordinates"`
+	Coordinates [2]float64          `json:"coordinates"`
+}
+
+// Point returns the location of the point object
+func (o LocationResponseObject) Point() common.GeoPoint {
+	return common.GeoPointFromGeoJSON(o.Coordinates)
+}
+
+// SetObjectRequestObject is one object of a pipelined SET
+type SetObjectRequestObject struct {
+	ObjID  string
+	Object *LocationObject
+	Fields LocationObject_Fields
+}
+
+// DriverLocationUpdateObject is one location update of a batch
+type DriverLocationUpdateObject struct {
+	DriverID  int32
+	Lat       float64
+	Lng       float64
+	Timestamp int64   // time of the position sent by the driver, 0 when not sent
+	Accuracy  float64 // meter, 0 when not sent
 }
 
 type GetObjectResponseObject struct {
@@ -50,8 +89,8 @@ func (o *DriverStatusObject) SetResult(result interface{}) {
 type DriverStatusRequestObject struct {
 	Fleet     string       `json:"fleet,omitempty"`
 	DriverId  int32        `json:"driverId,omitempty"`
-	Lat       float32      `json:"lat,omitempty"`
-	Lng       float32      `json:"lng,omitempty"`
+	Lat       float64      `json:"lat,omitempty"`
+	Lng       float64      `json:"lng,omitempty"`
 	Status    DriverStatus `json:"status,omitempty"`
 	JobId     int64        `json:"jobId,omitempty"`
 	Timestamp int64        `json:"timestamp,omitempty"`
@@ -59,8 +98,8 @@ type DriverStatusRequestObject struct {
 */
 type DriverAvailabilityRequestObject struct {
 	Availability string  `json:"avail,omitempty"`
-	Lat          float32 `json:"lat,omitempty"`
-	Lng          float32 `json:"lng,omitempty"`
+	Lat          float64 `json:"lat,omitempty"`
+	Lng          float64 `json:"lng,omitempty"`
 }
 
 type SetFieldResponseObject struct {
@@ -93,6 +132,19 @@ type NearbyObjectResponseObject struct {
 	Elapsed          string                  `json:"elapsed"`
 }
 
+// FieldMap returns the fields of the object at index i as a map of field name and value
+func (o *NearbyObjectResponseObject) FieldMap(i int) map[string]float64 {
+	fields := make(map[string]float64)
+	for j, fname := range o.Fields {
+		if j < len(o.Objects[i].Fields) {
+			if v, ok := o.Objects[i].Fields[j].(float64); ok {
+				fields[fname] = v
+			}
+		}
+	}
+	return fields
+}
+
 func (o *NearbyObjectResponseObject) RemoveObject(objID string) {
 	if objID != "" && len(o.Objects) > 0 {
 		foundIndex := -1
@@ -110,10 +162,12 @@ func (o *NearbyObjectResponseObject) RemoveObject(objID string) {
 }
 
 type ObjectsMapObject struct {
-	ID       string                    `json:"id,omitempty"`
-	Object   LocationResponseObject    `json:"object,omitempty"`
-	Fields   LocationObject_Properties `json:"fields,omitempty"`
-	Distance float64                   `json:"distance,omitempty"` // in meters
+	ID         string                    `json:"id,omitempty"`
+	Object     LocationResponseObject    `json:"object,omitempty"`
+	Fields     LocationObject_Properties `json:"fields,omitempty"`
+	Distance   float64                   `json:"distance,omitempty"`    // in meters
+	ETASeconds int64                     `json:"eta_seconds,omitempty"` // travel time to the point searched from
+	Tier       int32                     `json:"tier,omitempty"`        // search tier the object is found in, expanding search only
 }
 
 type NearbyObjectMapObject struct {
@@ -123,10 +177,11 @@ type NearbyObjectMapObject struct {
 	Error            string             `json:"err,omitempty"`
 	Count            int32              `json:"count"`
 	Cursor           int32              `json:"cursor,omitempty"`
+	NextCursor       string             `json:"nextcursor,omitempty"` // token of the next page, empty on the last page
 	Elapsed          string             `json:"elapsed"`
 }
 
-func (o *NearbyObjectMapObject) MapFrom(from *NearbyObjectResponseObject, from_lat float32, from_lng float32, filter *NearbyObjectResponseObject) *NearbyObjectMapObject {
+func (o *NearbyObjectMapObject) MapFrom(from *NearbyObjectResponseObject, from_lat float64, from_lng float64, filter *NearbyObjectResponseObject) *NearbyObjectMapObject {
 
 	newObj := &NearbyObjectMapObject{
 		Ok:               from.Ok,
@@ -156,35 +211,8 @@ func (o *NearbyObjectMapObject) MapFrom(from *NearbyObjectResponseObject, from_l
 		objs := make([]ObjectsMapObject, newCount)
 		for i, fo := range from.Objects {
 			log.Printf("NearbyObjectMapObject.MapFrom: Each object: %v\n", fo)
-			to := ObjectsMapObject{
-				ID:       fo.ID,
-				Object:   fo.Object,
-				Fields:   LocationObject_Properties{},
-				Distance: common.Distance(float64(from_lat), float64(from_lng), float64(fo.Object.Coordinates[1]), float64(fo.Object.Coordinates[0]))}
-
-			// loop through all fields
-			for j, fname := range from.Fields {
-				switch fname {
-				case "providerid":
-					to.Fields.ProviderID = int32(fo.Fields[j].(float64))
-				case "driverstatus":
-					to.Fields.Status = DriverStatus(int(fo.Fields[j].(float64)))
-				case "jobid":
-					to.Fields.JobID = int32(fo.Fields[j].(float64))
-				case "activeserviceid":
-					to.Fields.ActiveServiceID = int32(fo.Fields[j].(float64))
-				case "activeservicetypeid":
-					to.Fields.ActiveServiceTypeID = int32(fo.Fields[j].(float64))
-				case "priority":
-					to.Fields.Priority = int32(fo.Fields[j].(float64))
-				case "lastupdatedtime":
-					to.Fields.LastUpdatedTimestamp = int64(fo.Fields[j].(float64))
-				case "driverid":
-					to.Fields.DriverID = int32(fo.Fields[j].(float64))
-				default:
-					log.Panicf("ObjectsMapObject: Unknow field for mapping...")
-				}
-			}
+			to := mapObject(from.Fields, fo)
+			to.Distance = common.GeoPoint{Lat: from_lat, Lng: from_lng}.DistanceTo(fo.Object.Point())
 
 			objs[i] = to
 		}
@@ -194,6 +222,70 @@ func (o *NearbyObjectMapObject) MapFrom(from *NearbyObjectResponseObject, from_l
 	return newObj
 }
 
+// MapWithinFrom maps the result of an area search, which has no reference point to measure distance from
+func (o *NearbyObjectMapObject) MapWithinFrom(from *NearbyObjectResponseObject) *NearbyObjectMapObject {
+
+	newObj := &NearbyObjectMapObject{
+		Ok:               from.Ok,
+		ObjectCollection: from.ObjectCollection,
+		Error:            from.Error,
+		Count:            int32(len(from.Objects)),
+		Cursor:           from.Cursor,
+		Elapsed:          from.Elapsed}
+
+	objs := make([]ObjectsMapObject, len(from.Objects))
+	for i, fo := range from.Objects {
+		objs[i] = mapObject(from.Fields, fo)
+	}
+	newObj.Objects = objs
+
+	return newObj
+}
+
+// mapObject maps the field values of a search result object by the field names of the result
+func mapObject(fields []string, fo ObjectsResponseObject) ObjectsMapObject {
+	to := ObjectsMapObject{
+		ID:     fo.ID,
+		Object: fo.Object,
+		Fields: LocationObject_Properties{}}
+
+	// loop through all fields
+	for j, fname := range fields {
+		switch fname {
+		case "providerid":
+			to.Fields.ProviderID = int32(fo.Fields[j].(float64))
+		case "driverstatus":
+			to.Fields.Status = DriverStatus(int(fo.Fields[j].(float64)))
+		case "jobid":
+			to.Fields.JobID = int32(fo.Fields[j].(float64))
+		case "activeserviceid":
+			to.Fields.ActiveServiceID = int32(fo.Fields[j].(float64))
+		case "activeservicetypeid":
+			to.Fields.ActiveServiceTypeID = int32(fo.Fields[j].(float64))
+		case "priority":
+			to.Fields.Priority = int32(fo.Fields[j].(float64))
+		case "lastupdatedtime":
+			to.Fields.LastUpdatedTimestamp = int64(fo.Fields[j].(float64))
+		case "driverid":
+			to.Fields.DriverID = int32(fo.Fields[j].(float64))
+		case "prevdriverstatus":
+			to.Fields.PreviousStatus = DriverStatus(int(fo.Fields[j].(float64)))
+		case "speed":
+			to.Fields.Speed = fo.Fields[j].(float64)
+		case "bearing":
+			to.Fields.Bearing = fo.Fields[j].(float64)
+		case "motion":
+			to.Fields.Motion = DriverMotion(int(fo.Fields[j].(float64)))
+		case "lastmovedtime":
+			to.Fields.LastMovedTimestamp = int64(fo.Fields[j].(float64))
+		default:
+			log.Panicf("ObjectsMapObject: Unknow field for mapping...")
+		}
+	}
+
+	return to
+}
+
 type NearbyDriversObject struct {
 	NearbyDrivers interface{} `json:"nearbydrivers"`
 }
@@ -202,6 +294,78 @@ func (o *NearbyDriversObject) SetResult(result interface{}) {
 	o.NearbyDrivers = result
 }
 
+type PositionFilterObject struct {
+	PositionFilter interface{} `json:"positionfilter"`
+}
+
+func (o *PositionFilterObject) SetResult(result interface{}) {
+	o.PositionFilter = result
+}
+
+type FleetInfoCacheObject struct {
+	FleetInfoCache interface{} `json:"fleetinfocache"`
+}
+
+func (o *FleetInfoCacheObject) SetResult(result interface{}) {
+	o.FleetInfoCache = result
+}
+
+// DriverMarkerObject is the slim record of a driver for a marker on the map
+type DriverMarkerObject struct {
+	ID            string       `json:"id"`
+	Lat           float64      `json:"lat"`
+	Lng           float64      `json:"lng"`
+	Status        DriverStatus `json:"status"`
+	ProviderID    int32        `json:"provider"`
+	ServiceTypeID int32        `json:"srvtype"` // active service type
+	Bearing       float64      `json:"bearing"`
+	Motion        DriverMotion `json:"motion"`
+}
+
+// DriverMarkersMapObject is the drivers within the bounds, ordered by id
+type DriverMarkersMapObject struct {
+	Ok        bool                 `json:"ok"`
+	Drivers   []DriverMarkerObject `json:"drivers"`
+	Error     string               `json:"err,omitempty"`
+	Count     int32                `json:"count"`
+	Truncated bool                 `json:"truncated"` // more drivers within the bounds than the limit
+	Elapsed   string               `json:"elapsed"`
+}
+
+// MapMarkersFrom appends the drivers of the search result as markers, but the excluded drivers
+func (o *DriverMarkersMapObject) MapMarkersFrom(from *NearbyObjectResponseObject, exclude *SearchExcludeObject) *DriverMarkersMapObject {
+	o.Ok = from.Ok
+	o.Error = from.Error
+	o.Elapsed = from.Elapsed
+	for _, fo := range from.Objects {
+		obj := mapObject(from.Fields, fo)
+		if exclude != nil && exclude.Excludes(obj.Fields) {
+			continue
+		}
+		p := obj.Object.Point()
+		o.Drivers = append(o.Drivers, DriverMarkerObject{
+			ID:            obj.ID,
+			Lat:           p.Lat,
+			Lng:           p.Lng,
+			Status:        obj.Fields.Status,
+			ProviderID:    obj.Fields.ProviderID,
+			ServiceTypeID: obj.Fields.ActiveServiceTypeID,
+			Bearing:       obj.Fields.Bearing,
+			Motion:        obj.Fields.Motion,
+		})
+	}
+	o.Count = int32(len(o.Drivers))
+	return o
+}
+
+type WithinDriversObject struct {
+	WithinDrivers interface{} `json:"withindrivers"`
+}
+
+func (o *WithinDriversObject) SetResult(result interface{}) {
+	o.WithinDrivers = result
+}
+
 type WhereConditionFieldObject struct {
 	FieldName string
 	Min       interface{}
@@ -213,9 +377,145 @@ type WhereInConditionFieldObject struct {
 	Values    []interface{}
 }
 
+type SearchFilterObject struct {
+	Fleet         string // tenant of the drivers, the default tenant when empty
+	ProviderID    int32
+	ServiceTypeID int32
+	ServiceID     int32
+	Availability  NearbySearch_Availability
+	Priority      NearbySearch_Priority
+	Exclude       SearchExcludeObject
+	Motion        SearchMotionObject
+}
+
+// SearchExcludeObject is the drivers and the providers left out of a search,
+// e.g. the drivers who declined the job or the providers blocked by the customer
+type SearchExcludeObject struct {
+	DriverIDs   []int32
+	ProviderIDs []int32
+}
+
+// Excludes returns true when the driver or the provider of the driver is excluded
+func (e *SearchExcludeObject) Excludes(fields LocationObject_Properties) bool {
+	if e == nil {
+		return false
+	}
+	for _, id := range e.DriverIDs {
+		if id == fields.DriverID {
+			return true
+		}
+	}
+	for _, id := range e.ProviderIDs {
+		if id == fields.ProviderID {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty returns true when nothing is excluded
+func (e *SearchExcludeObject) Empty() bool {
+	return e == nil || (len(e.DriverIDs) == 0 && len(e.ProviderIDs) == 0)
+}
+
+// SearchMotionObject keeps the drivers of a search by their motion,
+// e.g. only the idle drivers, or not the drivers speeding away from the pickup
+type SearchMotionObject struct {
+	Motions        []DriverMotion   // any motion when empty
+	MaxSpeedKmh    float64          // any speed when zero
+	MovingAwayFrom *common.GeoPoint // the drivers moving away from the point are removed when set
+}
+
+// appendConditions appends the conditions on the motion and the speed of the drivers
+func (m *SearchMotionObject) appendConditions(
+	whereList []WhereConditionFieldObject,
+	whereInList []WhereInConditionFieldObject) ([]WhereConditionFieldObject, []WhereInConditionFieldObject) {
+
+	if m == nil {
+		return whereList, whereInList
+	}
+	if len(m.Motions) > 0 {
+		var vals []interface{}
+		for _, motion := range m.Motions {
+			vals = append(vals, int32(motion))
+		}
+		whereInList = append(whereInList, WhereInConditionFieldObject{FieldName: "motion", Values: vals})
+	}
+	if m.MaxSpeedKmh > 0 {
+		whereList = append(whereList, WhereConditionFieldObject{FieldName: "speed", Min: "-inf", Max: m.MaxSpeedKmh})
+	}
+	return whereList, whereInList
+}
+
+// Excludes returns true when the driver is moving away from the point of the search
+func (m *SearchMotionObject) Excludes(o *ObjectsMapObject) bool {
+	if m == nil || m.MovingAwayFrom == nil {
+		return false
+	}
+	return o.MovingAwayFrom(*m.MovingAwayFrom)
+}
+
+type NearbyQueryObject struct {
+	Lat         float64
+	Lng         float64
+	Radius      int32
+	Limit       int32
+	Cursor      int32 // position to start from, returned by the previous page
+	WhereList   []WhereConditionFieldObject
+	WhereInList []WhereInConditionFieldObject
+}
+
+type ScanQueryObject struct {
+	Limit       int32
+	Cursor      int32 // position to start from, returned by the previous page
+	WhereList   []WhereConditionFieldObject
+	WhereInList []WhereInConditionFieldObject
+}
+
+type AreaQueryObject struct {
+	SearchType  LocationSearch_Type // within or intersects
+	Object      json.RawMessage     // GeoJSON area
+	Bounds      *common.BoundingBox // instead of the GeoJSON area when set, must not cross the antimeridian
+	Limit       int32
+	WhereList   []WhereConditionFieldObject
+	WhereInList []WhereInConditionFieldObject
+}
+
+type HookFenceObject struct {
+	Name       string
+	Endpoint   string // comma separated endpoints
+	SearchType string
+	Key        Object_Collection
+	Match      string
+	// fence area, a point with radius for nearby, a GeoJSON object for within and intersects
+	Lat         float64
+	Lng         float64
+	Radius      int32
+	Object      json.RawMessage
+	DetectList  map[string]string
+	CommandList map[string]string
+	WhereList   []WhereConditionFieldObject
+	WhereInList []WhereInConditionFieldObject
+}
+
+// HookFenceEventObject is the payload of a fence event sent to the hook endpoints
+type HookFenceEventObject struct {
+	Command string                 `json:"command"`
+	Group   string                 `json:"group,omitempty"`
+	Detect  string                 `json:"detect"`
+	Hook    string                 `json:"hook"`
+	Key     Object_Collection      `json:"key"`
+	Time    string                 `json:"time"`
+	ID      string                 `json:"id"`
+	Object  LocationResponseObject `json:"object"`
+	Fields  map[string]float64     `json:"fields,omitempty"`
+	// distance in meters from the center of a nearby fence
+	Distance float64 `json:"distance,omitempty"`
+}
+
 type StartNearbyFenceRequestObject struct {
-	E_lat               float32 `json:"e_lat,omitempty"`
-	E_lng               float32 `json:"e_lng,omitempty"`
+	E_lat               float64 `json:"e_lat,omitempty"`
+	E_lng               float64 `json:"e_lng,omitempty"`
 	ID                  int32   `json:"id,omitempty"`
 	Availability        string  `json:"avail,omitempty"`
 	SearchServiceID     int32   `json:"srv"`
@@ -225,8 +525,8 @@ type StartNearbyFenceRequestObject struct {
 
 type SetDriverStatusRequestObject struct {
 	Availability string  `json:"avail,omitempty"`
-	Lat          float32 `json:"lat,omitempty"`
-	Lng          float32 `json:"lng,omitempty"`
+	Lat          float64 `json:"lat,omitempty"`
+	Lng          float64 `json:"lng,omitempty"`
 	JobId        int32   `json:"jobid,omitempty"`
 }
 
@@ -240,4 +540,49 @@ type HookFenceResponseObject struct {
 	Elapsed string `json:"elapsed"`
 }
 
+type GetGeoJSONResponseObject struct {
+	Ok               bool               `json:"ok"`
+	ObjectCollection Object_Collection  `json:"collection,omitempty"`
+	Object           json.RawMessage    `json:"object,omitempty"`
+	Fields           map[string]float64 `json:"fields,omitempty"`
+	Error            string             `json:"err,omitempty"`
+	Elapsed          string             `json:"elapsed"`
+}
+
+type GeoJSONObjectsResponseObject struct {
+	ID     string          `json:"id,omitempty"`
+	Object json.RawMessage `json:"object,omitempty"`
+	Fields []interface{}   `json:"fields,omitempty"`
+}
+
+type ScanObjectResponseObject struct {
+	Ok               bool                           `json:"ok"`
+	ObjectCollection Object_Collection              `json:"collection,omitempty"`
+	Fields           []string                       `json:"fields,omitempty"`
+	Objects          []GeoJSONObjectsResponseObject `json:"objects,omitempty"`
+	Error            string                         `json:"err,omitempty"`
+	Count            int32                          `json:"count"`
+	Cursor           int32                          `json:"cursor,omitempty"`
+	Elapsed          string                         `json:"elapsed"`
+}
+
+// FieldMap returns the fields of the object at index i as a map of field name and value
+func (o *ScanObjectResponseObject) FieldMap(i int) map[string]float64 {
+	fields := make(map[string]float64)
+	for j, fname := range o.Fields {
+		if j < len(o.Objects[i].Fields) {
+			if v, ok := o.Objects[i].Fields[j].(float64); ok {
+				fields[fname] = v
+			}
+		}
+	}
+	return fields
+}
+
+type DelObjectResponseObject struct {
+	Ok      bool   `json:"ok"`
+	Error   string `json:"err,omitempty"`
+	Elapsed string `json:"elapsed"`
+}
+
 var DetectList map[string]string
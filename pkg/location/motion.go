@@ -0,0 +1,84 @@
+package location
+
+import (
+	"log"
+	"math"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/config"
+)
+
+// addMotionFields adds the speed, bearing and motion of the driver moved to the point to the fields,
+// derived from the last position and the last updated time of the driver
+func addMotionFields(driverExistObj *GetObjectResponseObject, p common.GeoPoint, timeNow int64, fields LocationObject_Fields) {
+	movingKmh, stoppedSecond := motionThresholds()
+
+	// a new driver has no last position to move from
+	if !driverExistObj.Ok || driverExistObj.Fields.DriverID == 0 || driverExistObj.Fields.LastUpdatedTimestamp <= 0 {
+		fields["speed"] = 0
+		fields["motion"] = int32(DriverMotion_IDLE)
+		fields["lastmovedtime"] = timeNow
+		return
+	}
+
+	last := driverExistObj.Fields
+	elapsed := timeNow - last.LastUpdatedTimestamp
+	// updates are timed in seconds, the motion of the updates within the same second is kept
+	if elapsed <= 0 {
+		return
+	}
+
+	from := driverExistObj.Object.Point()
+	distance := from.DistanceTo(p)
+	speed := distance / float64(elapsed) * 3.6
+	fields["speed"] = speed
+	if distance >= Motion_Bearing_Meter {
+		fields["bearing"] = from.BearingTo(p)
+	}
+
+	lastMoved := last.LastMovedTimestamp
+	if lastMoved <= 0 {
+		lastMoved = last.LastUpdatedTimestamp
+	}
+	switch {
+	case speed >= movingKmh:
+		fields["motion"] = int32(DriverMotion_MOVING)
+		fields["lastmovedtime"] = timeNow
+	case timeNow-lastMoved >= int64(stoppedSecond):
+		fields["motion"] = int32(DriverMotion_STOPPED)
+	default:
+		fields["motion"] = int32(DriverMotion_IDLE)
+	}
+}
+
+// MovingAwayFrom returns true when the driver is moving and heading away from the point,
+// the bearing of the driver is more than 90 degrees off the bearing to the point
+func (o *ObjectsMapObject) MovingAwayFrom(p common.GeoPoint) bool {
+	if o.Fields.Motion != DriverMotion_MOVING {
+		return false
+	}
+	diff := math.Abs(o.Object.Point().BearingTo(p) - o.Fields.Bearing)
+	if diff > 180 {
+		diff = 360 - diff
+	}
+	return diff > 90
+}
+
+func motionThresholds() (float64, int32) {
+	movingKmh := Motion_Moving_Kmh
+	stoppedSecond := Motion_Stopped_Second
+
+	// load system configuration based on environment, singleton pattern
+	configuration, err := config.GetInstance("")
+	if configuration == nil {
+		log.Printf("Failed to load configuration: %v\n", err)
+		return movingKmh, stoppedSecond
+	}
+	if configuration.Locationremoteserver.MotionMovingKmh > 0 {
+		movingKmh = configuration.Locationremoteserver.MotionMovingKmh
+	}
+	if configuration.Locationremoteserver.MotionStoppedSecond > 0 {
+		stoppedSecond = configuration.Locationremoteserver.MotionStoppedSecond
+	}
+	return movingKmh, stoppedSecond
+}
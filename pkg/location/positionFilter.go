@@ -0,0 +1,213 @@
+package location
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/config"
+)
+
+var (
+	ErrPositionOutOfOrder = errors.New("Position is older than the last position of the driver")
+	ErrPositionSpeed      = errors.New("Position implies an impossible speed from the last position of the driver")
+	ErrPositionAccuracy   = errors.New("Position accuracy is too low")
+)
+
+// PositionFilterStatsObject counts the positions filtered since the start of the server
+type PositionFilterStatsObject struct {
+	Accepted           int64 `json:"accepted"`
+	RejectedOutOfOrder int64 `json:"rejectedoutoforder"`
+	RejectedSpeed      int64 `json:"rejectedspeed"`
+	RejectedAccuracy   int64 `json:"rejectedaccuracy"`
+	Restarted          int64 `json:"restarted"` // tracks started again after the max rejections
+	Drivers            int32 `json:"drivers"`   // drivers with a last position
+}
+
+// positionTrack is the last accepted position of a driver
+type positionTrack struct {
+	point     common.GeoPoint // smoothed
+	variance  float64         // meter², of the smoothed point
+	timestamp int64           // time of the position
+	seenTime  int64           // time the position was received
+	rejects   int32           // consecutive rejections
+}
+
+var positionTracks = map[TenantDriverKey]*positionTrack{}
+var positionStats PositionFilterStatsObject
+var positionPruneTime int64
+var positionMu sync.Mutex
+
+// GetPositionFilterStats returns a copy of the counts of the position filter
+func GetPositionFilterStats() *PositionFilterStatsObject {
+	positionMu.Lock()
+	defer positionMu.Unlock()
+
+	stats := positionStats
+	stats.Drivers = int32(len(positionTracks))
+	return &stats
+}
+
+// positionUpdates holds the tracks of the positions accepted by the filter until the positions are set in the store,
+// they become the last positions of the drivers with commitPositions
+type positionUpdates map[TenantDriverKey]*positionTrack
+
+// filterPosition checks the position against the last position of the driver and returns the smoothed position.
+// Positions older than the last one, implying an impossible speed or with a too low accuracy are rejected.
+// The track of an accepted position is kept in the pending updates, the positions of the same driver later
+// in the batch are checked against it
+func filterPosition(filterConfig *config.PositionFilterConfig, tenant *Tenant, u *DriverLocationUpdateObject, timeNow int64, pending positionUpdates) (common.GeoPoint, error) {
+	p := common.GeoPoint{Lat: u.Lat, Lng: u.Lng}
+	if filterConfig == nil || !filterConfig.Enabled {
+		return p, nil
+	}
+
+	timestamp := u.Timestamp
+	if timestamp <= 0 {
+		timestamp = timeNow
+	}
+	accuracy := u.Accuracy
+	if accuracy <= 0 {
+		accuracy = filterConfig.DefaultAccuracyMeter
+	}
+
+	positionMu.Lock()
+	defer positionMu.Unlock()
+
+	prunePositionTracks(timeNow, int64(filterConfig.TrackTTLSecond))
+
+	if filterConfig.MaxAccuracyMeter > 0 && u.Accuracy > filterConfig.MaxAccuracyMeter {
+		positionStats.RejectedAccuracy++
+		return p, ErrPositionAccuracy
+	}
+
+	// the tracks of the drivers with the same id in two tenants are kept apart
+	key := tenant.DriverKey(u.DriverID)
+	track, ok := pending[key]
+	if !ok {
+		track, ok = positionTracks[key]
+		if ok && filterConfig.TrackTTLSecond > 0 && timeNow-track.seenTime > int64(filterConfig.TrackTTLSecond) {
+			ok = false
+		}
+	}
+	if !ok {
+		pending[key] = newPositionTrack(p, accuracy, timestamp, timeNow)
+		positionStats.Accepted++
+		return p, nil
+	}
+
+	err := checkPosition(filterConfig, track, p, timestamp)
+	if err == ErrPositionOutOfOrder {
+		positionStats.RejectedOutOfOrder++
+		return p, err
+	}
+	if err != nil {
+		track.rejects++
+		if filterConfig.MaxRejects <= 0 || track.rejects <= filterConfig.MaxRejects {
+			positionStats.RejectedSpeed++
+			return p, err
+		}
+
+		// the positions keep disagreeing with the last one, the last one is taken as the bad one
+		log.Printf("Position filter: driver %d restarted after %d rejections\n", u.DriverID, track.rejects-1)
+		pending[key] = newPositionTrack(p, accuracy, timestamp, timeNow)
+		positionStats.Restarted++
+		positionStats.Accepted++
+		return p, nil
+	}
+
+	next := *track
+	next.smooth(p, accuracy, timestamp, filterConfig.SmoothingNoiseMps)
+	next.seenTime = timeNow
+	next.rejects = 0
+	pending[key] = &next
+	positionStats.Accepted++
+	return next.point, nil
+}
+
+// commitPositions keeps the tracks of the pending updates as the last positions of their drivers,
+// once the positions are set in the store. A newer track committed meanwhile by another batch is kept
+func commitPositions(pending positionUpdates) {
+	if len(pending) == 0 {
+		return
+	}
+
+	positionMu.Lock()
+	defer positionMu.Unlock()
+
+	for key, track := range pending {
+		if last, ok := positionTracks[key]; ok && last.timestamp > track.timestamp {
+			continue
+		}
+		positionTracks[key] = track
+	}
+}
+
+// checkPosition checks the position is not older than the last one, and the speed from the last one is possible
+func checkPosition(filterConfig *config.PositionFilterConfig, track *positionTrack, p common.GeoPoint, timestamp int64) error {
+	if timestamp < track.timestamp {
+		return ErrPositionOutOfOrder
+	}
+	if filterConfig.MaxSpeedKmh <= 0 {
+		return nil
+	}
+
+	// timestamps are in seconds, positions within the same second are taken as one second apart
+	elapsed := timestamp - track.timestamp
+	if elapsed < 1 {
+		elapsed = 1
+	}
+	speedKmh := track.point.DistanceTo(p) / float64(elapsed) * 3.6
+	if speedKmh > filterConfig.MaxSpeedKmh {
+		return ErrPositionSpeed
+	}
+	return nil
+}
+
+func newPositionTrack(p common.GeoPoint, accuracy float64, timestamp int64, timeNow int64) *positionTrack {
+	return &positionTrack{point: p, variance: accuracy * accuracy, timestamp: timestamp, seenTime: timeNow}
+}
+
+// smooth moves the point of the track toward the position with a Kalman filter.
+// The variance of the track grows with the time since the last position, so a moving driver is followed closely,
+// and the position is trusted more the better its accuracy is
+func (t *positionTrack) smooth(p common.GeoPoint, accuracy float64, timestamp int64, noiseMps float64) {
+	elapsed := timestamp - t.timestamp
+	t.timestamp = timestamp
+	if noiseMps <= 0 || accuracy <= 0 {
+		t.point = p
+		t.variance = accuracy * accuracy
+		return
+	}
+
+	t.variance += float64(elapsed) * noiseMps * noiseMps
+	gain := t.variance / (t.variance + accuracy*accuracy)
+	t.point.Lat += gain * (p.Lat - t.point.Lat)
+	t.point.Lng += gain * (p.Lng - t.point.Lng)
+	t.variance = (1 - gain) * t.variance
+}
+
+// prunePositionTracks removes the tracks not seen within the ttl, at most once per ttl. Caller must hold the lock
+func prunePositionTracks(timeNow int64, ttl int64) {
+	if ttl <= 0 || timeNow-positionPruneTime < ttl {
+		return
+	}
+	positionPruneTime = timeNow
+
+	for key, track := range positionTracks {
+		if timeNow-track.seenTime > ttl {
+			delete(positionTracks, key)
+		}
+	}
+}
+
+func positionFilterConfig() *config.PositionFilterConfig {
+	// load system configuration based on environment, singleton pattern
+	configuration, err := config.GetInstance("")
+	if configuration == nil {
+		log.Printf("Failed to load configuration: %v\n", err)
+		return nil
+	}
+	return &configuration.Positionfilter
+}
@@ -0,0 +1,91 @@
+package location
+
+import (
+	"testing"
+
+	"github.com/iknowhtml/locationtracker/pkg/config"
+)
+
+func TestFilterPositionCommit(t *testing.T) {
+	filterConfig := &config.PositionFilterConfig{Enabled: true, MaxSpeedKmh: 200, MaxRejects: 3}
+	timeNow := int64(1700000000)
+
+	// about 11km north of the start, only reachable from the start within 200 seconds
+	start := DriverLocationUpdateObject{DriverID: 1, Lat: 3.0, Lng: 101.6, Timestamp: timeNow}
+	moved := DriverLocationUpdateObject{DriverID: 1, Lat: 3.1, Lng: 101.6, Timestamp: timeNow + 10}
+	back := DriverLocationUpdateObject{DriverID: 1, Lat: 3.0, Lng: 101.6, Timestamp: timeNow + 20}
+
+	tests := []struct {
+		name   string
+		commit bool
+		err    error // of the position back at the start after the move
+	}{
+		{"move set in the store", true, ErrPositionSpeed},
+		{"move not set in the store", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenant := &Tenant{Name: tt.name}
+
+			pending := positionUpdates{}
+			if _, err := filterPosition(filterConfig, tenant, &start, timeNow, pending); err != nil {
+				t.Fatalf("filterPosition() of the start error = %v", err)
+			}
+			commitPositions(pending)
+
+			// far from the start in the same batch, checked against the start
+			pending = positionUpdates{}
+			if _, err := filterPosition(filterConfig, tenant, &moved, timeNow+10, pending); err != ErrPositionSpeed {
+				t.Fatalf("filterPosition() of the move error = %v, want %v", err, ErrPositionSpeed)
+			}
+			// the move of a slow driver is accepted
+			slow := moved
+			slow.Timestamp = timeNow + 300
+			if _, err := filterPosition(filterConfig, tenant, &slow, timeNow+300, pending); err != nil {
+				t.Fatalf("filterPosition() of the slow move error = %v", err)
+			}
+			if tt.commit {
+				commitPositions(pending)
+			}
+
+			back.Timestamp = timeNow + 310
+			_, err := filterPosition(filterConfig, tenant, &back, timeNow+310, positionUpdates{})
+			if err != tt.err {
+				t.Errorf("filterPosition() of the return error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestFilterPositionBatch(t *testing.T) {
+	filterConfig := &config.PositionFilterConfig{Enabled: true, MaxSpeedKmh: 200}
+	tenant := &Tenant{Name: "batch"}
+	timeNow := int64(1700000000)
+
+	// the positions of a batch are checked against the earlier positions of the batch, before any commit
+	pending := positionUpdates{}
+	first := DriverLocationUpdateObject{DriverID: 2, Lat: 3.0, Lng: 101.6, Timestamp: timeNow}
+	if _, err := filterPosition(filterConfig, tenant, &first, timeNow, pending); err != nil {
+		t.Fatalf("filterPosition() of the first position error = %v", err)
+	}
+	older := DriverLocationUpdateObject{DriverID: 2, Lat: 3.0, Lng: 101.6, Timestamp: timeNow - 5}
+	if _, err := filterPosition(filterConfig, tenant, &older, timeNow, pending); err != ErrPositionOutOfOrder {
+		t.Errorf("filterPosition() of an older position error = %v, want %v", err, ErrPositionOutOfOrder)
+	}
+
+	positionMu.Lock()
+	_, ok := positionTracks[tenant.DriverKey(2)]
+	positionMu.Unlock()
+	if ok {
+		t.Errorf("track of driver 2 kept before commit")
+	}
+
+	commitPositions(pending)
+	positionMu.Lock()
+	track, ok := positionTracks[tenant.DriverKey(2)]
+	positionMu.Unlock()
+	if !ok || track.timestamp != timeNow {
+		t.Errorf("track of driver 2 after commit = %v, want timestamp %v", track, timeNow)
+	}
+}
@@ -1,31 +1,24 @@
 package location
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"log"
+	"strings"
 
-	"github.com/gomodule/redigo/redis"
 	"github.com/iknowhtml/locationtracker/pkg/common"
 )
 
 type LocationService struct {
-	client *LocationClient
+	store GeoStore
 }
 
 func (ls *LocationService) Init(c *LocationClient) error {
-	if c != nil {
-		log.Printf("On connection: %v\n", c)
-		ls.client = c
-	} else {
-		ls.client = new(LocationClient)
-		err := ls.client.Init()
-		if err != nil {
-			return err
-		}
+	store, err := NewGeoStore(c)
+	if err != nil {
+		return err
 	}
+	ls.store = store
 	return nil
 }
 
@@ -33,10 +26,6 @@ func (ls *LocationService) GetObject(
 	key Object_Collection,
 	id int32) (*GetObjectResponseObject, error) {
 
-	var respObj GetObjectResponseObject
-	conn := ls.client.pool.Get()
-	defer conn.Close()
-
 	if key == "" {
 		return nil, errors.New("Key is empty")
 	}
@@ -44,31 +33,49 @@ func (ls *LocationService) GetObject(
 		return nil, errors.New("Id is not set")
 	}
 
-	// generate command args
+	// generate object id
 	objID := GenerateLocationObjectId("", id)
-	commandType := "GET"
-	commandArgs := []interface{}{key, objID, "WITHFIELDS"}
 
-	// send command to redis to update cache
-	log.Printf("Cmd: %s %v\n", commandType, commandArgs)
-	res, err := redis.Bytes(conn.Do(commandType, commandArgs...))
+	respObj, err := ls.store.Get(key, objID)
 	if err != nil {
 		return nil, err
 	}
 	log.Printf("Get Object successful - key: %s, id: %s\n", key, objID)
-	//log.Println(res)
-	// decode response to json object of ley value pair (string, generic)
-	err = json.Unmarshal(res, &respObj)
-	if err != nil {
-		return nil, err
-	}
-
-	log.Println(string(res))
 	log.Println(respObj)
 
 	// Return the struct object of result
 	respObj.ObjectCollection = key
-	return &respObj, nil
+	return respObj, nil
+}
+
+// GetObjects gets the objects in one pipelined round trip, responses are in the order of the ids
+func (ls *LocationService) GetObjects(
+	key Object_Collection,
+	ids []int32) ([]*GetObjectResponseObject, error) {
+
+	if key == "" {
+		return nil, errors.New("Key is empty")
+	}
+
+	// generate object ids
+	objIDs := make([]string, len(ids))
+	for i, id := range ids {
+		if id == 0 {
+			return nil, errors.New("Id is not set")
+		}
+		objIDs[i] = GenerateLocationObjectId("", id)
+	}
+
+	respObjs, err := ls.store.GetMany(key, objIDs)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Get Objects successful - key: %s, count: %d\n", key, len(objIDs))
+
+	for _, respObj := range respObjs {
+		respObj.ObjectCollection = key
+	}
+	return respObjs, nil
 }
 
 func (ls *LocationService) SetField(
@@ -76,10 +83,6 @@ func (ls *LocationService) SetField(
 	id int32,
 	fields LocationObject_Fields) (*SetFieldResponseObject, error) {
 
-	var respObj SetFieldResponseObject
-	conn := ls.client.pool.Get()
-	defer conn.Close()
-
 	if key == "" {
 		return nil, errors.New("Key is empty")
 	}
@@ -90,32 +93,18 @@ func (ls *LocationService) SetField(
 		return nil, errors.New("Field object is nil")
 	}
 
-	// generate command args
+	// generate object id
 	objID := GenerateLocationObjectId("", id)
-	commandType := "FSET"
-	commandArgs := []interface{}{key, objID}
-	for k, v := range fields {
-		commandArgs = append(commandArgs, k)
-		commandArgs = append(commandArgs, v)
-	}
-	// send command to redis to update cache
-	log.Printf("Cmd: %s %v\n", commandType, commandArgs)
-	res, err := redis.Bytes(conn.Do(commandType, commandArgs...))
+
+	respObj, err := ls.store.FSet(key, objID, fields)
 	if err != nil {
 		return nil, err
 	}
 	log.Printf("Set Field successful - key: %s, id: %s\n", key, objID)
-	//log.Println(res)
-	// decode response to json object of ley value pair (string, generic)
-	err = json.Unmarshal(res, &respObj)
-	if err != nil {
-		return nil, err
-	}
-
-	log.Println(string(res))
 	log.Println(respObj)
+
 	// Return value is the integer count of how many fields actually changed their values
-	return &respObj, nil
+	return respObj, nil
 }
 
 func (ls *LocationService) SetObject(
@@ -124,10 +113,6 @@ func (ls *LocationService) SetObject(
 	obj *LocationObject,
 	fields LocationObject_Fields) (*SetObjectResponseObject, error) {
 
-	var respObj SetObjectResponseObject
-	conn := ls.client.pool.Get()
-	defer conn.Close()
-
 	if key == "" {
 		return nil, errors.New("Key is empty")
 	}
@@ -138,55 +123,116 @@ func (ls *LocationService) SetObject(
 		return nil, errors.New("Location object is nil")
 	}
 
-	// convert LocationObject to JSON
-	jsonObj, err := json.Marshal(obj)
+	// generate object id
+	objID := GenerateLocationObjectId("", id)
+
+	respObj, err := ls.store.Set(key, objID, obj, fields)
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("JSON object created: %s\n", string(jsonObj))
+	log.Printf("Set Object successful - key: %s, id: %s\n", key, objID)
+	log.Println(respObj)
 
-	// send command to redis to update cache
-	objID := GenerateLocationObjectId("", id)
-	commandType := "SET"
-	commandArgs := []interface{}{key, objID}
-	if fields != nil && len(fields) > 0 {
-		for k, v := range fields {
-			commandArgs = append(commandArgs, "FIELD")
-			commandArgs = append(commandArgs, k)
-			commandArgs = append(commandArgs, v)
-		}
+	// return string 'OK'
+	return respObj, nil
+}
+
+// SetFieldIf sets the fields only when the current fields are equal to the expected ones,
+// ok is false with GeoStore_Error_Conflict when they are not
+func (ls *LocationService) SetFieldIf(
+	key Object_Collection,
+	id int32,
+	expected LocationObject_Fields,
+	fields LocationObject_Fields) (*SetFieldResponseObject, error) {
+
+	if key == "" {
+		return nil, errors.New("Key is empty")
+	}
+	if id == 0 {
+		return nil, errors.New("Id is not set")
+	}
+	if expected == nil || fields == nil {
+		return nil, errors.New("Field object is nil")
 	}
-	// Pass by POINT
-	commandArgs = append(commandArgs, "POINT")
-	commandArgs = append(commandArgs, obj.Coordinates[0]) // lat
-	commandArgs = append(commandArgs, obj.Coordinates[1]) // lng
 
-	log.Printf("Cmd: %s %v\n", commandType, commandArgs)
-	res, err := redis.Bytes(conn.Do(commandType, commandArgs...))
+	// generate object id
+	objID := GenerateLocationObjectId("", id)
+
+	respObj, err := ls.store.FSetIf(key, objID, expected, fields)
 	if err != nil {
 		return nil, err
 	}
-	log.Println(string(res))
-	log.Printf("Set Object successful - key: %s, id: %s\n", key, objID)
+	log.Printf("Set Field If - key: %s, id: %s, expected: %v\n", key, objID, expected)
+	log.Println(respObj)
+
+	return respObj, nil
+}
+
+// SetObjectIf sets the object only when the current fields are equal to the expected ones,
+// or only when the object does not exist when expected is nil
+func (ls *LocationService) SetObjectIf(
+	key Object_Collection,
+	id int32,
+	expected LocationObject_Fields,
+	obj *LocationObject,
+	fields LocationObject_Fields) (*SetObjectResponseObject, error) {
 
-	// decode response to json object of ley value pair (string, generic)
-	err = json.Unmarshal(res, &respObj)
+	if key == "" {
+		return nil, errors.New("Key is empty")
+	}
+	if id == 0 {
+		return nil, errors.New("Id is not set")
+	}
+	if obj == nil {
+		return nil, errors.New("Location object is nil")
+	}
+
+	// generate object id
+	objID := GenerateLocationObjectId("", id)
+
+	respObj, err := ls.store.SetIf(key, objID, expected, obj, fields)
 	if err != nil {
 		return nil, err
 	}
-
+	log.Printf("Set Object If - key: %s, id: %s, expected: %v\n", key, objID, expected)
 	log.Println(respObj)
 
-	// return string 'OK'
-	return &respObj, nil
+	return respObj, nil
+}
+
+// SetObjects sets the point objects in one pipelined round trip, responses are in the order of the objects
+func (ls *LocationService) SetObjects(
+	key Object_Collection,
+	objs []SetObjectRequestObject) ([]*SetObjectResponseObject, error) {
+
+	if key == "" {
+		return nil, errors.New("Key is empty")
+	}
+	for _, o := range objs {
+		if o.ObjID == "" {
+			return nil, errors.New("Id is not set")
+		}
+		if o.Object == nil {
+			return nil, errors.New("Location object is nil")
+		}
+	}
+
+	respObjs, err := ls.store.SetMany(key, objs)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Set Objects successful - key: %s, count: %d\n", key, len(objs))
+
+	return respObjs, nil
 }
 
 func (ls *LocationService) NearbyObject(
 	key Object_Collection,
-	point_lat float32,
-	point_lng float32,
+	point_lat float64,
+	point_lng float64,
 	radius int32,
 	limit int32,
+	cursor int32,
 	whereList []WhereConditionFieldObject,
 	whereInList []WhereInConditionFieldObject) (*NearbyObjectResponseObject, error) {
 
@@ -199,61 +245,29 @@ func (ls *LocationService) NearbyObject(
 	if limit <= 0 {
 		return nil, errors.New("Search limit is not set")
 	}
-
-	var respObj NearbyObjectResponseObject
-	conn := ls.client.pool.Get()
-	defer conn.Close()
-
-	// send command to redis to update cache
-	commandType := "NEARBY"
-	commandArgs := []interface{}{key}
-	if limit > 0 {
-		commandArgs = append(commandArgs, "LIMIT")
-		commandArgs = append(commandArgs, limit)
+	if cursor < 0 {
+		return nil, errors.New("Search cursor is invalid")
 	}
 
-	for _, c := range whereList {
-		commandArgs = append(commandArgs, "WHERE")
-		commandArgs = append(commandArgs, c.FieldName)
-		commandArgs = append(commandArgs, c.Min)
-		commandArgs = append(commandArgs, c.Max)
+	query := &NearbyQueryObject{
+		Lat:         point_lat,
+		Lng:         point_lng,
+		Radius:      radius,
+		Limit:       limit,
+		Cursor:      cursor,
+		WhereList:   whereList,
+		WhereInList: whereInList,
 	}
 
-	for _, wi := range whereInList {
-		commandArgs = append(commandArgs, "WHEREIN")
-		if count := len(wi.Values); count > 0 {
-			commandArgs = append(commandArgs, wi.FieldName)
-			commandArgs = append(commandArgs, count)
-			for _, wiv := range wi.Values {
-				commandArgs = append(commandArgs, wiv)
-			}
-		}
-	}
-
-	commandArgs = append(commandArgs, "POINT")
-	commandArgs = append(commandArgs, point_lat)
-	commandArgs = append(commandArgs, point_lng)
-	commandArgs = append(commandArgs, radius)
-
-	log.Printf("Cmd: %s %v\n", commandType, commandArgs)
-	res, err := redis.Bytes(conn.Do(commandType, commandArgs...))
+	respObj, err := ls.store.Nearby(key, query)
 	if err != nil {
 		return nil, err
 	}
-
-	log.Println(string(res))
 	log.Printf("Search Nearby successful - key: %s, lat: %f, lng: %f, radius: %d\n", key, point_lat, point_lng, radius)
-
-	// decode response to json object of ley value pair (string, generic)
-	err = json.Unmarshal(res, &respObj)
-	if err != nil {
-		return nil, err
-	}
-
 	log.Println(respObj)
 
 	respObj.ObjectCollection = key
-	return &respObj, nil
+	return respObj, nil
 }
 
 func (ls *LocationService) SetHookSearchFence(
@@ -262,8 +276,8 @@ func (ls *LocationService) SetHookSearchFence(
 	searchType string,
 	key Object_Collection,
 	id int32,
-	point_lat float32,
-	point_lng float32,
+	point_lat float64,
+	point_lng float64,
 	radius int32,
 	detectList map[string]string,
 	commandList map[string]string,
@@ -286,154 +300,416 @@ func (ls *LocationService) SetHookSearchFence(
 		return nil, errors.New("Search radius is not set")
 	}
 
-	// send command to redis to update cache
-	var respObj HookFenceResponseObject
+	objID := GenerateLocationObjectId("", id)
+	hook := &HookFenceObject{
+		Name:        GenerateHookName(topicName, string(key), objID),
+		Endpoint:    GenerateHookEndpoint(endPoints, topicName),
+		SearchType:  searchType,
+		Key:         key,
+		Match:       objID,
+		Lat:         point_lat,
+		Lng:         point_lng,
+		Radius:      radius,
+		DetectList:  detectList,
+		CommandList: commandList,
+		WhereList:   whereList,
+		WhereInList: whereInList,
+	}
+
+	respObj, err := ls.store.SetHook(hook)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Set Hook Search Fence successful - key: %s, id: %s\n", key, objID)
+	log.Println(respObj)
+
+	return respObj, nil
+}
+
+func (ls *LocationService) DelHookSearchFence(
+	topicName string,
+	searchType string,
+	key Object_Collection,
+	id int32) (*HookFenceResponseObject, error) {
+
+	if topicName == "" {
+		return nil, errors.New("Hook Name is empty")
+	}
+	if searchType == "" {
+		return nil, errors.New("Search Type is empty")
+	}
+	if key == "" {
+		return nil, errors.New("Key is empty")
+	}
+
 	objID := GenerateLocationObjectId("", id)
 	hookName := GenerateHookName(topicName, string(key), objID)
-	conn := ls.client.pool.Get()
-	defer conn.Close()
 
-	commandType := "SETHOOK"
-	commandArgs := []interface{}{hookName}
+	respObj, err := ls.store.DelHook(hookName)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Delete Hook Search Fence successful - key: %s, id: %s\n", key, objID)
+	log.Println(respObj)
 
-	epStr := ""
-	for i, ep := range endPoints {
-		if i == 0 {
-			epStr = common.Concate(epStr, ep, "/", topicName)
-		} else {
-			epStr = common.Concate(epStr, ",", ep, "/", topicName)
-		}
+	return respObj, nil
+}
+
+func (ls *LocationService) GetGeoJSONObject(
+	key Object_Collection,
+	objID string) (*GetGeoJSONResponseObject, error) {
+
+	if key == "" {
+		return nil, errors.New("Key is empty")
 	}
-	commandArgs = append(commandArgs, epStr)
+	if objID == "" {
+		return nil, errors.New("Id is not set")
+	}
+
+	respObj, err := ls.store.GetGeoJSON(key, objID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Get GeoJSON Object successful - key: %s, id: %s\n", key, objID)
+	log.Println(respObj)
 
-	commandArgs = append(commandArgs, searchType)
-	commandArgs = append(commandArgs, key)
-	commandArgs = append(commandArgs, "MATCH")
-	commandArgs = append(commandArgs, objID)
+	respObj.ObjectCollection = key
+	return respObj, nil
+}
+
+func (ls *LocationService) SetGeoJSONObject(
+	key Object_Collection,
+	objID string,
+	object json.RawMessage,
+	fields LocationObject_Fields) (*SetObjectResponseObject, error) {
 
-	for _, c := range whereList {
-		commandArgs = append(commandArgs, "WHERE")
-		commandArgs = append(commandArgs, c.FieldName)
-		commandArgs = append(commandArgs, c.Min)
-		commandArgs = append(commandArgs, c.Max)
+	if key == "" {
+		return nil, errors.New("Key is empty")
+	}
+	if objID == "" {
+		return nil, errors.New("Id is not set")
+	}
+	if len(object) == 0 {
+		return nil, errors.New("GeoJSON object is empty")
 	}
 
-	for _, wi := range whereInList {
-		commandArgs = append(commandArgs, "WHEREIN")
-		if count := len(wi.Values); count > 0 {
-			commandArgs = append(commandArgs, wi.FieldName)
-			commandArgs = append(commandArgs, count)
-			for _, wiv := range wi.Values {
-				commandArgs = append(commandArgs, wiv)
-			}
-		}
+	respObj, err := ls.store.SetGeoJSON(key, objID, object, fields)
+	if err != nil {
+		return nil, err
 	}
+	log.Printf("Set GeoJSON Object successful - key: %s, id: %s\n", key, objID)
+	log.Println(respObj)
 
-	commandArgs = append(commandArgs, "FENCE")
+	return respObj, nil
+}
 
-	detectLen := len(detectList)
-	if detectList != nil && detectLen > 0 {
-		count := 0
-		detectBuffer := new(bytes.Buffer)
-		for _, d := range detectList {
-			if count == 0 {
-				fmt.Fprintf(detectBuffer, "%s", d)
-			} else {
-				fmt.Fprintf(detectBuffer, ",%s", d)
-			}
-			count++
-		}
-		commandArgs = append(commandArgs, "DETECT")
-		commandArgs = append(commandArgs, detectBuffer.String())
-	}
-
-	commandLen := len(commandList)
-	if commandList != nil && commandLen > 0 {
-		count := 0
-		commandBuffer := new(bytes.Buffer)
-		for _, d := range commandList {
-			if count == 0 {
-				fmt.Fprintf(commandBuffer, "%s", d)
-			} else {
-				fmt.Fprintf(commandBuffer, ",%s", d)
-			}
-			count++
-		}
-		commandArgs = append(commandArgs, "COMMANDS")
-		commandArgs = append(commandArgs, commandBuffer.String())
+// SetGeoJSONObjectIf sets the GeoJSON object only when the current fields are equal to the expected ones,
+// or only when the object does not exist when expected is nil
+func (ls *LocationService) SetGeoJSONObjectIf(
+	key Object_Collection,
+	objID string,
+	expected LocationObject_Fields,
+	object json.RawMessage,
+	fields LocationObject_Fields) (*SetObjectResponseObject, error) {
+
+	if key == "" {
+		return nil, errors.New("Key is empty")
+	}
+	if objID == "" {
+		return nil, errors.New("Id is not set")
+	}
+	if len(object) == 0 {
+		return nil, errors.New("GeoJSON object is empty")
 	}
 
-	commandArgs = append(commandArgs, "POINT")
-	commandArgs = append(commandArgs, point_lat)
-	commandArgs = append(commandArgs, point_lng)
-	commandArgs = append(commandArgs, radius)
+	respObj, err := ls.store.SetGeoJSONIf(key, objID, expected, object, fields)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Set GeoJSON Object If - key: %s, id: %s, expected: %v\n", key, objID, expected)
+	log.Println(respObj)
+
+	return respObj, nil
+}
+
+func (ls *LocationService) DelObject(
+	key Object_Collection,
+	objID string) (*DelObjectResponseObject, error) {
 
-	log.Printf("Cmd: %s %v\n", commandType, commandArgs)
+	if key == "" {
+		return nil, errors.New("Key is empty")
+	}
+	if objID == "" {
+		return nil, errors.New("Id is not set")
+	}
 
-	res, err := redis.Bytes(conn.Do(commandType, commandArgs...))
+	respObj, err := ls.store.Del(key, objID)
 	if err != nil {
 		return nil, err
 	}
+	log.Printf("Delete Object successful - key: %s, id: %s\n", key, objID)
+	log.Println(respObj)
 
-	log.Println(string(res))
-	log.Printf("Set Hook Search Fence successful - key: %s, id: %s\n", key, objID)
+	return respObj, nil
+}
 
-	// decode response to json object of ley value pair (string, generic)
-	err = json.Unmarshal(res, &respObj)
+func (ls *LocationService) ScanObject(
+	key Object_Collection,
+	limit int32,
+	cursor int32,
+	whereList []WhereConditionFieldObject,
+	whereInList []WhereInConditionFieldObject) (*ScanObjectResponseObject, error) {
+
+	if key == "" {
+		return nil, errors.New("Key is empty")
+	}
+	if limit <= 0 {
+		return nil, errors.New("Scan limit is not set")
+	}
+
+	query := &ScanQueryObject{
+		Limit:       limit,
+		Cursor:      cursor,
+		WhereList:   whereList,
+		WhereInList: whereInList,
+	}
+
+	respObj, err := ls.store.Scan(key, query)
 	if err != nil {
 		return nil, err
 	}
+	log.Printf("Scan successful - key: %s, count: %d\n", key, respObj.Count)
+
+	respObj.ObjectCollection = key
+	return respObj, nil
+}
 
+func (ls *LocationService) SearchAreaObject(
+	key Object_Collection,
+	searchType LocationSearch_Type,
+	area json.RawMessage,
+	limit int32,
+	whereList []WhereConditionFieldObject,
+	whereInList []WhereInConditionFieldObject) (*NearbyObjectResponseObject, error) {
+
+	if key == "" {
+		return nil, errors.New("Key is empty")
+	}
+	if searchType != LocationSearch_Type_Within && searchType != LocationSearch_Type_Intersects {
+		return nil, errors.New("Search Type must be within or intersects")
+	}
+	if len(area) == 0 {
+		return nil, errors.New("Search area is empty")
+	}
+	if limit <= 0 {
+		return nil, errors.New("Search limit is not set")
+	}
+
+	query := &AreaQueryObject{
+		SearchType:  searchType,
+		Object:      area,
+		Limit:       limit,
+		WhereList:   whereList,
+		WhereInList: whereInList,
+	}
+
+	respObj, err := ls.store.SearchArea(key, query)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Search %s successful - key: %s, count: %d\n", searchType, key, respObj.Count)
 	log.Println(respObj)
 
-	return &respObj, nil
+	respObj.ObjectCollection = key
+	return respObj, nil
 }
 
-func (ls *LocationService) DelHookSearchFence(
-	topicName string,
-	searchType string,
+// SearchBoundsObject searches the objects within the bounding box, which must not cross the antimeridian
+func (ls *LocationService) SearchBoundsObject(
 	key Object_Collection,
-	id int32) (*HookFenceResponseObject, error) {
+	bounds common.BoundingBox,
+	limit int32,
+	whereList []WhereConditionFieldObject,
+	whereInList []WhereInConditionFieldObject) (*NearbyObjectResponseObject, error) {
 
-	if topicName == "" {
-		return nil, errors.New("Hook Name is empty")
+	if key == "" {
+		return nil, errors.New("Key is empty")
 	}
-	if searchType == "" {
-		return nil, errors.New("Search Type is empty")
+	if bounds.MinLat >= bounds.MaxLat || bounds.MinLng >= bounds.MaxLng {
+		return nil, errors.New("Search bounds are empty or cross the antimeridian")
+	}
+	if limit <= 0 {
+		return nil, errors.New("Search limit is not set")
 	}
+
+	query := &AreaQueryObject{
+		SearchType:  LocationSearch_Type_Within,
+		Bounds:      &bounds,
+		Limit:       limit,
+		WhereList:   whereList,
+		WhereInList: whereInList,
+	}
+
+	respObj, err := ls.store.SearchArea(key, query)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Search bounds successful - key: %s, count: %d\n", key, respObj.Count)
+
+	respObj.ObjectCollection = key
+	return respObj, nil
+}
+
+// NearbyGeoJSONObject searches objects around the point and returns them in GeoJSON format,
+// a radius of zero returns the nearest objects without distance limit
+func (ls *LocationService) NearbyGeoJSONObject(
+	key Object_Collection,
+	point_lat float64,
+	point_lng float64,
+	radius int32,
+	limit int32,
+	whereList []WhereConditionFieldObject,
+	whereInList []WhereInConditionFieldObject) (*ScanObjectResponseObject, error) {
+
 	if key == "" {
 		return nil, errors.New("Key is empty")
 	}
+	if radius < 0 {
+		return nil, errors.New("Search radius is negative")
+	}
+	if limit <= 0 {
+		return nil, errors.New("Search limit is not set")
+	}
 
-	// send command to redis to update cache
-	var respObj HookFenceResponseObject
-	objID := GenerateLocationObjectId("", id)
-	hookName := GenerateHookName(topicName, string(key), objID)
-	conn := ls.client.pool.Get()
-	defer conn.Close()
+	query := &NearbyQueryObject{
+		Lat:         point_lat,
+		Lng:         point_lng,
+		Radius:      radius,
+		Limit:       limit,
+		WhereList:   whereList,
+		WhereInList: whereInList,
+	}
 
-	commandType := "DELHOOK"
-	commandArgs := []interface{}{hookName}
+	respObj, err := ls.store.NearbyGeoJSON(key, query)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Search Nearby GeoJSON successful - key: %s, lat: %f, lng: %f, radius: %d, count: %d\n", key, point_lat, point_lng, radius, respObj.Count)
 
-	log.Printf("Cmd: %s %v\n", commandType, commandArgs)
+	respObj.ObjectCollection = key
+	return respObj, nil
+}
+
+// SearchAreaGeoJSONObject searches objects within or intersecting the area and returns them in GeoJSON format
+func (ls *LocationService) SearchAreaGeoJSONObject(
+	key Object_Collection,
+	searchType LocationSearch_Type,
+	area json.RawMessage,
+	limit int32,
+	whereList []WhereConditionFieldObject,
+	whereInList []WhereInConditionFieldObject) (*ScanObjectResponseObject, error) {
+
+	if key == "" {
+		return nil, errors.New("Key is empty")
+	}
+	if searchType != LocationSearch_Type_Within && searchType != LocationSearch_Type_Intersects {
+		return nil, errors.New("Search Type must be within or intersects")
+	}
+	if len(area) == 0 {
+		return nil, errors.New("Search area is empty")
+	}
+	if limit <= 0 {
+		return nil, errors.New("Search limit is not set")
+	}
+
+	query := &AreaQueryObject{
+		SearchType:  searchType,
+		Object:      area,
+		Limit:       limit,
+		WhereList:   whereList,
+		WhereInList: whereInList,
+	}
 
-	res, err := redis.Bytes(conn.Do(commandType, commandArgs...))
+	respObj, err := ls.store.SearchAreaGeoJSON(key, query)
 	if err != nil {
 		return nil, err
 	}
+	log.Printf("Search %s GeoJSON successful - key: %s, count: %d\n", searchType, key, respObj.Count)
 
-	log.Println(string(res))
-	log.Printf("Delete Hook Search Fence successful - key: %s, id: %s\n", key, objID)
+	respObj.ObjectCollection = key
+	return respObj, nil
+}
+
+// SetHookAreaFence sets a hook on a GeoJSON area, objects of the collection matching the pattern are detected
+func (ls *LocationService) SetHookAreaFence(
+	endPoints []string,
+	topicName string,
+	hookName string,
+	searchType LocationSearch_Type,
+	key Object_Collection,
+	match string,
+	area json.RawMessage,
+	detectList map[string]string,
+	commandList map[string]string,
+	whereList []WhereConditionFieldObject,
+	whereInList []WhereInConditionFieldObject) (*HookFenceResponseObject, error) {
+
+	if topicName == "" || hookName == "" {
+		return nil, errors.New("Hook Name is empty")
+	}
+	if endPoints == nil || len(endPoints) == 0 {
+		return nil, errors.New("Hook Endpoint is empty")
+	}
+	if searchType != LocationSearch_Type_Within && searchType != LocationSearch_Type_Intersects {
+		return nil, errors.New("Search Type must be within or intersects")
+	}
+	if key == "" {
+		return nil, errors.New("Key is empty")
+	}
+	if len(area) == 0 {
+		return nil, errors.New("Fence area is empty")
+	}
+	if match == "" {
+		match = GenerateLocationObjectId("", 0)
+	}
+
+	hook := &HookFenceObject{
+		Name:        hookName,
+		Endpoint:    GenerateHookEndpoint(endPoints, topicName),
+		SearchType:  string(searchType),
+		Key:         key,
+		Match:       match,
+		Object:      area,
+		DetectList:  detectList,
+		CommandList: commandList,
+		WhereList:   whereList,
+		WhereInList: whereInList,
+	}
 
-	// decode response to json object of ley value pair (string, generic)
-	err = json.Unmarshal(res, &respObj)
+	respObj, err := ls.store.SetHook(hook)
 	if err != nil {
 		return nil, err
 	}
+	log.Printf("Set Hook Area Fence successful - hook: %s, key: %s\n", hookName, key)
+	log.Println(respObj)
+
+	return respObj, nil
+}
+
+func (ls *LocationService) DelHook(hookName string) (*HookFenceResponseObject, error) {
+
+	if hookName == "" {
+		return nil, errors.New("Hook Name is empty")
+	}
 
+	respObj, err := ls.store.DelHook(hookName)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Delete Hook successful - hook: %s\n", hookName)
 	log.Println(respObj)
 
-	return &respObj, nil
+	return respObj, nil
 }
 
 func GenerateLocationObjectId(objType string, id int32) string {
@@ -449,6 +725,24 @@ func GenerateLocationObjectId(objType string, id int32) string {
 	}
 }
 
+// HookTopic returns the topic of the driver hooks of the hook type, also the prefix of their hook names
+func HookTopic(hookType Hook_Type) string {
+	return common.Concate(HookPrefix, strings.Title(string(hookType)))
+}
+
 func GenerateHookName(topic string, key string, objId string) string {
 	return topic + "_" + key + "_" + objId
 }
+
+// GenerateHookEndpoint joins the endpoints with the topic name, separated by comma
+func GenerateHookEndpoint(endPoints []string, topic string) string {
+	epStr := ""
+	for i, ep := range endPoints {
+		if i == 0 {
+			epStr = common.Concate(epStr, ep, "/", topic)
+		} else {
+			epStr = common.Concate(epStr, ",", ep, "/", topic)
+		}
+	}
+	return epStr
+}
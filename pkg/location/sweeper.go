@@ -0,0 +1,197 @@
+package location
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/config"
+	"github.com/iknowhtml/locationtracker/pkg/history"
+)
+
+// max number of stale drivers set unreachable per sweep, the others are set on the next sweep
+const Sweep_Limit int32 = 1000
+
+// DriverEvent_Type
+type DriverEvent_Type string
+
+const (
+	DriverEvent_Type_Unreachable DriverEvent_Type = "unreachable"
+)
+
+// DriverEventObject is raised when the status of a driver is changed by the system
+type DriverEventObject struct {
+	Event                DriverEvent_Type       `json:"event"`
+	Fleet                string                 `json:"fleet,omitempty"` // tenant of the driver
+	DriverID             int32                  `json:"driverid"`
+	ProviderID           int32                  `json:"providerid"`
+	JobID                int32                  `json:"jobid,omitempty"`
+	Status               DriverStatus           `json:"driverstatus"`
+	PreviousStatus       DriverStatus           `json:"prevdriverstatus"`
+	Object               LocationResponseObject `json:"object"`
+	LastUpdatedTimestamp int64                  `json:"lastupdatedtime"`
+	Time                 int64                  `json:"time"`
+}
+
+// DriverEventHandler receives the driver events, it is called outside of any lock
+type DriverEventHandler func(event *DriverEventObject)
+
+var driverEventHandlers []DriverEventHandler
+var driverEventMu sync.RWMutex
+
+// AddDriverEventHandler registers a handler of the driver events, every handler receives every event
+func AddDriverEventHandler(handler DriverEventHandler) {
+	if handler == nil {
+		return
+	}
+	driverEventMu.Lock()
+	defer driverEventMu.Unlock()
+	driverEventHandlers = append(driverEventHandlers, handler)
+}
+
+func emitDriverEvent(event *DriverEventObject) {
+	log.Printf("Driver event: %s driver %d\n", event.Event, event.DriverID)
+
+	driverEventMu.RLock()
+	handlers := driverEventHandlers
+	driverEventMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// Set drivers of the tenant of the controller without update within the heartbeat timeout to unreachable.
+// Only drivers which can become unreachable in the driver lifecycle are set, their status is kept to be restored on their next update.
+// Returns the number of drivers set unreachable
+func (lc *LocationController) SweepStaleDrivers() (int, error) {
+	timeout := heartbeatTimeout()
+	if timeout <= 0 {
+		return 0, nil
+	}
+
+	timeNow := time.Now().Unix()
+	whereList := []WhereConditionFieldObject{
+		WhereConditionFieldObject{FieldName: "lastupdatedtime", Min: "-inf", Max: timeNow - int64(timeout) - 1},
+	}
+	whereInList := []WhereInConditionFieldObject{
+		WhereInConditionFieldObject{FieldName: "driverstatus", Values: driverStatusesTo(DriverStatus_UNREACHABLE)},
+	}
+
+	res, err := lc.locationService.ScanObject(lc.tenant.Collection, Sweep_Limit, 0, whereList, whereInList)
+	if err != nil {
+		return 0, err
+	}
+
+	// check if ok is false
+	if res.Ok == false {
+		return 0, errors.New(res.Error)
+	}
+
+	count := 0
+	for i, o := range res.Objects {
+		fields := res.FieldMap(i)
+		driverID, err := strconv.ParseInt(o.ID, 10, 32)
+		if err != nil {
+			log.Printf("Sweep: invalid driver id %s\n", o.ID)
+			continue
+		}
+
+		var object LocationResponseObject
+		err = json.Unmarshal(o.Object, &object)
+		if err != nil {
+			log.Printf("Sweep: invalid object of driver %d: %v\n", driverID, err)
+			continue
+		}
+
+		prevStatus := DriverStatus(fields["driverstatus"])
+		// the driver is set only as scanned, a driver updated since the scan is no longer stale
+		expected := LocationObject_Fields{
+			"driverstatus":    int32(prevStatus),
+			"jobid":           int32(fields["jobid"]),
+			"lastupdatedtime": int64(fields["lastupdatedtime"]),
+		}
+		setRes, err := lc.locationService.SetFieldIf(lc.tenant.Collection, int32(driverID), expected, LocationObject_Fields{
+			"driverstatus":     int32(DriverStatus_UNREACHABLE),
+			"prevdriverstatus": int32(prevStatus),
+		})
+		if err != nil {
+			log.Printf("Sweep: failed to set driver %d unreachable: %v\n", driverID, err)
+			continue
+		}
+		if setRes.Ok == false && setRes.Error == GeoStore_Error_Conflict {
+			log.Printf("Sweep: driver %d was updated since the scan, skipped\n", driverID)
+			continue
+		}
+		if setRes.Ok == false {
+			log.Printf("Sweep: failed to set driver %d unreachable: %s\n", driverID, setRes.Error)
+			continue
+		}
+		count++
+
+		jobID := int32(fields["jobid"])
+		p := object.Point()
+		lc.historyController.RecordDriverPoint(
+			int32(driverID), p.Lat, p.Lng, int32(DriverStatus_UNREACHABLE), jobID, history.History_Event_Unreachable, timeNow)
+
+		emitDriverEvent(&DriverEventObject{
+			Event:                DriverEvent_Type_Unreachable,
+			Fleet:                lc.tenant.Name,
+			DriverID:             int32(driverID),
+			ProviderID:           int32(fields["providerid"]),
+			JobID:                jobID,
+			Status:               DriverStatus_UNREACHABLE,
+			PreviousStatus:       prevStatus,
+			Object:               object,
+			LastUpdatedTimestamp: int64(fields["lastupdatedtime"]),
+			Time:                 timeNow,
+		})
+	}
+
+	log.Printf("Sweep: %d stale drivers of %s set unreachable\n", count, lc.tenant.Collection)
+	return count, nil
+}
+
+// RunStaleDriverSweeper sweeps stale drivers of every tenant every heartbeat sweep interval until stop is closed
+func RunStaleDriverSweeper(stop <-chan struct{}) {
+	// load system configuration based on environment, singleton pattern
+	configuration, err := config.GetInstance("")
+	if configuration == nil {
+		log.Printf("Sweep: failed to load configuration: %v\n", err)
+		return
+	}
+
+	interval := configuration.Locationremoteserver.HeartbeatSweepSecond
+	if configuration.Locationremoteserver.HeartbeatTimeoutSecond <= 0 || interval <= 0 {
+		log.Printf("Sweep: stale driver sweeper is disabled\n")
+		return
+	}
+
+	log.Printf("Sweep: running stale driver sweeper every %d seconds\n", interval)
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			lc := new(LocationController)
+			err := lc.Init()
+			if err != nil {
+				log.Printf("Sweep: %v\n", err)
+				continue
+			}
+			for _, tenant := range GetTenants() {
+				tenantLC, _ := lc.ForTenant(tenant.Name)
+				_, err = tenantLC.SweepStaleDrivers()
+				if err != nil {
+					log.Printf("Sweep: %s: %v\n", tenant.Collection, err)
+				}
+			}
+		case <-stop:
+			log.Printf("Sweep: stale driver sweeper stopped\n")
+			return
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package location
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/iknowhtml/locationtracker/pkg/config"
+)
+
+var (
+	ErrTenantUnknown = errors.New("Fleet (tenant) is unknown")
+)
+
+// Tenant is a business line or a brand with drivers of its own. The drivers of the tenant are kept in the
+// collection of the tenant, so the searches and the hooks of a tenant never see the drivers of another
+type Tenant struct {
+	Name       string            `json:"name"`
+	ID         int32             `json:"id"`         // kept in the fields of the jobs, 0 for the default tenant
+	Collection Object_Collection `json:"collection"` // fleet for the default tenant, fleet:name for the others
+	// search tier radii, 0 when no range is defined
+	SearchTier1Meter int32 `json:"searchtier1meter"`
+	SearchTier2Meter int32 `json:"searchtier2meter"`
+	SearchTier3Meter int32 `json:"searchtier3meter"`
+}
+
+// TenantDriverKey identifies a driver across the tenants, drivers of two tenants may have the same id
+type TenantDriverKey struct {
+	Tenant   string // name of the tenant
+	DriverID int32
+}
+
+// DriverKey returns the key of the driver of the tenant
+func (t *Tenant) DriverKey(driverID int32) TenantDriverKey {
+	return TenantDriverKey{Tenant: t.Name, DriverID: driverID}
+}
+
+// SearchTiers returns the radius of the search tiers from the nearest
+func (t *Tenant) SearchTiers() []int32 {
+	return []int32{t.SearchTier1Meter, t.SearchTier2Meter, t.SearchTier3Meter}
+}
+
+var tenants []*Tenant
+var tenantsOnce sync.Once
+
+// GetTenants returns the default tenant first, then the configured tenants
+func GetTenants() []*Tenant {
+	tenantsOnce.Do(func() {
+		// load system configuration based on environment, singleton pattern
+		configuration, err := config.GetInstance("")
+		if configuration == nil {
+			log.Printf("Tenant: failed to load configuration, only the default tenant is used: %v\n", err)
+			tenants = []*Tenant{&Tenant{Collection: Object_Collection_Fleet}}
+			return
+		}
+		tenants = newTenants(&configuration.Locationremoteserver, configuration.Tenants)
+	})
+	return tenants
+}
+
+// GetTenant returns the tenant of the fleet name, the default tenant for an empty name
+func GetTenant(name string) (*Tenant, error) {
+	all := GetTenants()
+	if name == "" {
+		return all[0], nil
+	}
+	for _, t := range all {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, ErrTenantUnknown
+}
+
+// GetTenantByID returns the tenant of the id kept in the fields of a job
+func GetTenantByID(id int32) (*Tenant, error) {
+	for _, t := range GetTenants() {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return nil, ErrTenantUnknown
+}
+
+// newTenants returns the default tenant and the valid tenants of the configuration, the invalid tenants are left out
+func newTenants(rs *config.LocationRemoteServerConfig, configured []config.TenantConfig) []*Tenant {
+	all := []*Tenant{&Tenant{
+		Name:             rs.DefaultTenant,
+		Collection:       Object_Collection_Fleet,
+		SearchTier1Meter: rs.SearchTier1Meter,
+		SearchTier2Meter: rs.SearchTier2Meter,
+		SearchTier3Meter: rs.SearchTier3Meter,
+	}}
+
+	names := map[string]bool{rs.DefaultTenant: true}
+	ids := map[int32]bool{0: true}
+	for _, tc := range configured {
+		switch {
+		case tc.Name == "" || strings.ContainsAny(tc.Name, " :"):
+			log.Printf("Tenant: %q is left out, the name is invalid\n", tc.Name)
+			continue
+		case names[tc.Name]:
+			log.Printf("Tenant: %s is left out, the name is already used\n", tc.Name)
+			continue
+		case tc.ID <= 0 || ids[tc.ID]:
+			log.Printf("Tenant: %s is left out, the id %d is invalid or already used\n", tc.Name, tc.ID)
+			continue
+		}
+		names[tc.Name] = true
+		ids[tc.ID] = true
+
+		t := &Tenant{
+			Name:             tc.Name,
+			ID:               tc.ID,
+			Collection:       Object_Collection(string(Object_Collection_Fleet) + ":" + tc.Name),
+			SearchTier1Meter: tc.SearchTier1Meter,
+			SearchTier2Meter: tc.SearchTier2Meter,
+			SearchTier3Meter: tc.SearchTier3Meter,
+		}
+		// the tiers of locationremoteserver when the tenant sets none
+		if t.SearchTier1Meter == 0 && t.SearchTier2Meter == 0 && t.SearchTier3Meter == 0 {
+			t.SearchTier1Meter, t.SearchTier2Meter, t.SearchTier3Meter = rs.SearchTier1Meter, rs.SearchTier2Meter, rs.SearchTier3Meter
+		}
+		all = append(all, t)
+	}
+	return all
+}
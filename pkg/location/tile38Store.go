@@ -0,0 +1,537 @@
+package location
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// tile38Store implements GeoStore by sending commands to a Tile38 server over the redis protocol
+type tile38Store struct {
+	client *LocationClient
+}
+
+func newTile38Store(c *LocationClient) (*tile38Store, error) {
+	if c != nil {
+		log.Printf("On connection: %v\n", c)
+		return &tile38Store{client: c}, nil
+	}
+
+	client := new(LocationClient)
+	err := client.Init()
+	if err != nil {
+		return nil, err
+	}
+	return &tile38Store{client: client}, nil
+}
+
+// do sends the command to Tile38 and decodes the JSON reply into respObj
+func (ts *tile38Store) do(respObj interface{}, commandType string, commandArgs ...interface{}) error {
+	conn := ts.client.pool.Get()
+	defer conn.Close()
+
+	log.Printf("Cmd: %s %v\n", commandType, commandArgs)
+	res, err := redis.Bytes(conn.Do(commandType, commandArgs...))
+	if err != nil {
+		return err
+	}
+	log.Println(string(res))
+
+	// decode response to json object of ley value pair (string, generic)
+	return json.Unmarshal(res, respObj)
+}
+
+// doPipeline sends the commands in one round trip and decodes each JSON reply into the respObj of the
+// same index. All replies are read even on error, so the connection goes back to the pool clean
+func (ts *tile38Store) doPipeline(respObjs []interface{}, commandType string, commandArgsList [][]interface{}) error {
+	conn := ts.client.pool.Get()
+	defer conn.Close()
+
+	log.Printf("Pipeline: %s x %d\n", commandType, len(commandArgsList))
+	for _, commandArgs := range commandArgsList {
+		err := conn.Send(commandType, commandArgs...)
+		if err != nil {
+			return err
+		}
+	}
+	err := conn.Flush()
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for i := range commandArgsList {
+		res, err := redis.Bytes(conn.Receive())
+		if err == nil {
+			err = json.Unmarshal(res, respObjs[i])
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (ts *tile38Store) Get(key Object_Collection, objID string) (*GetObjectResponseObject, error) {
+	var respObj GetObjectResponseObject
+
+	err := ts.do(&respObj, "GET", key, objID, "WITHFIELDS")
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+func (ts *tile38Store) Set(key Object_Collection, objID string, obj *LocationObject, fields LocationObject_Fields) (*SetObjectResponseObject, error) {
+	var respObj SetObjectResponseObject
+
+	err := ts.do(&respObj, "SET", setArgs(key, objID, obj, fields)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+func (ts *tile38Store) GetMany(key Object_Collection, objIDs []string) ([]*GetObjectResponseObject, error) {
+	respObjs := make([]*GetObjectResponseObject, len(objIDs))
+	replies := make([]interface{}, len(objIDs))
+	commandArgsList := make([][]interface{}, len(objIDs))
+	for i, objID := range objIDs {
+		respObjs[i] = new(GetObjectResponseObject)
+		replies[i] = respObjs[i]
+		commandArgsList[i] = []interface{}{key, objID, "WITHFIELDS"}
+	}
+
+	err := ts.doPipeline(replies, "GET", commandArgsList)
+	if err != nil {
+		return nil, err
+	}
+
+	return respObjs, nil
+}
+
+func (ts *tile38Store) SetMany(key Object_Collection, objs []SetObjectRequestObject) ([]*SetObjectResponseObject, error) {
+	respObjs := make([]*SetObjectResponseObject, len(objs))
+	replies := make([]interface{}, len(objs))
+	commandArgsList := make([][]interface{}, len(objs))
+	for i, o := range objs {
+		respObjs[i] = new(SetObjectResponseObject)
+		replies[i] = respObjs[i]
+		commandArgsList[i] = setArgs(key, o.ObjID, o.Object, o.Fields)
+	}
+
+	err := ts.doPipeline(replies, "SET", commandArgsList)
+	if err != nil {
+		return nil, err
+	}
+
+	return respObjs, nil
+}
+
+func (ts *tile38Store) FSet(key Object_Collection, objID string, fields LocationObject_Fields) (*SetFieldResponseObject, error) {
+	var respObj SetFieldResponseObject
+
+	commandArgs := []interface{}{key, objID}
+	for k, v := range fields {
+		commandArgs = append(commandArgs, k)
+		commandArgs = append(commandArgs, v)
+	}
+
+	err := ts.do(&respObj, "FSET", commandArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+// compareAndSetScript runs the command on the object only when its fields are equal to the expected ones.
+// ARGV: id, count of expected fields (-1 when the object must not exist), expected name and value pairs,
+// command, command args. Scripts run atomically, no other command runs between the check and the update
+const compareAndSetScript = `
+local obj = tile38.pcall('GET', KEYS[1], ARGV[1], 'WITHFIELDS')
+local exists = type(obj) == 'table' and obj.err == nil and obj[1] ~= nil
+local n = tonumber(ARGV[2])
+if n < 0 then
+	if exists then return 'conflict' end
+	n = 0
+else
+	if not exists then return 'notfound' end
+	local current = {}
+	if type(obj[2]) == 'table' then
+		for i = 1, #obj[2], 2 do current[obj[2][i]] = tonumber(obj[2][i + 1]) end
+	end
+	for i = 1, n do
+		if (current[ARGV[1 + i * 2]] or 0) ~= tonumber(ARGV[2 + i * 2]) then return 'conflict' end
+	end
+end
+local args = {}
+for i = 4 + n * 2, #ARGV do args[#args + 1] = ARGV[i] end
+tile38.call(ARGV[3 + n * 2], KEYS[1], ARGV[1], unpack(args))
+return 'ok'
+`
+
+type evalResponseObject struct {
+	Ok      bool   `json:"ok"`
+	Result  string `json:"result,omitempty"`
+	Error   string `json:"err,omitempty"`
+	Elapsed string `json:"elapsed"`
+}
+
+// compareAndSet runs the command with EVAL, see compareAndSetScript. The result is ok, conflict or notfound
+func (ts *tile38Store) compareAndSet(key Object_Collection, objID string, expected LocationObject_Fields, command string, commandArgs []interface{}) (*evalResponseObject, error) {
+	var respObj evalResponseObject
+
+	evalArgs := []interface{}{compareAndSetScript, 1, key, objID}
+	if expected == nil {
+		evalArgs = append(evalArgs, -1)
+	} else {
+		evalArgs = append(evalArgs, len(expected))
+		for k, v := range expected {
+			evalArgs = append(evalArgs, k)
+			evalArgs = append(evalArgs, v)
+		}
+	}
+	evalArgs = append(evalArgs, command)
+	evalArgs = append(evalArgs, commandArgs...)
+
+	err := ts.do(&respObj, "EVAL", evalArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+func (ts *tile38Store) FSetIf(key Object_Collection, objID string, expected LocationObject_Fields, fields LocationObject_Fields) (*SetFieldResponseObject, error) {
+	if expected == nil {
+		return nil, errors.New("Expected fields are not set")
+	}
+
+	commandArgs := []interface{}{}
+	for k, v := range fields {
+		commandArgs = append(commandArgs, k)
+		commandArgs = append(commandArgs, v)
+	}
+
+	evalObj, err := ts.compareAndSet(key, objID, expected, "FSET", commandArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	respObj := &SetFieldResponseObject{Ok: evalObj.Ok, Error: evalObj.Error, Elapsed: evalObj.Elapsed}
+	if evalObj.Ok {
+		respObj.Ok, respObj.Error = compareAndSetResult(evalObj.Result)
+	}
+	return respObj, nil
+}
+
+func (ts *tile38Store) SetIf(key Object_Collection, objID string, expected LocationObject_Fields, obj *LocationObject, fields LocationObject_Fields) (*SetObjectResponseObject, error) {
+	// the key and the id are passed by the script
+	commandArgs := setArgs(key, objID, obj, fields)[2:]
+
+	evalObj, err := ts.compareAndSet(key, objID, expected, "SET", commandArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	respObj := &SetObjectResponseObject{Ok: evalObj.Ok, Error: evalObj.Error, Elapsed: evalObj.Elapsed}
+	if evalObj.Ok {
+		respObj.Ok, respObj.Error = compareAndSetResult(evalObj.Result)
+	}
+	return respObj, nil
+}
+
+// compareAndSetResult maps the result of compareAndSetScript to the ok and error of the response
+func compareAndSetResult(result string) (bool, string) {
+	switch result {
+	case "ok":
+		return true, ""
+	case "notfound":
+		return false, "id not found"
+	default:
+		return false, GeoStore_Error_Conflict
+	}
+}
+
+func (ts *tile38Store) Nearby(key Object_Collection, query *NearbyQueryObject) (*NearbyObjectResponseObject, error) {
+	var respObj NearbyObjectResponseObject
+
+	err := ts.do(&respObj, "NEARBY", nearbyArgs(key, query)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+func (ts *tile38Store) NearbyGeoJSON(key Object_Collection, query *NearbyQueryObject) (*ScanObjectResponseObject, error) {
+	var respObj ScanObjectResponseObject
+
+	err := ts.do(&respObj, "NEARBY", nearbyArgs(key, query)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+func (ts *tile38Store) GetGeoJSON(key Object_Collection, objID string) (*GetGeoJSONResponseObject, error) {
+	var respObj GetGeoJSONResponseObject
+
+	err := ts.do(&respObj, "GET", key, objID, "WITHFIELDS")
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+func (ts *tile38Store) SetGeoJSON(key Object_Collection, objID string, object json.RawMessage, fields LocationObject_Fields) (*SetObjectResponseObject, error) {
+	var respObj SetObjectResponseObject
+
+	err := ts.do(&respObj, "SET", setGeoJSONArgs(key, objID, object, fields)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+func (ts *tile38Store) SetGeoJSONIf(key Object_Collection, objID string, expected LocationObject_Fields, object json.RawMessage, fields LocationObject_Fields) (*SetObjectResponseObject, error) {
+	// the key and the id are passed by the script
+	commandArgs := setGeoJSONArgs(key, objID, object, fields)[2:]
+
+	evalObj, err := ts.compareAndSet(key, objID, expected, "SET", commandArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	respObj := &SetObjectResponseObject{Ok: evalObj.Ok, Error: evalObj.Error, Elapsed: evalObj.Elapsed}
+	if evalObj.Ok {
+		respObj.Ok, respObj.Error = compareAndSetResult(evalObj.Result)
+	}
+	return respObj, nil
+}
+
+func (ts *tile38Store) Del(key Object_Collection, objID string) (*DelObjectResponseObject, error) {
+	var respObj DelObjectResponseObject
+
+	err := ts.do(&respObj, "DEL", key, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+func (ts *tile38Store) Scan(key Object_Collection, query *ScanQueryObject) (*ScanObjectResponseObject, error) {
+	var respObj ScanObjectResponseObject
+
+	commandArgs := []interface{}{key, "LIMIT", query.Limit}
+	if query.Cursor > 0 {
+		commandArgs = append(commandArgs, "CURSOR")
+		commandArgs = append(commandArgs, query.Cursor)
+	}
+	commandArgs = appendWhereArgs(commandArgs, query.WhereList, query.WhereInList)
+
+	err := ts.do(&respObj, "SCAN", commandArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+func (ts *tile38Store) SearchArea(key Object_Collection, query *AreaQueryObject) (*NearbyObjectResponseObject, error) {
+	var respObj NearbyObjectResponseObject
+
+	err := ts.do(&respObj, strings.ToUpper(string(query.SearchType)), areaArgs(key, query)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+func (ts *tile38Store) SearchAreaGeoJSON(key Object_Collection, query *AreaQueryObject) (*ScanObjectResponseObject, error) {
+	var respObj ScanObjectResponseObject
+
+	err := ts.do(&respObj, strings.ToUpper(string(query.SearchType)), areaArgs(key, query)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+func (ts *tile38Store) SetHook(hook *HookFenceObject) (*HookFenceResponseObject, error) {
+	var respObj HookFenceResponseObject
+
+	commandArgs := []interface{}{hook.Name, hook.Endpoint, hook.SearchType, hook.Key, "MATCH", hook.Match}
+	if hook.SearchType == string(LocationSearch_Type_Nearby) {
+		// add the distance to the fence center to the events
+		commandArgs = append(commandArgs, "DISTANCE")
+	}
+	commandArgs = appendWhereArgs(commandArgs, hook.WhereList, hook.WhereInList)
+
+	commandArgs = append(commandArgs, "FENCE")
+
+	if len(hook.DetectList) > 0 {
+		commandArgs = append(commandArgs, "DETECT")
+		commandArgs = append(commandArgs, joinList(hook.DetectList))
+	}
+
+	if len(hook.CommandList) > 0 {
+		commandArgs = append(commandArgs, "COMMANDS")
+		commandArgs = append(commandArgs, joinList(hook.CommandList))
+	}
+
+	if hook.SearchType == string(LocationSearch_Type_Nearby) {
+		commandArgs = append(commandArgs, "POINT")
+		commandArgs = append(commandArgs, hook.Lat)
+		commandArgs = append(commandArgs, hook.Lng)
+		commandArgs = append(commandArgs, hook.Radius)
+	} else {
+		commandArgs = append(commandArgs, "OBJECT")
+		commandArgs = append(commandArgs, string(hook.Object))
+	}
+
+	err := ts.do(&respObj, "SETHOOK", commandArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+func (ts *tile38Store) DelHook(hookName string) (*HookFenceResponseObject, error) {
+	var respObj HookFenceResponseObject
+
+	err := ts.do(&respObj, "DELHOOK", hookName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respObj, nil
+}
+
+// setArgs returns the args of the SET command of a point object
+func setArgs(key Object_Collection, objID string, obj *LocationObject, fields LocationObject_Fields) []interface{} {
+	commandArgs := []interface{}{key, objID}
+	for k, v := range fields {
+		commandArgs = append(commandArgs, "FIELD")
+		commandArgs = append(commandArgs, k)
+		commandArgs = append(commandArgs, v)
+	}
+	// Pass by POINT, ordered lat lng
+	p := obj.Point()
+	commandArgs = append(commandArgs, "POINT")
+	commandArgs = append(commandArgs, p.Lat)
+	commandArgs = append(commandArgs, p.Lng)
+
+	return commandArgs
+}
+
+// setGeoJSONArgs returns the args of the SET command of a GeoJSON object
+func setGeoJSONArgs(key Object_Collection, objID string, object json.RawMessage, fields LocationObject_Fields) []interface{} {
+	commandArgs := []interface{}{key, objID}
+	for k, v := range fields {
+		commandArgs = append(commandArgs, "FIELD")
+		commandArgs = append(commandArgs, k)
+		commandArgs = append(commandArgs, v)
+	}
+	// Pass by OBJECT
+	commandArgs = append(commandArgs, "OBJECT")
+	commandArgs = append(commandArgs, string(object))
+
+	return commandArgs
+}
+
+// nearbyArgs returns the args of the NEARBY command, the radius is left out when zero and the cursor when not paging
+func nearbyArgs(key Object_Collection, query *NearbyQueryObject) []interface{} {
+	commandArgs := []interface{}{key}
+	if query.Limit > 0 {
+		commandArgs = append(commandArgs, "LIMIT")
+		commandArgs = append(commandArgs, query.Limit)
+	}
+	if query.Cursor > 0 {
+		commandArgs = append(commandArgs, "CURSOR")
+		commandArgs = append(commandArgs, query.Cursor)
+	}
+	commandArgs = appendWhereArgs(commandArgs, query.WhereList, query.WhereInList)
+
+	commandArgs = append(commandArgs, "POINT")
+	commandArgs = append(commandArgs, query.Lat)
+	commandArgs = append(commandArgs, query.Lng)
+	if query.Radius > 0 {
+		commandArgs = append(commandArgs, query.Radius)
+	}
+
+	return commandArgs
+}
+
+// areaArgs returns the args of the WITHIN or INTERSECTS command
+func areaArgs(key Object_Collection, query *AreaQueryObject) []interface{} {
+	commandArgs := []interface{}{key}
+	if query.Limit > 0 {
+		commandArgs = append(commandArgs, "LIMIT")
+		commandArgs = append(commandArgs, query.Limit)
+	}
+	commandArgs = appendWhereArgs(commandArgs, query.WhereList, query.WhereInList)
+
+	if b := query.Bounds; b != nil {
+		commandArgs = append(commandArgs, "BOUNDS", b.MinLat, b.MinLng, b.MaxLat, b.MaxLng)
+		return commandArgs
+	}
+	commandArgs = append(commandArgs, "OBJECT")
+	commandArgs = append(commandArgs, string(query.Object))
+
+	return commandArgs
+}
+
+// appendWhereArgs appends the WHERE and WHEREIN filters to the command args
+func appendWhereArgs(commandArgs []interface{}, whereList []WhereConditionFieldObject, whereInList []WhereInConditionFieldObject) []interface{} {
+	for _, c := range whereList {
+		commandArgs = append(commandArgs, "WHERE")
+		commandArgs = append(commandArgs, c.FieldName)
+		commandArgs = append(commandArgs, c.Min)
+		commandArgs = append(commandArgs, c.Max)
+	}
+
+	for _, wi := range whereInList {
+		if count := len(wi.Values); count > 0 {
+			commandArgs = append(commandArgs, "WHEREIN")
+			commandArgs = append(commandArgs, wi.FieldName)
+			commandArgs = append(commandArgs, count)
+			for _, wiv := range wi.Values {
+				commandArgs = append(commandArgs, wiv)
+			}
+		}
+	}
+
+	return commandArgs
+}
+
+// joinList joins the values of detect or command list with comma
+func joinList(list map[string]string) string {
+	count := 0
+	buffer := new(bytes.Buffer)
+	for _, d := range list {
+		if count == 0 {
+			fmt.Fprintf(buffer, "%s", d)
+		} else {
+			fmt.Fprintf(buffer, ",%s", d)
+		}
+		count++
+	}
+	return buffer.String()
+}
@@ -0,0 +1,55 @@
+package message
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// DriverStatusPoll_Version, version of the driver status message
+const (
+	DriverStatusPoll_Version_1 int32 = 1 // DriverStatusPoll, float coordinates
+	DriverStatusPoll_Version_2 int32 = 2 // DriverStatusPollV2, double coordinates with the motion of the driver
+)
+
+// ToV2 returns the message as a DriverStatusPollV2, the motion of the driver is not known
+func (m *DriverStatusPoll) ToV2() *DriverStatusPollV2 {
+	return &DriverStatusPollV2{
+		Fleet:      m.Fleet,
+		DriverId:   m.DriverId,
+		ProviderId: m.ProviderId,
+		Status:     m.Status,
+		JobId:      m.JobId,
+		Timestamp:  m.Timestamp,
+		Version:    DriverStatusPoll_Version_2,
+		Lat:        float64(m.Lat),
+		Lng:        float64(m.Lng),
+	}
+}
+
+// ToV1 returns the message as a DriverStatusPoll for the clients of version 1, the coordinates lose precision
+func (m *DriverStatusPollV2) ToV1() *DriverStatusPoll {
+	return &DriverStatusPoll{
+		Fleet:      m.Fleet,
+		DriverId:   m.DriverId,
+		ProviderId: m.ProviderId,
+		Lat:        float32(m.Lat),
+		Lng:        float32(m.Lng),
+		Status:     m.Status,
+		JobId:      m.JobId,
+		Timestamp:  m.Timestamp,
+	}
+}
+
+// UnmarshalDriverStatusPoll decodes a driver status message of any version, a version 1 message is returned as version 2.
+// The coordinates of version 2 have other field numbers, so a version 1 message is decoded as version 2 without version
+func UnmarshalDriverStatusPoll(buf []byte) (*DriverStatusPollV2, error) {
+	v2 := &DriverStatusPollV2{}
+	if err := proto.Unmarshal(buf, v2); err == nil && v2.Version == DriverStatusPoll_Version_2 {
+		return v2, nil
+	}
+
+	v1 := &DriverStatusPoll{}
+	if err := proto.Unmarshal(buf, v1); err != nil {
+		return nil, err
+	}
+	return v1.ToV2(), nil
+}
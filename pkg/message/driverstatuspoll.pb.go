@@ -23,21 +23,39 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 type DriverStatusPoll_DriverStatus int32
 
 const (
-	DriverStatusPoll_AVAILABLE    DriverStatusPoll_DriverStatus = 0
-	DriverStatusPoll_NOTAVAILABLE DriverStatusPoll_DriverStatus = 1
-	DriverStatusPoll_BUSY         DriverStatusPoll_DriverStatus = 2
+	DriverStatusPoll_AVAILABLE          DriverStatusPoll_DriverStatus = 0
+	DriverStatusPoll_NOTAVAILABLE       DriverStatusPoll_DriverStatus = 1
+	DriverStatusPoll_BUSY               DriverStatusPoll_DriverStatus = 2
+	DriverStatusPoll_UNREACHABLE        DriverStatusPoll_DriverStatus = 3
+	DriverStatusPoll_ON_BREAK           DriverStatusPoll_DriverStatus = 4
+	DriverStatusPoll_OFFERED            DriverStatusPoll_DriverStatus = 5
+	DriverStatusPoll_EN_ROUTE_TO_PICKUP DriverStatusPoll_DriverStatus = 6
+	DriverStatusPoll_ARRIVED_AT_PICKUP  DriverStatusPoll_DriverStatus = 7
+	DriverStatusPoll_ON_TRIP            DriverStatusPoll_DriverStatus = 8
 )
 
 var DriverStatusPoll_DriverStatus_name = map[int32]string{
 	0: "AVAILABLE",
 	1: "NOTAVAILABLE",
 	2: "BUSY",
+	3: "UNREACHABLE",
+	4: "ON_BREAK",
+	5: "OFFERED",
+	6: "EN_ROUTE_TO_PICKUP",
+	7: "ARRIVED_AT_PICKUP",
+	8: "ON_TRIP",
 }
 
 var DriverStatusPoll_DriverStatus_value = map[string]int32{
-	"AVAILABLE":    0,
-	"NOTAVAILABLE": 1,
-	"BUSY":         2,
+	"AVAILABLE":          0,
+	"NOTAVAILABLE":       1,
+	"BUSY":               2,
+	"UNREACHABLE":        3,
+	"ON_BREAK":           4,
+	"OFFERED":            5,
+	"EN_ROUTE_TO_PICKUP": 6,
+	"ARRIVED_AT_PICKUP":  7,
+	"ON_TRIP":            8,
 }
 
 func (x DriverStatusPoll_DriverStatus) String() string {
@@ -143,29 +161,187 @@ func (m *DriverStatusPoll) GetTimestamp() int64 {
 	return 0
 }
 
+// DriverStatusPollV2 carries the coordinates in double precision with the motion of the driver.
+// The version is always 2, so the servers tell it apart from DriverStatusPoll on the wire.
+type DriverStatusPollV2 struct {
+	Fleet                string                        `protobuf:"bytes,1,opt,name=fleet,proto3" json:"fleet,omitempty"`
+	DriverId             int32                         `protobuf:"varint,2,opt,name=driverId,proto3" json:"driverId,omitempty"`
+	ProviderId           int32                         `protobuf:"varint,3,opt,name=providerId,proto3" json:"providerId,omitempty"`
+	Status               DriverStatusPoll_DriverStatus `protobuf:"varint,6,opt,name=status,proto3,enum=message.DriverStatusPoll_DriverStatus" json:"status,omitempty"`
+	JobId                int64                         `protobuf:"varint,7,opt,name=jobId,proto3" json:"jobId,omitempty"`
+	Timestamp            int64                         `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Version              int32                         `protobuf:"varint,9,opt,name=version,proto3" json:"version,omitempty"`
+	Lat                  float64                       `protobuf:"fixed64,10,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lng                  float64                       `protobuf:"fixed64,11,opt,name=lng,proto3" json:"lng,omitempty"`
+	Accuracy             float32                       `protobuf:"fixed32,12,opt,name=accuracy,proto3" json:"accuracy,omitempty"`
+	Speed                float32                       `protobuf:"fixed32,13,opt,name=speed,proto3" json:"speed,omitempty"`
+	Bearing              float32                       `protobuf:"fixed32,14,opt,name=bearing,proto3" json:"bearing,omitempty"`
+	Altitude             float64                       `protobuf:"fixed64,15,opt,name=altitude,proto3" json:"altitude,omitempty"`
+	Motion               int32                         `protobuf:"varint,16,opt,name=motion,proto3" json:"motion,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
+	XXX_unrecognized     []byte                        `json:"-"`
+	XXX_sizecache        int32                         `json:"-"`
+}
+
+func (m *DriverStatusPollV2) Reset()         { *m = DriverStatusPollV2{} }
+func (m *DriverStatusPollV2) String() string { return proto.CompactTextString(m) }
+func (*DriverStatusPollV2) ProtoMessage()    {}
+func (*DriverStatusPollV2) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4499c9bd31e3d701, []int{1}
+}
+
+func (m *DriverStatusPollV2) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DriverStatusPollV2.Unmarshal(m, b)
+}
+func (m *DriverStatusPollV2) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DriverStatusPollV2.Marshal(b, m, deterministic)
+}
+func (m *DriverStatusPollV2) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DriverStatusPollV2.Merge(m, src)
+}
+func (m *DriverStatusPollV2) XXX_Size() int {
+	return xxx_messageInfo_DriverStatusPollV2.Size(m)
+}
+func (m *DriverStatusPollV2) XXX_DiscardUnknown() {
+	xxx_messageInfo_DriverStatusPollV2.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DriverStatusPollV2 proto.InternalMessageInfo
+
+func (m *DriverStatusPollV2) GetFleet() string {
+	if m != nil {
+		return m.Fleet
+	}
+	return ""
+}
+
+func (m *DriverStatusPollV2) GetDriverId() int32 {
+	if m != nil {
+		return m.DriverId
+	}
+	return 0
+}
+
+func (m *DriverStatusPollV2) GetProviderId() int32 {
+	if m != nil {
+		return m.ProviderId
+	}
+	return 0
+}
+
+func (m *DriverStatusPollV2) GetStatus() DriverStatusPoll_DriverStatus {
+	if m != nil {
+		return m.Status
+	}
+	return DriverStatusPoll_AVAILABLE
+}
+
+func (m *DriverStatusPollV2) GetJobId() int64 {
+	if m != nil {
+		return m.JobId
+	}
+	return 0
+}
+
+func (m *DriverStatusPollV2) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *DriverStatusPollV2) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *DriverStatusPollV2) GetLat() float64 {
+	if m != nil {
+		return m.Lat
+	}
+	return 0
+}
+
+func (m *DriverStatusPollV2) GetLng() float64 {
+	if m != nil {
+		return m.Lng
+	}
+	return 0
+}
+
+func (m *DriverStatusPollV2) GetAccuracy() float32 {
+	if m != nil {
+		return m.Accuracy
+	}
+	return 0
+}
+
+func (m *DriverStatusPollV2) GetSpeed() float32 {
+	if m != nil {
+		return m.Speed
+	}
+	return 0
+}
+
+func (m *DriverStatusPollV2) GetBearing() float32 {
+	if m != nil {
+		return m.Bearing
+	}
+	return 0
+}
+
+func (m *DriverStatusPollV2) GetAltitude() float64 {
+	if m != nil {
+		return m.Altitude
+	}
+	return 0
+}
+
+func (m *DriverStatusPollV2) GetMotion() int32 {
+	if m != nil {
+		return m.Motion
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterEnum("message.DriverStatusPoll_DriverStatus", DriverStatusPoll_DriverStatus_name, DriverStatusPoll_DriverStatus_value)
 	proto.RegisterType((*DriverStatusPoll)(nil), "message.DriverStatusPoll")
+	proto.RegisterType((*DriverStatusPollV2)(nil), "message.DriverStatusPollV2")
 }
 
 func init() { proto.RegisterFile("driverstatuspoll.proto", fileDescriptor_4499c9bd31e3d701) }
 
 var fileDescriptor_4499c9bd31e3d701 = []byte{
-	// 253 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x90, 0x41, 0x4b, 0xc3, 0x30,
-	0x14, 0xc7, 0x4d, 0xbb, 0x76, 0xed, 0x63, 0x4a, 0x78, 0x88, 0x04, 0x11, 0x09, 0x3b, 0x48, 0x4e,
-	0x3d, 0xe8, 0xc9, 0x8b, 0xd0, 0xa1, 0x87, 0xc2, 0x50, 0xc9, 0x54, 0xf0, 0xd8, 0xd1, 0x58, 0x2a,
-	0xe9, 0x52, 0x9a, 0xb8, 0xaf, 0xe6, 0xd7, 0x93, 0xa5, 0xc5, 0x4d, 0x6f, 0xf9, 0xff, 0xfe, 0x2f,
-	0x8f, 0x1f, 0x0f, 0xce, 0xaa, 0xbe, 0xd9, 0xaa, 0xde, 0xba, 0xd2, 0x7d, 0xd9, 0xce, 0x68, 0x9d,
-	0x75, 0xbd, 0x71, 0x06, 0xa7, 0xad, 0xb2, 0xb6, 0xac, 0xd5, 0xfc, 0x3b, 0x00, 0x7a, 0xef, 0x67,
-	0x56, 0x7e, 0xe6, 0xd9, 0x68, 0x8d, 0xa7, 0x10, 0x7d, 0x68, 0xa5, 0x1c, 0x23, 0x9c, 0x88, 0x54,
-	0x0e, 0x01, 0xcf, 0x21, 0x19, 0xb6, 0x15, 0x15, 0x0b, 0x38, 0x11, 0x91, 0xfc, 0xcd, 0x78, 0x09,
-	0xd0, 0xf5, 0x66, 0xdb, 0x54, 0xbe, 0x0d, 0x7d, 0x7b, 0x40, 0x90, 0x42, 0xa8, 0x4b, 0xc7, 0x26,
-	0x9c, 0x88, 0x40, 0xee, 0x9e, 0x9e, 0x6c, 0x6a, 0x16, 0x8d, 0x64, 0x53, 0xe3, 0x1d, 0xc4, 0x83,
-	0x27, 0x8b, 0x39, 0x11, 0x27, 0xd7, 0x57, 0xd9, 0x28, 0x99, 0xfd, 0x17, 0xfc, 0x03, 0xe4, 0xf8,
-	0x6b, 0x67, 0xfd, 0x69, 0xd6, 0x45, 0xc5, 0xa6, 0x9c, 0x88, 0x50, 0x0e, 0x01, 0x2f, 0x20, 0x75,
-	0x4d, 0xab, 0xac, 0x2b, 0xdb, 0x8e, 0x25, 0xbe, 0xd9, 0x83, 0xf9, 0x2d, 0xcc, 0x0e, 0x77, 0xe1,
-	0x31, 0xa4, 0xf9, 0x5b, 0x5e, 0x2c, 0xf3, 0xc5, 0xf2, 0x81, 0x1e, 0x21, 0x85, 0xd9, 0xe3, 0xd3,
-	0xcb, 0x9e, 0x10, 0x4c, 0x60, 0xb2, 0x78, 0x5d, 0xbd, 0xd3, 0x60, 0x1d, 0xfb, 0x4b, 0xde, 0xfc,
-	0x04, 0x00, 0x00, 0xff, 0xff, 0x79, 0x33, 0x0c, 0x76, 0x63, 0x01, 0x00, 0x00,
+	// 434 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc5, 0x53, 0x4d, 0x6b, 0xdb, 0x40,
+	0x10, 0x8d, 0xac, 0x4f, 0x8f, 0x9d, 0x64, 0x33, 0xb4, 0x61, 0x29, 0x21, 0x04, 0x1f, 0x42, 0x4e,
+	0x3e, 0xa4, 0xf7, 0x82, 0x1c, 0x2b, 0x54, 0x4d, 0x90, 0xcc, 0x46, 0x36, 0xe4, 0x24, 0x64, 0x6b,
+	0x63, 0x54, 0x64, 0xcb, 0x48, 0xeb, 0x40, 0xff, 0x4d, 0x0a, 0xfd, 0xa1, 0x5d, 0xad, 0x64, 0xe7,
+	0xe3, 0x5e, 0x7a, 0x11, 0x7a, 0xef, 0xed, 0xbc, 0x79, 0xab, 0x19, 0xc1, 0x69, 0x5a, 0x66, 0xcf,
+	0xbc, 0xac, 0x44, 0x22, 0xb6, 0xd5, 0xa6, 0xc8, 0xf3, 0xe1, 0xa6, 0x2c, 0x44, 0x81, 0xf6, 0x8a,
+	0x57, 0x55, 0xb2, 0xe4, 0x83, 0x17, 0x1d, 0xc8, 0x58, 0x9d, 0x79, 0x50, 0x67, 0x26, 0xf2, 0x0c,
+	0x7e, 0x02, 0xf3, 0x29, 0xe7, 0x5c, 0x50, 0xed, 0x42, 0xbb, 0xea, 0xb2, 0x06, 0xe0, 0x17, 0x70,
+	0x1a, 0x37, 0x3f, 0xa5, 0x1d, 0x29, 0x98, 0x6c, 0x8f, 0xf1, 0x1c, 0x40, 0x1a, 0x3f, 0x67, 0xa9,
+	0x52, 0x75, 0xa5, 0xbe, 0x61, 0x90, 0x80, 0x9e, 0x27, 0x82, 0x1a, 0x52, 0xe8, 0xb0, 0xfa, 0x55,
+	0x31, 0xeb, 0x25, 0x35, 0x5b, 0x66, 0xbd, 0xc4, 0x6f, 0x60, 0x35, 0x39, 0xa9, 0x25, 0xc9, 0xa3,
+	0xeb, 0xcb, 0x61, 0x1b, 0x72, 0xf8, 0x31, 0xe0, 0x3b, 0x82, 0xb5, 0x55, 0x75, 0xea, 0x9f, 0xc5,
+	0x5c, 0xb6, 0xb7, 0x65, 0xb9, 0xce, 0x1a, 0x80, 0x67, 0xd0, 0x15, 0x99, 0x34, 0x12, 0xc9, 0x6a,
+	0x43, 0x1d, 0xa5, 0xbc, 0x12, 0x83, 0xdf, 0x1a, 0xf4, 0xdf, 0x9a, 0xe1, 0x21, 0x74, 0xdd, 0x99,
+	0xeb, 0xdf, 0xbb, 0xa3, 0x7b, 0x8f, 0x1c, 0xc8, 0x94, 0xfd, 0x20, 0x8c, 0x5e, 0x19, 0x0d, 0x1d,
+	0x30, 0x46, 0xd3, 0x87, 0x47, 0xd2, 0xc1, 0x63, 0xe8, 0x4d, 0x03, 0xe6, 0xb9, 0x37, 0xdf, 0x95,
+	0xa4, 0x63, 0x1f, 0x9c, 0x30, 0x88, 0x47, 0x92, 0xba, 0x23, 0x06, 0xf6, 0xc0, 0x0e, 0x6f, 0x6f,
+	0x3d, 0xe6, 0x8d, 0x89, 0x89, 0xa7, 0x80, 0x5e, 0x10, 0xb3, 0x70, 0x1a, 0x79, 0x71, 0x14, 0xc6,
+	0x13, 0xff, 0xe6, 0x6e, 0x3a, 0x21, 0x16, 0x7e, 0x86, 0x13, 0x97, 0x31, 0x7f, 0xe6, 0x8d, 0x63,
+	0x37, 0xda, 0xd1, 0xb6, 0xaa, 0x0d, 0xe2, 0x88, 0xf9, 0x13, 0xe2, 0x0c, 0xfe, 0xe8, 0x80, 0x1f,
+	0xbf, 0xc0, 0xec, 0xfa, 0x1f, 0x0c, 0xe9, 0x3f, 0x0c, 0x00, 0x29, 0xd8, 0xf5, 0x7e, 0x66, 0xc5,
+	0x9a, 0x76, 0x55, 0xa0, 0x1d, 0xdc, 0xad, 0x0c, 0x48, 0x56, 0x7b, 0xb7, 0x32, 0xbd, 0x96, 0x91,
+	0x2b, 0x23, 0x6f, 0x9b, 0x2c, 0x16, 0xdb, 0x32, 0x59, 0xfc, 0xa2, 0x7d, 0xb5, 0x49, 0x7b, 0x5c,
+	0xa7, 0xa9, 0x36, 0x9c, 0xa7, 0xf4, 0x50, 0x09, 0x0d, 0xa8, 0xfb, 0xcd, 0x79, 0x52, 0x66, 0xd2,
+	0xe7, 0x48, 0xf1, 0x3b, 0xa8, 0xbc, 0x72, 0x91, 0x89, 0x6d, 0xca, 0xe9, 0xb1, 0x6a, 0xb1, 0xc7,
+	0x72, 0x7c, 0xd6, 0xaa, 0x10, 0x75, 0x48, 0xa2, 0x42, 0xb6, 0xe8, 0x87, 0xe1, 0x18, 0xc4, 0x94,
+	0x4f, 0x93, 0x58, 0x73, 0x4b, 0xfd, 0x59, 0x5f, 0xff, 0x02, 0x56, 0xb2, 0x8f, 0x96, 0x73, 0x03,
+	0x00, 0x00,
 }
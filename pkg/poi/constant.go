@@ -0,0 +1,26 @@
+package poi
+
+// POIListLimit
+const (
+	POI_List_Limit   int32 = 1000
+	POI_Import_Limit int   = 1000 // max number of POIs per bulk import
+)
+
+// POISearch default radius in meter and limit
+const (
+	POI_Search_Radius int32 = 10000
+	POI_Search_Limit  int32 = 20
+)
+
+// POIType
+type POI_Type int32
+
+const (
+	POI_Type_Workshop POI_Type = 1
+	POI_Type_TowYard  POI_Type = 2
+	POI_Type_Depot    POI_Type = 3
+)
+
+func (t POI_Type) IsValid() bool {
+	return t >= POI_Type_Workshop && t <= POI_Type_Depot
+}
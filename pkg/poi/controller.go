@@ -0,0 +1,372 @@
+package poi
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+var (
+	ErrPOINotFound    = errors.New("POI not found")
+	ErrPOIExists      = errors.New("POI already exists")
+	ErrDriverNotFound = errors.New("Driver not found")
+)
+
+type POIController struct {
+	locationService    *location.LocationService
+	locationController *location.LocationController
+}
+
+func (pc *POIController) Init() error {
+	pc.locationService = new(location.LocationService)
+	err := pc.locationService.Init(nil)
+	if err != nil {
+		return err
+	}
+	pc.locationController = new(location.LocationController)
+	err = pc.locationController.Init()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Create POI
+// if POI id already used, return ErrPOIExists
+func (pc *POIController) CreatePOI(reqObj *POIRequestObject) (*POIObject, error) {
+
+	existObj, err := pc.getPOIObject(reqObj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existObj.Ok {
+		return nil, ErrPOIExists
+	}
+
+	return pc.setPOI(reqObj)
+}
+
+// Update POI location and properties
+// if POI not found, return ErrPOINotFound
+func (pc *POIController) UpdatePOI(reqObj *POIRequestObject) (*POIObject, error) {
+
+	existObj, err := pc.getPOIObject(reqObj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !existObj.Ok {
+		return nil, ErrPOINotFound
+	}
+
+	return pc.setPOI(reqObj)
+}
+
+func (pc *POIController) GetPOI(poiID int32) (*POIObject, error) {
+
+	existObj, err := pc.getPOIObject(poiID)
+	if err != nil {
+		return nil, err
+	}
+	if !existObj.Ok {
+		return nil, ErrPOINotFound
+	}
+
+	poi := &POIObject{}
+	return poi.MapFrom(poiID, existObj.Object, existObj.Fields)
+}
+
+// List POIs ordered by id, filtered by type and provider if set
+func (pc *POIController) ListPOIs(poiType POI_Type, providerID int32) ([]*POIObject, error) {
+
+	res, err := pc.locationService.ScanObject(location.Object_Collection_POI, POI_List_Limit, 0, nil, searchConditions(poiType, providerID))
+	if err != nil {
+		return nil, err
+	}
+
+	// check if ok is false
+	if res.Ok == false {
+		return nil, errors.New(res.Error)
+	}
+
+	pois := []*POIObject{}
+	for i, o := range res.Objects {
+		poi, err := mapPOI(o.ID, o.Object, res.FieldMap(i))
+		if err != nil {
+			return nil, err
+		}
+		pois = append(pois, poi)
+	}
+
+	return pois, nil
+}
+
+// Delete POI
+// if POI not found, return ErrPOINotFound
+func (pc *POIController) DeletePOI(poiID int32) error {
+
+	existObj, err := pc.getPOIObject(poiID)
+	if err != nil {
+		return err
+	}
+	if !existObj.Ok {
+		return ErrPOINotFound
+	}
+
+	res, err := pc.locationService.DelObject(location.Object_Collection_POI, location.GenerateLocationObjectId("", poiID))
+	if err != nil {
+		return err
+	}
+
+	// check if ok is false
+	if res.Ok == false {
+		return errors.New(res.Error)
+	}
+
+	log.Printf("POI deleted: %d\n", poiID)
+	return nil
+}
+
+// Import POIs, existing POIs are replaced.
+// Every POI is validated and set on its own, failed POIs are reported by their index in the list
+func (pc *POIController) ImportPOIs(reqObjs []POIRequestObject) (*POIImportObject, error) {
+	if len(reqObjs) > POI_Import_Limit {
+		return nil, errors.New("Too many POIs, import at most " + strconv.Itoa(POI_Import_Limit) + " POIs at once")
+	}
+
+	result := &POIImportObject{Failed: []POIImportErrorObject{}}
+	for i := range reqObjs {
+		_, err := pc.setPOI(&reqObjs[i])
+		if err != nil {
+			log.Printf("POI import failed at %d: %v\n", i, err)
+			result.Failed = append(result.Failed, POIImportErrorObject{Index: i, ID: reqObjs[i].ID, Error: err.Error()})
+			continue
+		}
+		result.Imported++
+	}
+
+	log.Printf("POI imported: %d, failed: %d\n", result.Imported, len(result.Failed))
+	return result, nil
+}
+
+// Search POIs around the point ordered by distance
+func (pc *POIController) SearchNearbyPOI(
+	lat float64,
+	lng float64,
+	radius int32,
+	limit int32,
+	poiType POI_Type,
+	providerID int32) ([]*POIObject, error) {
+
+	res, err := pc.locationService.NearbyGeoJSONObject(
+		location.Object_Collection_POI, lat, lng, radius, limit, nil, searchConditions(poiType, providerID))
+	if err != nil {
+		return nil, err
+	}
+
+	// check if ok is false
+	if res.Ok == false {
+		return nil, errors.New(res.Error)
+	}
+
+	pois, err := mapPOIs(res)
+	if err != nil {
+		return nil, err
+	}
+	for _, poi := range pois {
+		poi.Distance = common.Distance(lat, lng, poi.Lat, poi.Lng)
+	}
+
+	return pois, nil
+}
+
+// Search POIs within the GeoJSON Polygon or MultiPolygon ordered by id
+func (pc *POIController) SearchWithinPOI(
+	geometry json.RawMessage,
+	limit int32,
+	poiType POI_Type,
+	providerID int32) ([]*POIObject, error) {
+
+	// validate geometry is a Polygon or MultiPolygon
+	_, err := location.ParsePolygons(geometry)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := pc.locationService.SearchAreaGeoJSONObject(
+		location.Object_Collection_POI, location.LocationSearch_Type_Within, geometry, limit, nil, searchConditions(poiType, providerID))
+	if err != nil {
+		return nil, err
+	}
+
+	// check if ok is false
+	if res.Ok == false {
+		return nil, errors.New(res.Error)
+	}
+
+	return mapPOIs(res)
+}
+
+// Find the nearest POI of the type to the current location of the driver of the tenant of the fleet, without distance limit
+// if driver not found, return ErrDriverNotFound
+// if no POI matches, return ErrPOINotFound
+func (pc *POIController) NearestPOIToDriver(driverID int32, fleet string, poiType POI_Type, providerID int32) (*POINearestObject, error) {
+
+	locationController, err := pc.locationController.ForTenant(fleet)
+	if err != nil {
+		return nil, err
+	}
+	driverObj, err := locationController.GetDriverStatus(driverID)
+	if err != nil {
+		return nil, err
+	}
+	if !driverObj.Ok {
+		return nil, ErrDriverNotFound
+	}
+
+	p := driverObj.Object.Point()
+	pois, err := pc.SearchNearbyPOI(p.Lat, p.Lng, 0, 1, poiType, providerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pois) == 0 {
+		return nil, ErrPOINotFound
+	}
+
+	return &POINearestObject{
+		DriverID:       driverID,
+		DriverLocation: driverObj.Object,
+		POI:            pois[0],
+	}, nil
+}
+
+// ValidatePOIRequest checks the id, type and location of the POI
+func ValidatePOIRequest(reqObj *POIRequestObject) error {
+	if reqObj.ID <= 0 {
+		return errors.New("POI id is missing, but required")
+	}
+	if !reqObj.POIType.IsValid() {
+		return errors.New("POI type is invalid")
+	}
+	if err := (common.GeoPoint{Lat: reqObj.Lat, Lng: reqObj.Lng}).Validate(); err != nil {
+		return errors.New("POI location is invalid: " + err.Error())
+	}
+	if reqObj.Name == "" {
+		return errors.New("POI name is missing, but required")
+	}
+	return nil
+}
+
+func (pc *POIController) getPOIObject(poiID int32) (*location.GetGeoJSONResponseObject, error) {
+	if poiID <= 0 {
+		return nil, errors.New("POI id is not set")
+	}
+
+	res, err := pc.locationService.GetGeoJSONObject(location.Object_Collection_POI, location.GenerateLocationObjectId("", poiID))
+	if err != nil {
+		return nil, err
+	}
+
+	// check if response object is empty
+	if res == nil {
+		return nil, errors.New("response object is empty")
+	}
+
+	return res, nil
+}
+
+func (pc *POIController) setPOI(reqObj *POIRequestObject) (*POIObject, error) {
+
+	err := ValidatePOIRequest(reqObj)
+	if err != nil {
+		return nil, err
+	}
+
+	feature := POIFeatureObject{
+		Type: location.LocationObject_Type_Feature,
+		Geometry: POIGeometryObject{
+			Type:        location.LocationObject_Type_GeoJSONPoint,
+			Coordinates: common.GeoPoint{Lat: reqObj.Lat, Lng: reqObj.Lng}.GeoJSON(),
+		},
+		Properties: POIPropertiesObject{
+			Name:         reqObj.Name,
+			Address:      reqObj.Address,
+			Phone:        reqObj.Phone,
+			OpeningHours: reqObj.OpeningHours,
+		},
+	}
+	object, err := json.Marshal(feature)
+	if err != nil {
+		return nil, err
+	}
+
+	timeNow := time.Now().Unix()
+	fields := location.LocationObject_Fields{
+		"poitype":         int32(reqObj.POIType),
+		"providerid":      reqObj.ProviderID,
+		"capacity":        reqObj.Capacity,
+		"lastupdatedtime": timeNow,
+	}
+
+	res, err := pc.locationService.SetGeoJSONObject(location.Object_Collection_POI, location.GenerateLocationObjectId("", reqObj.ID), object, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// check if ok is false
+	if res.Ok == false {
+		return nil, errors.New(res.Error)
+	}
+
+	log.Printf("POI updated: %d\n", reqObj.ID)
+	return &POIObject{
+		ID:                   reqObj.ID,
+		POIType:              reqObj.POIType,
+		ProviderID:           reqObj.ProviderID,
+		Capacity:             reqObj.Capacity,
+		Lat:                  reqObj.Lat,
+		Lng:                  reqObj.Lng,
+		Name:                 reqObj.Name,
+		Address:              reqObj.Address,
+		Phone:                reqObj.Phone,
+		OpeningHours:         reqObj.OpeningHours,
+		LastUpdatedTimestamp: timeNow,
+	}, nil
+}
+
+// searchConditions filters POIs by type and provider, zero means any
+func searchConditions(poiType POI_Type, providerID int32) []location.WhereInConditionFieldObject {
+	whereInList := []location.WhereInConditionFieldObject{}
+	if poiType != 0 {
+		whereInList = append(whereInList, location.WhereInConditionFieldObject{FieldName: "poitype", Values: []interface{}{int32(poiType)}})
+	}
+	if providerID != 0 {
+		whereInList = append(whereInList, location.WhereInConditionFieldObject{FieldName: "providerid", Values: []interface{}{providerID}})
+	}
+	return whereInList
+}
+
+func mapPOIs(res *location.ScanObjectResponseObject) ([]*POIObject, error) {
+	pois := make([]*POIObject, len(res.Objects))
+	for i, o := range res.Objects {
+		poi, err := mapPOI(o.ID, o.Object, res.FieldMap(i))
+		if err != nil {
+			return nil, err
+		}
+		pois[i] = poi
+	}
+	return pois, nil
+}
+
+func mapPOI(objID string, object json.RawMessage, fields map[string]float64) (*POIObject, error) {
+	id, err := strconv.ParseInt(objID, 10, 32)
+	if err != nil {
+		return nil, errors.New("POI id is invalid: " + objID)
+	}
+	poi := &POIObject{}
+	return poi.MapFrom(int32(id), object, fields)
+}
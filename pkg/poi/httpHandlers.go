@@ -0,0 +1,464 @@
+package poi
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+// POST body: { "id": 1, "poitype": [1|2|3], "providerid": 1, "capacity": 10, "lat": 3.1, "lng": 101.6,
+// "name": "Yard A", "address": "...", "phone": "...", "openinghours": "..." }
+func HandleCreatePOI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	// reading POST body
+	log.Println("Decoding request json body")
+	decoder := json.NewDecoder(r.Body)
+	var reqObj POIRequestObject
+	err := decoder.Decode(&reqObj)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+	log.Println(reqObj)
+
+	if err := ValidatePOIRequest(&reqObj); err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	poiController := new(POIController)
+	err = poiController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := poiController.CreatePOI(&reqObj)
+	if err != nil {
+		handlePOIErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &POIResultObject{POI: res})
+}
+
+// Url Param: poi type (type = 1|2|3) (optional)
+// providerid (provider) (optional)
+func HandleListPOIs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	// get Url Param
+	queryValues := r.URL.Query()
+	log.Println(queryValues)
+
+	poiType, providerID, err := parseSearchFilter(queryValues)
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	poiController := new(POIController)
+	err = poiController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := poiController.ListPOIs(poiType, providerID)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &POIsResultObject{POIs: res})
+}
+
+// url: poi id ("id") (required)
+func HandleGetPOI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	poiID, err := parsePOIID(mux.Vars(r))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	poiController := new(POIController)
+	err = poiController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := poiController.GetPOI(poiID)
+	if err != nil {
+		handlePOIErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &POIResultObject{POI: res})
+}
+
+// url: poi id ("id") (required)
+// PUT body: same as create, the id of the body is ignored
+func HandleUpdatePOI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	poiID, err := parsePOIID(mux.Vars(r))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	// reading PUT body
+	log.Println("Decoding request json body")
+	decoder := json.NewDecoder(r.Body)
+	var reqObj POIRequestObject
+	err = decoder.Decode(&reqObj)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+	log.Println(reqObj)
+
+	reqObj.ID = poiID
+	if err := ValidatePOIRequest(&reqObj); err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	poiController := new(POIController)
+	err = poiController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := poiController.UpdatePOI(&reqObj)
+	if err != nil {
+		handlePOIErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &POIResultObject{POI: res})
+}
+
+// url: poi id ("id") (required)
+func HandleDeletePOI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "DELETE" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	poiID, err := parsePOIID(mux.Vars(r))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	poiController := new(POIController)
+	err = poiController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	err = poiController.DeletePOI(poiID)
+	if err != nil {
+		handlePOIErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &common.EmptyResultObject{})
+}
+
+// POST body: { "pois": [ POI, ... ] }, POIs are created or replaced
+func HandleImportPOIs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	// reading POST body
+	log.Println("Decoding request json body")
+	decoder := json.NewDecoder(r.Body)
+	var reqObj POIImportRequestObject
+	err := decoder.Decode(&reqObj)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	if len(reqObj.POIs) == 0 {
+		common.HandleStatus400Response(w, "POI list is missing, but required")
+		return
+	}
+	if len(reqObj.POIs) > POI_Import_Limit {
+		common.HandleStatus400Response(w, "POI list is over the import limit of "+strconv.Itoa(POI_Import_Limit))
+		return
+	}
+
+	poiController := new(POIController)
+	err = poiController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := poiController.ImportPOIs(reqObj.POIs)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &POIImportResultObject{POIImport: res})
+}
+
+// Url Param: latitude (lat) (required)
+// longitude (lng) (required)
+// radius in meter (radius) (optional)
+// limit (limit) (optional)
+// poi type (type = 1|2|3) (optional)
+// providerid (provider) (optional)
+func HandleGetNearbyPOIs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	// get Url Param
+	queryValues := r.URL.Query()
+	log.Println(queryValues)
+
+	point, err := common.ParseGeoPoint(queryValues.Get("lat"), queryValues.Get("lng"))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	radius, err := parsePositiveParam(queryValues, "radius", POI_Search_Radius)
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+	limit, err := parsePositiveParam(queryValues, "limit", POI_Search_Limit)
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+	poiType, providerID, err := parseSearchFilter(queryValues)
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	poiController := new(POIController)
+	err = poiController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := poiController.SearchNearbyPOI(point.Lat, point.Lng, radius, limit, poiType, providerID)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &POIsResultObject{POIs: res})
+}
+
+// POST body: { "geometry": { GeoJSON Polygon or MultiPolygon }, "poitype": [0|1|2|3], "providerid": 0, "limit": 20 }
+func HandleGetWithinPOIs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	// reading POST body
+	log.Println("Decoding request json body")
+	decoder := json.NewDecoder(r.Body)
+	var reqObj POIWithinRequestObject
+	err := decoder.Decode(&reqObj)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+	log.Println(reqObj)
+
+	if len(reqObj.Geometry) == 0 {
+		common.HandleStatus400Response(w, "Geometry is missing, but required")
+		return
+	}
+	if _, err := location.ParsePolygons(reqObj.Geometry); err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+	if reqObj.POIType != 0 && !reqObj.POIType.IsValid() {
+		common.HandleStatus400Response(w, "POI type is invalid")
+		return
+	}
+	if reqObj.Limit < 0 {
+		common.HandleStatus400Response(w, "Limit is invalid")
+		return
+	}
+	if reqObj.Limit == 0 {
+		reqObj.Limit = POI_Search_Limit
+	}
+
+	poiController := new(POIController)
+	err = poiController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := poiController.SearchWithinPOI(reqObj.Geometry, reqObj.Limit, reqObj.POIType, reqObj.ProviderID)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &POIsResultObject{POIs: res})
+}
+
+// Url Param: driverid (driver) (required)
+// fleet, tenant of the driver (fleet = towing) (optional, the default tenant)
+// poi type (type = 1|2|3) (required)
+// providerid (provider) (optional)
+func HandleGetNearestPOI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	// get Url Param
+	queryValues := r.URL.Query()
+	log.Println(queryValues)
+
+	if queryValues.Get("driver") == "" {
+		common.HandleStatus400Response(w, "Driver ID is missing, but required")
+		return
+	}
+	driverID, err := strconv.ParseInt(queryValues.Get("driver"), 10, 32)
+	if err != nil || driverID <= 0 {
+		common.HandleStatus400Response(w, "Driver ID is invalid")
+		return
+	}
+	if queryValues.Get("type") == "" {
+		common.HandleStatus400Response(w, "POI type is missing, but required")
+		return
+	}
+	poiType, providerID, err := parseSearchFilter(queryValues)
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	poiController := new(POIController)
+	err = poiController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := poiController.NearestPOIToDriver(int32(driverID), queryValues.Get("fleet"), poiType, providerID)
+	if err != nil {
+		handlePOIErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &POINearestResultObject{POINearest: res})
+}
+
+func parsePOIID(vars map[string]string) (int32, error) {
+	id, err := strconv.ParseInt(vars["id"], 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("POI id is invalid")
+	}
+	return int32(id), nil
+}
+
+// parseSearchFilter reads the poi type (type) and providerid (provider), zero if not set
+func parseSearchFilter(queryValues url.Values) (POI_Type, int32, error) {
+	var poiType POI_Type
+	if queryValues.Get("type") != "" {
+		t, err := strconv.ParseInt(queryValues.Get("type"), 10, 32)
+		if err != nil || !POI_Type(t).IsValid() {
+			return 0, 0, errors.New("POI type is invalid")
+		}
+		poiType = POI_Type(t)
+	}
+
+	var providerID int32
+	if queryValues.Get("provider") != "" {
+		p, err := strconv.ParseInt(queryValues.Get("provider"), 10, 32)
+		if err != nil || p < 0 {
+			return 0, 0, errors.New("Provider ID is invalid")
+		}
+		providerID = int32(p)
+	}
+
+	return poiType, providerID, nil
+}
+
+// parsePositiveParam reads a positive integer param, the default value is returned if not set
+func parsePositiveParam(queryValues url.Values, name string, defaultValue int32) (int32, error) {
+	if queryValues.Get(name) == "" {
+		return defaultValue, nil
+	}
+	v, err := strconv.ParseInt(queryValues.Get(name), 10, 32)
+	if err != nil || v <= 0 {
+		return 0, errors.New("Param " + name + " is invalid")
+	}
+	return int32(v), nil
+}
+
+func handlePOIErrorResponse(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrPOINotFound, ErrDriverNotFound:
+		common.HandleStatusNotFoundResponse(w, err.Error())
+	case ErrPOIExists, location.ErrTenantUnknown:
+		common.HandleStatus400Response(w, err.Error())
+	default:
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+	}
+}
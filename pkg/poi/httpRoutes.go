@@ -0,0 +1,22 @@
+package poi
+
+import (
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+func NewRouter() []common.Route {
+
+	poiRouter := []common.Route{
+		common.Route{"CreatePOI", "POST", "/pois", HandleCreatePOI},
+		common.Route{"ListPOIs", "GET", "/pois", HandleListPOIs},
+		common.Route{"ImportPOIs", "POST", "/pois/import", HandleImportPOIs},
+		common.Route{"GetNearbyPOIs", "GET", "/pois/nearby", HandleGetNearbyPOIs},
+		common.Route{"GetWithinPOIs", "POST", "/pois/within", HandleGetWithinPOIs},
+		common.Route{"GetNearestPOI", "GET", "/pois/nearest", HandleGetNearestPOI},
+		common.Route{"GetPOI", "GET", "/pois/{id:[0-9]+}", HandleGetPOI},
+		common.Route{"UpdatePOI", "PUT", "/pois/{id:[0-9]+}", HandleUpdatePOI},
+		common.Route{"DeletePOI", "DELETE", "/pois/{id:[0-9]+}", HandleDeletePOI},
+	}
+
+	return poiRouter
+}
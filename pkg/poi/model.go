@@ -0,0 +1,142 @@
+package poi
+
+import (
+	"encoding/json"
+
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+type POIRequestObject struct {
+	ID           int32    `json:"id"`
+	POIType      POI_Type `json:"poitype"`
+	ProviderID   int32    `json:"providerid"`
+	Capacity     int32    `json:"capacity"`
+	Lat          float64  `json:"lat"`
+	Lng          float64  `json:"lng"`
+	Name         string   `json:"name"`
+	Address      string   `json:"address"`
+	Phone        string   `json:"phone"`
+	OpeningHours string   `json:"openinghours"`
+}
+
+type POIImportRequestObject struct {
+	POIs []POIRequestObject `json:"pois"`
+}
+
+// POIWithinRequestObject searches POIs within a GeoJSON Polygon or MultiPolygon
+type POIWithinRequestObject struct {
+	Geometry   json.RawMessage `json:"geometry"`
+	POIType    POI_Type        `json:"poitype"`
+	ProviderID int32           `json:"providerid"`
+	Limit      int32           `json:"limit"`
+}
+
+// POIPropertiesObject holds the text properties of the POI, stored in the properties of the
+// GeoJSON Feature since Tile38 fields are numeric only
+type POIPropertiesObject struct {
+	Name         string `json:"name"`
+	Address      string `json:"address,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	OpeningHours string `json:"openinghours,omitempty"`
+}
+
+type POIGeometryObject struct {
+	Type        location.LocationObject_Type `json:"type"`
+	Coordinates [2]float64                   `json:"coordinates"` // [lng, lat]
+}
+
+type POIFeatureObject struct {
+	Type       location.LocationObject_Type `json:"type"`
+	Geometry   POIGeometryObject            `json:"geometry"`
+	Properties POIPropertiesObject          `json:"properties"`
+}
+
+type POIObject struct {
+	ID                   int32    `json:"id"`
+	POIType              POI_Type `json:"poitype"`
+	ProviderID           int32    `json:"providerid"`
+	Capacity             int32    `json:"capacity"`
+	Lat                  float64  `json:"lat"`
+	Lng                  float64  `json:"lng"`
+	Name                 string   `json:"name"`
+	Address              string   `json:"address,omitempty"`
+	Phone                string   `json:"phone,omitempty"`
+	OpeningHours         string   `json:"openinghours,omitempty"`
+	Distance             float64  `json:"distance,omitempty"` // meter, set by searches
+	LastUpdatedTimestamp int64    `json:"lastupdatedtime"`
+}
+
+// MapFrom maps the GeoJSON Feature and the fields of the POI
+func (o *POIObject) MapFrom(id int32, object json.RawMessage, fields map[string]float64) (*POIObject, error) {
+	var feature POIFeatureObject
+	err := json.Unmarshal(object, &feature)
+	if err != nil {
+		return nil, err
+	}
+
+	p := common.GeoPointFromGeoJSON(feature.Geometry.Coordinates)
+	return &POIObject{
+		ID:                   id,
+		POIType:              POI_Type(fields["poitype"]),
+		ProviderID:           int32(fields["providerid"]),
+		Capacity:             int32(fields["capacity"]),
+		Lat:                  p.Lat,
+		Lng:                  p.Lng,
+		Name:                 feature.Properties.Name,
+		Address:              feature.Properties.Address,
+		Phone:                feature.Properties.Phone,
+		OpeningHours:         feature.Properties.OpeningHours,
+		LastUpdatedTimestamp: int64(fields["lastupdatedtime"]),
+	}, nil
+}
+
+type POIImportErrorObject struct {
+	Index int    `json:"index"`
+	ID    int32  `json:"id"`
+	Error string `json:"err"`
+}
+
+type POIImportObject struct {
+	Imported int32                  `json:"imported"`
+	Failed   []POIImportErrorObject `json:"failed"`
+}
+
+// POINearestObject is the nearest POI to a driver
+type POINearestObject struct {
+	DriverID       int32                           `json:"driverid"`
+	DriverLocation location.LocationResponseObject `json:"driverlocation"`
+	POI            *POIObject                      `json:"poi"`
+}
+
+type POIResultObject struct {
+	POI interface{} `json:"poi"`
+}
+
+func (o *POIResultObject) SetResult(result interface{}) {
+	o.POI = result
+}
+
+type POIsResultObject struct {
+	POIs interface{} `json:"pois"`
+}
+
+func (o *POIsResultObject) SetResult(result interface{}) {
+	o.POIs = result
+}
+
+type POIImportResultObject struct {
+	POIImport interface{} `json:"poiimport"`
+}
+
+func (o *POIImportResultObject) SetResult(result interface{}) {
+	o.POIImport = result
+}
+
+type POINearestResultObject struct {
+	POINearest interface{} `json:"poinearest"`
+}
+
+func (o *POINearestResultObject) SetResult(result interface{}) {
+	o.POINearest = result
+}
@@ -2,16 +2,22 @@ package socket
 
 import (
 	"bytes"
+	"encoding/json"
 	"log"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/iknowhtml/locationtracker/pkg/dispatch"
+	"github.com/iknowhtml/locationtracker/pkg/location"
 )
 
 // Client is a middleman between the websocket connection and the hub.
 type Client struct {
 	clientId int32
 
+	// Fleet (tenant) of the driver of clientId, the name of the tenant
+	fleet string
+
 	hub *Hub
 
 	// The websocket connection.
@@ -22,6 +28,12 @@ type Client struct {
 
 	// Buffered channel of status message
 	status chan []byte
+
+	// Fence event subscriber, of the driver of clientId or all drivers when 0
+	subscriber bool
+
+	// Version of the driver status messages pushed to the client
+	version int32
 }
 
 // ReadMessage pull messages from the websocket connection to the hub.
@@ -65,6 +77,9 @@ func (c *Client) ReadMessage() {
 
 			// Send to broadcast channel to send messages to all client's send channel
 			//c.hub.broadcast <- message
+
+			// answers of the driver to the job offers
+			c.handleText(message)
 		case websocket.BinaryMessage:
 			// BinaryMessage denotes a binary data message.
 			log.Printf("Socket/ReadMessage: Client# %d: Binary Message Received\n", c.clientId)
@@ -87,6 +102,34 @@ func (c *Client) ReadMessage() {
 	}
 }
 
+// key returns the key of the driver of the client in the registered clients
+func (c *Client) key() location.TenantDriverKey {
+	return location.TenantDriverKey{Tenant: c.fleet, DriverID: c.clientId}
+}
+
+// handleText passes the answer of the driver to the job offer, the other text messages are ignored
+func (c *Client) handleText(message []byte) {
+	if c.subscriber || c.clientId == 0 {
+		return
+	}
+
+	var resp dispatch.OfferResponseObject
+	if err := json.Unmarshal(message, &resp); err != nil {
+		log.Printf("Socket/ReadMessage: Client# %d: Ignoring text message: %v\n", c.clientId, err)
+		return
+	}
+	if resp.Type != dispatch.OfferMessage_Type_Accept && resp.Type != dispatch.OfferMessage_Type_Decline {
+		log.Printf("Socket/ReadMessage: Client# %d: Ignoring text message of type: %s\n", c.clientId, resp.Type)
+		return
+	}
+
+	if err := dispatch.RespondOffer(c.fleet, c.clientId, &resp); err != nil {
+		log.Printf("Socket/ReadMessage: Client# %d: Failed to answer offer of job %d: %v\n", c.clientId, resp.JobID, err)
+		return
+	}
+	log.Printf("Socket/ReadMessage: Client# %d: Offer of job %d answered: %s\n", c.clientId, resp.JobID, resp.Type)
+}
+
 // WriteMessage push messages from the hub to the websocket connection.
 //
 // A goroutine running WriteMessage is started for each connection. The
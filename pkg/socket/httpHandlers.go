@@ -8,6 +8,8 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+	"github.com/iknowhtml/locationtracker/pkg/message"
 )
 
 var (
@@ -29,10 +31,9 @@ func WebSocketHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Start hub
-	hub := newHub()
-	go hub.run()
+	hub := startHub()
 
-	ServeWs(hub, w, r, 0)
+	ServeWs(hub, w, r, 0, "", message.DriverStatusPoll_Version_1)
 }
 
 func WSDriverStatusHandler(w http.ResponseWriter, r *http.Request) {
@@ -53,14 +54,32 @@ func WSDriverStatusHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Url Param: version of the status messages (version = 1|2) (optional, default 1)
+	version := message.DriverStatusPoll_Version_1
+	switch r.URL.Query().Get("version") {
+	case "", "1":
+	case "2":
+		version = message.DriverStatusPoll_Version_2
+	default:
+		common.HandleStatus400Response(w, "Version is invalid")
+		return
+	}
+
+	// Url Param: fleet, tenant of the driver (fleet = towing) (optional, the default tenant)
+	tenant, err := location.GetTenant(r.URL.Query().Get("fleet"))
+	if err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
 	// Start hub
-	hub := newHub()
-	go hub.run()
+	hub := startHub()
 
-	ServeWs(hub, w, r, int32(driverID))
+	// the name of the tenant, so the clients of the default tenant have the same key with or without fleet
+	ServeWs(hub, w, r, int32(driverID), tenant.Name, version)
 }
 
-func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, driverID int32) {
+func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, driverID int32, fleet string, version int32) {
 	log.Printf("Socket/ServeWs: Upgrading connection...\n")
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -68,7 +87,7 @@ func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, driverID int32) {
 		return
 	}
 	log.Printf("Socket/ServeWs: New connection: %d\n", driverID)
-	client := &Client{clientId: driverID, hub: hub, conn: conn, send: make(chan []byte, 256), status: make(chan []byte, 256)}
+	client := &Client{clientId: driverID, fleet: fleet, hub: hub, conn: conn, send: make(chan []byte, 256), status: make(chan []byte, 256), version: version}
 	client.hub.register <- client
 
 	// Allow collection of memory referenced by the caller by doing all work in
@@ -76,3 +95,40 @@ func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, driverID int32) {
 	go client.WriteMessage()
 	go client.ReadMessage()
 }
+
+// url: driver id ("id") (optional), the events of all drivers when not set
+func WSFenceEventsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Socket/WSFenceEventsHandler: Handling Fence Events request...\n")
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	var driverID int
+	if vars["id"] != "" {
+		driverID, _ = strconv.Atoi(vars["id"])
+		if driverID == 0 {
+			// send a internal server error back to the caller
+			common.HandleStatus400Response(w, "Driver ID is invalid")
+			return
+		}
+	}
+
+	// Start hub
+	hub := startHub()
+
+	log.Printf("Socket/WSFenceEventsHandler: Upgrading connection...\n")
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Socket/WSFenceEventsHandler: Error upgrading connection: %v\n", err)
+		return
+	}
+	log.Printf("Socket/WSFenceEventsHandler: New subscriber: %d\n", driverID)
+	client := &Client{clientId: int32(driverID), hub: hub, conn: conn, send: make(chan []byte, 256), status: make(chan []byte, 256), subscriber: true}
+	client.hub.subscribe <- client
+
+	go client.WriteMessage()
+	go client.ReadMessage()
+}
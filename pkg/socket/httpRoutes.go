@@ -9,6 +9,8 @@ func NewWSRouter() []common.Route {
 	wsRouter := []common.Route{
 		//common.Route{"HandleWS", "GET", "/handle", WebSocketHandler},
 		common.Route{"WSDriverStatus", "GET", "/driver/{id:[0-9]+}/status", WSDriverStatusHandler},
+		common.Route{"WSFenceEvents", "GET", "/fence/events", WSFenceEventsHandler},
+		common.Route{"WSDriverFenceEvents", "GET", "/driver/{id:[0-9]+}/fence", WSFenceEventsHandler},
 	}
 
 	return wsRouter
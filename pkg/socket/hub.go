@@ -17,8 +17,8 @@ type Hub struct {
 	// Hub ID
 	ID string
 
-	// Registered clients.
-	clients map[int32]*Client
+	// Registered clients, by tenant and driver id.
+	clients map[location.TenantDriverKey]*Client
 
 	// Inbound messages from the clients.
 	broadcast chan []byte
@@ -28,10 +28,23 @@ type Hub struct {
 
 	// Unregister requests from clients.
 	unregister chan *Client
+
+	// Registered fence event subscribers.
+	subscribers map[*Client]bool
+
+	// Subscribe requests from the fence event subscribers.
+	subscribe chan *Client
+
+	// Fence events to push to the subscribers.
+	fenceEvents chan *fenceEventMessage
+
+	// Job offers to push to the drivers.
+	offers chan *offerMessage
 }
 
 var h *Hub
 var once sync.Once
+var runOnce sync.Once
 
 // Initialize and create Hub instance
 func newHub() *Hub {
@@ -46,13 +59,29 @@ func newHub() *Hub {
 			broadcast:  make(chan []byte),
 			register:   make(chan *Client),
 			unregister: make(chan *Client),
-			clients:    make(map[int32]*Client),
+			clients:    make(map[location.TenantDriverKey]*Client),
+
+			subscribers: make(map[*Client]bool),
+			subscribe:   make(chan *Client),
+			fenceEvents: make(chan *fenceEventMessage, 256),
+
+			offers: make(chan *offerMessage),
 		}
 	})
 
 	return h
 }
 
+// Retrieve the Hub instance and run it once, the clients are only handled by a single run
+func startHub() *Hub {
+	hub := newHub()
+	runOnce.Do(func() {
+		go hub.run()
+	})
+
+	return hub
+}
+
 // Run the Hub instance to start registering/unregistering clients and handling of incoming messages
 func (h *Hub) run() {
 	log.Printf("Socket/run: Running Hub: %s\n", h.ID)
@@ -60,17 +89,28 @@ func (h *Hub) run() {
 		select {
 		case client := <-h.register:
 			log.Printf("Socket/run: Registering new client: %v\n", client)
-			h.clients[client.clientId] = client
+			h.clients[client.key()] = client
 
 			// start a new goroutine to pushing status every x seconds
 			go h.startPushStatus(client, writePeriod)
 
+		case client := <-h.subscribe:
+			log.Printf("Socket/run: Subscribing client to fence events: %v\n", client)
+			h.subscribers[client] = true
+
 		case client := <-h.unregister:
 			log.Printf("Socket/run: Un-Registering client: %v\n", client)
-			if _, ok := h.clients[client.clientId]; ok {
+			if client.subscriber {
+				if _, ok := h.subscribers[client]; ok {
+					log.Printf("Socket/run:Remove subscriber: %v\n", client)
+					delete(h.subscribers, client)
+					close(client.send)
+					close(client.status)
+				}
+			} else if _, ok := h.clients[client.key()]; ok {
 				// remove client
 				log.Printf("Socket/run:Remove client: %v\n", client)
-				delete(h.clients, client.clientId)
+				delete(h.clients, client.key())
 				// close client send channel
 				log.Printf("Socket/run:Close client send channel: %v\n", client)
 				close(client.send)
@@ -91,9 +131,43 @@ func (h *Hub) run() {
 					log.Printf("Socket/run:Close client status channel: %v\n", client)
 					close(client.status)
 					log.Printf("Socket/run:Remove client: %v\n", client)
-					delete(h.clients, client.clientId)
+					delete(h.clients, client.key())
+				}
+			}
+		case event := <-h.fenceEvents:
+			log.Printf("Socket/run: Receiving fence event of driver %d\n", event.driverID)
+			for client := range h.subscribers {
+				// subscribers of a driver only receive the events of the driver
+				if client.clientId != 0 && client.clientId != event.driverID {
+					continue
+				}
+
+				select {
+				case client.send <- event.data:
+					log.Printf("Socket/run: Subscriber# %d: Pushing fence event to Send channel\n", client.clientId)
+				default:
+					log.Printf("Socket/run: Failed to push fence event to subscriber send channel: %v\n", client)
+					close(client.send)
+					close(client.status)
+					delete(h.subscribers, client)
 				}
 			}
+		case offer := <-h.offers:
+			log.Printf("Socket/run: Receiving job offer of driver %d of %s\n", offer.key.DriverID, offer.key.Tenant)
+			client, ok := h.clients[offer.key]
+			if !ok {
+				offer.result <- errDriverNotConnected
+				continue
+			}
+
+			select {
+			case client.send <- offer.data:
+				log.Printf("Socket/run: Client# %d: Pushing job offer to Send channel\n", client.clientId)
+				offer.result <- nil
+			default:
+				log.Printf("Socket/run: Failed to push job offer to client send channel: %v\n", client)
+				offer.result <- errDriverNotConnected
+			}
 		}
 	}
 	log.Printf("Socket/run: Hub ended...\n")
@@ -111,6 +185,14 @@ func (h *Hub) startPushStatus(c *Client, pushWait time.Duration) {
 		// TODO send notification to frontend
 		return
 	}
+	// the driver is read from the collection of the tenant of the client
+	locationController, err = locationController.ForTenant(c.fleet)
+	if err != nil {
+		log.Printf("Socket/StartPushStatus: Error: %v\n", err.Error())
+
+		// TODO send notification to frontend
+		return
+	}
 
 	writeTicker := time.NewTicker(pushWait)
 	defer func() {
@@ -143,15 +225,27 @@ func (h *Hub) startPushStatus(c *Client, pushWait time.Duration) {
 				return
 			}
 
+			point := res.Object.Point()
 			packet := createPacket(
+				locationController.Tenant().Name,
 				res.Fields.DriverID,
 				res.Fields.ProviderID,
-				res.Object.Coordinates[1],
-				res.Object.Coordinates[0],
+				point.Lat,
+				point.Lng,
 				res.Fields.Status,
 				res.Fields.LastUpdatedTimestamp,
 				res.Fields.JobID)
-			data, err := proto.Marshal(packet)
+			// the speed of the packet is in meter per second, motion is sent to clients of version 2 only
+			packet.Speed = float32(res.Fields.Speed / 3.6)
+			packet.Bearing = float32(res.Fields.Bearing)
+			packet.Motion = int32(res.Fields.Motion)
+			// clients of version 1 receive the float coordinates of DriverStatusPoll
+			var data []byte
+			if c.version == message.DriverStatusPoll_Version_2 {
+				data, err = proto.Marshal(packet)
+			} else {
+				data, err = proto.Marshal(packet.ToV1())
+			}
 			if err != nil {
 				log.Panicf("Socket/StartPushStatus: Client# %d: marshalling error: %v\n", c.clientId, err)
 			}
@@ -167,7 +261,7 @@ func (h *Hub) startPushStatus(c *Client, pushWait time.Duration) {
 				log.Printf("Socket/StartPushStatus:Close client status channel: %v\n", c)
 				close(c.status)
 				log.Printf("Socket/StartPushStatus:Remove client: %v\n", c)
-				delete(h.clients, c.clientId)
+				delete(h.clients, c.key())
 			}
 		}
 	}
@@ -175,19 +269,21 @@ func (h *Hub) startPushStatus(c *Client, pushWait time.Duration) {
 }
 
 func createPacket(
+	fleet string,
 	driverID int32,
 	providerID int32,
-	lat float32,
-	lng float32,
+	lat float64,
+	lng float64,
 	status location.DriverStatus,
 	timestamp int64,
-	jobID int32) *message.DriverStatusPoll {
+	jobID int32) *message.DriverStatusPollV2 {
 	log.Printf("Socket/createPacket: Client# %d: Creating Status Packet...\n", driverID)
 	// create test packet
-	packet := message.DriverStatusPoll{
-		Fleet:      string(location.Object_Collection_Fleet),
+	packet := message.DriverStatusPollV2{
+		Fleet:      fleet,
 		DriverId:   driverID,
 		ProviderId: providerID,
+		Version:    message.DriverStatusPoll_Version_2,
 		Lat:        lat,
 		Lng:        lng,
 		//Status:     message.DriverStatusPoll_BUSY,
@@ -202,6 +298,18 @@ func createPacket(
 		packet.Status = message.DriverStatusPoll_BUSY
 	case location.DriverStatus_NOTAVAILABLE:
 		packet.Status = message.DriverStatusPoll_NOTAVAILABLE
+	case location.DriverStatus_UNREACHABLE:
+		packet.Status = message.DriverStatusPoll_UNREACHABLE
+	case location.DriverStatus_ON_BREAK:
+		packet.Status = message.DriverStatusPoll_ON_BREAK
+	case location.DriverStatus_OFFERED:
+		packet.Status = message.DriverStatusPoll_OFFERED
+	case location.DriverStatus_EN_ROUTE_TO_PICKUP:
+		packet.Status = message.DriverStatusPoll_EN_ROUTE_TO_PICKUP
+	case location.DriverStatus_ARRIVED_AT_PICKUP:
+		packet.Status = message.DriverStatusPoll_ARRIVED_AT_PICKUP
+	case location.DriverStatus_ON_TRIP:
+		packet.Status = message.DriverStatusPoll_ON_TRIP
 	}
 
 	return &packet
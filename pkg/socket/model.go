@@ -1 +1,58 @@
 package socket
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/iknowhtml/locationtracker/pkg/dispatch"
+	"github.com/iknowhtml/locationtracker/pkg/fence"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+type fenceEventMessage struct {
+	driverID int32
+	data     []byte
+}
+
+// FenceEventSink pushes the fence events to the WebSocket subscribers
+func FenceEventSink() fence.Sink {
+	return fence.SinkFunc(func(event *fence.FenceEventObject) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		hub := startHub()
+		hub.fenceEvents <- &fenceEventMessage{driverID: event.DriverID, data: data}
+		return nil
+	})
+}
+
+var errDriverNotConnected = errors.New("Driver is not connected")
+
+type offerMessage struct {
+	key    location.TenantDriverKey
+	data   []byte
+	result chan error
+}
+
+// OfferSink pushes the job offers to the WebSocket connection of the driver,
+// an error is returned when the driver is not connected
+func OfferSink() dispatch.OfferSender {
+	return dispatch.OfferSenderFunc(func(offer *dispatch.OfferObject) error {
+		data, err := json.Marshal(offer)
+		if err != nil {
+			return err
+		}
+
+		tenant, err := location.GetTenant(offer.Fleet)
+		if err != nil {
+			return err
+		}
+
+		hub := startHub()
+		msg := &offerMessage{key: tenant.DriverKey(offer.DriverID), data: data, result: make(chan error, 1)}
+		hub.offers <- msg
+		return <-msg.result
+	})
+}
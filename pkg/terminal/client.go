@@ -15,12 +15,14 @@ type UDPClient struct {
 	remoteAddr string
 	client     *net.UDPConn
 	wg         *sync.WaitGroup
+	version    int32 // version of the driver status messages sent
 }
 
-func (c *UDPClient) Init(remoteAddr string, wg *sync.WaitGroup) {
+func (c *UDPClient) Init(remoteAddr string, wg *sync.WaitGroup, version int32) {
 	log.Printf("Initializing client...\n")
 	c.remoteAddr = remoteAddr
 	c.wg = wg
+	c.version = version
 	log.Printf("Client initialized...\n")
 }
 
@@ -61,8 +63,13 @@ func (c *UDPClient) WriteToServer(id int32) {
 	log.Printf("%s - Writing data to server: %d\n", c.client.LocalAddr().String(), id)
 	defer c.wg.Done()
 	randJobID := rand.Intn(100)
-	packet := CreatePacket(id, randJobID)
-	data, err := proto.Marshal(packet)
+	var data []byte
+	var err error
+	if c.version == message.DriverStatusPoll_Version_2 {
+		data, err = proto.Marshal(CreatePacketV2(id, randJobID))
+	} else {
+		data, err = proto.Marshal(CreatePacket(id, randJobID))
+	}
 	if err != nil {
 		log.Panicf("marshalling error: ", err)
 	}
@@ -91,6 +98,26 @@ func CreatePacket(driverId int32, randJobID int) *message.DriverStatusPoll {
 	return &packet
 }
 
+func CreatePacketV2(driverId int32, randJobID int) *message.DriverStatusPollV2 {
+	// create test packet
+	packet := message.DriverStatusPollV2{
+		Fleet:      "towing",
+		DriverId:   driverId,
+		ProviderId: 999,
+		Version:    message.DriverStatusPoll_Version_2,
+		Lat:        21.045247,
+		Lng:        105.845268,
+		Accuracy:   5,
+		Speed:      8.3,
+		Bearing:    90,
+		Altitude:   12.5,
+		Status:     message.DriverStatusPoll_BUSY,
+		Timestamp:  time.Now().Unix(),
+		JobId:      int64(randJobID),
+	}
+	return &packet
+}
+
 func (c *UDPClient) Close() error {
 	return c.client.Close()
 }
@@ -10,7 +10,13 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/iknowhtml/locationtracker/pkg/common"
 	"github.com/iknowhtml/locationtracker/pkg/config"
+	"github.com/iknowhtml/locationtracker/pkg/dispatch"
+	"github.com/iknowhtml/locationtracker/pkg/heatmap"
+	"github.com/iknowhtml/locationtracker/pkg/history"
+	"github.com/iknowhtml/locationtracker/pkg/job"
 	"github.com/iknowhtml/locationtracker/pkg/location"
+	"github.com/iknowhtml/locationtracker/pkg/poi"
+	"github.com/iknowhtml/locationtracker/pkg/zone"
 	//keycloak "github.com/mitch-strong/keycloakgo"
 )
 
@@ -23,6 +29,8 @@ type HTTPServer struct {
 	Wg         *sync.WaitGroup
 	Router     *mux.Router
 	Server     *http.Server
+	stop       chan struct{}
+	closeOnce  sync.Once
 }
 
 func (u *HTTPServer) New() *HTTPServer {
@@ -59,6 +67,36 @@ func (u *HTTPServer) New() *HTTPServer {
 		fleetAPI.Methods(r.Method).Path(r.Pattern).Name(r.Name).Handler(r.HandlerFunc)
 	}
 
+	// add Zone route
+	for _, r := range zone.NewRouter() {
+		fleetAPI.Methods(r.Method).Path(r.Pattern).Name(r.Name).Handler(r.HandlerFunc)
+	}
+
+	// add History route
+	for _, r := range history.NewRouter() {
+		fleetAPI.Methods(r.Method).Path(r.Pattern).Name(r.Name).Handler(r.HandlerFunc)
+	}
+
+	// add POI route
+	for _, r := range poi.NewRouter() {
+		fleetAPI.Methods(r.Method).Path(r.Pattern).Name(r.Name).Handler(r.HandlerFunc)
+	}
+
+	// add Job route
+	for _, r := range job.NewRouter() {
+		fleetAPI.Methods(r.Method).Path(r.Pattern).Name(r.Name).Handler(r.HandlerFunc)
+	}
+
+	// add Dispatch route
+	for _, r := range dispatch.NewRouter() {
+		fleetAPI.Methods(r.Method).Path(r.Pattern).Name(r.Name).Handler(r.HandlerFunc)
+	}
+
+	// add Heatmap route
+	for _, r := range heatmap.NewRouter() {
+		fleetAPI.Methods(r.Method).Path(r.Pattern).Name(r.Name).Handler(r.HandlerFunc)
+	}
+
 	// create CORS middleware
 	cors := common.CORSMiddlewareObj{
 		AllowedOrigins:     u.CorsConfig.AllowedOrigins,
@@ -70,6 +108,9 @@ func (u *HTTPServer) New() *HTTPServer {
 		MaxAge:             u.CorsConfig.MaxAge,
 	}
 
+	// closed when the server is closed, to stop the background jobs
+	u.stop = make(chan struct{})
+
 	u.Server = &http.Server{
 		Addr: u.Addr,
 		// Good practice to set timeouts to avoid Slowloris attacks.
@@ -84,9 +125,13 @@ func (u *HTTPServer) New() *HTTPServer {
 	return u
 }
 
-// Process will take the data from channel for processing.
+// Process runs the background jobs of the HTTP server until it is closed.
 func (u *HTTPServer) Process() {
 	log.Printf("Processing data: %s\n", u.Addr)
+
+	// set drivers without update within the heartbeat timeout to unreachable
+	location.RunStaleDriverSweeper(u.stop)
+
 	log.Printf("Finished processing data: %s\n", u.Addr)
 }
 
@@ -110,5 +155,6 @@ func (u *HTTPServer) Run() {
 // Close ensures that the HTTPServer is shut down gracefully.
 func (u *HTTPServer) Close() error {
 	log.Printf("Closing server: %s\n", u.Addr)
+	u.closeOnce.Do(func() { close(u.stop) })
 	return u.Server.Shutdown(context.TODO())
 }
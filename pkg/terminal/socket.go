@@ -8,15 +8,19 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/dispatch"
+	"github.com/iknowhtml/locationtracker/pkg/fence"
 	"github.com/iknowhtml/locationtracker/pkg/socket"
 )
 
 // SocketServer holds the necessary structure for our
 // Socket server.
 type SocketServer struct {
-	Addr   string
-	Wg     *sync.WaitGroup
-	Server *http.Server
+	Addr      string
+	Wg        *sync.WaitGroup
+	Server    *http.Server
+	stop      chan struct{}
+	closeOnce sync.Once
 }
 
 func (s *SocketServer) New() *SocketServer {
@@ -36,6 +40,17 @@ func (s *SocketServer) New() *SocketServer {
 			Handler(r.HandlerFunc)
 	}
 
+	// add Fence route, Tile38 posts the driver hook events to it
+	for _, r := range fence.NewRouter() {
+		router.Methods(r.Method).Path(r.Pattern).Name(r.Name).Handler(r.HandlerFunc)
+	}
+
+	// add Dispatch offer route, the jobs are offered to the drivers connected to this server
+	for _, r := range dispatch.NewOfferRouter() {
+		router.Methods(r.Method).Path(r.Pattern).Name(r.Name).Handler(r.HandlerFunc)
+	}
+	dispatch.SetOfferSender(socket.OfferSink())
+
 	// add Location route
 	socketRouter := router.PathPrefix("/socket/").Subrouter()
 	socketRouter.Use(socket.WebSocketMiddleware)
@@ -47,6 +62,9 @@ func (s *SocketServer) New() *SocketServer {
 		socketRouter.Path(r.Pattern).Name(r.Name).Handler(r.HandlerFunc)
 	}
 
+	// closed when the server is closed, to stop the fence consumer
+	s.stop = make(chan struct{})
+
 	s.Server = &http.Server{
 		Addr: s.Addr,
 		// Good practice to set timeouts to avoid Slowloris attacks.
@@ -61,9 +79,23 @@ func (s *SocketServer) New() *SocketServer {
 	return s
 }
 
-// Process will take the data from channel for processing.
+// Process consumes the fence events and pushes them to the subscribers until the server is closed.
 func (s *SocketServer) Process() {
 	log.Printf("Processing data: %s\n", s.Addr)
+
+	consumer := new(fence.Consumer)
+	err := consumer.Init()
+	if err != nil {
+		log.Printf("Fence consumer failed: %v\n", err)
+		return
+	}
+	consumer.AddSink(socket.FenceEventSink())
+
+	err = consumer.Run(s.stop)
+	if err != nil {
+		log.Printf("Fence consumer failed: %v\n", err)
+	}
+
 	log.Printf("Finished processing data: %s\n", s.Addr)
 }
 
@@ -85,5 +117,6 @@ func (s *SocketServer) Run() {
 // Close ensures that the SocketServer is shut down gracefully.
 func (s *SocketServer) Close() error {
 	log.Printf("Closing server: %s\n", s.Addr)
+	s.closeOnce.Do(func() { close(s.stop) })
 	return s.Server.Shutdown(context.TODO())
 }
@@ -6,7 +6,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/iknowhtml/locationtracker/pkg/location"
 	"github.com/iknowhtml/locationtracker/pkg/message"
 )
@@ -14,10 +13,12 @@ import (
 // UDPServer holds the necessary structure for our
 // UDP server.
 type UDPServer struct {
-	Addr   string
-	Server *net.UDPConn
-	Wg     *sync.WaitGroup
-	Ch     chan message.DriverStatusPoll
+	Addr        string
+	BatchSize   int           // max packets per location update batch
+	BatchWindow time.Duration // max wait before a batch is flushed
+	Server      *net.UDPConn
+	Wg          *sync.WaitGroup
+	Ch          chan message.DriverStatusPollV2
 }
 
 func (u *UDPServer) New() *UDPServer {
@@ -38,12 +39,39 @@ func (u *UDPServer) Init(addr string, wg *sync.WaitGroup, ch chan message.Driver
 */
 
 // Process will take the data from channel for processing.
+// Packets are collected into a batch which is flushed when it reaches the batch size
+// or when the batch window has passed, so the geo store is called once per batch
 func (u *UDPServer) Process() {
 	log.Printf("Processing data: %s\n", u.Addr)
+
+	batchSize := u.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	batchWindow := u.BatchWindow
+	if batchWindow <= 0 {
+		batchWindow = 50 * time.Millisecond
+	}
+
+	batch := make([]message.DriverStatusPollV2, 0, batchSize)
+	ticker := time.NewTicker(batchWindow)
+	defer ticker.Stop()
 	for {
-		processData(<-u.Ch)
+		select {
+		case data := <-u.Ch:
+			batch = append(batch, data)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		processBatch(batch)
+		batch = batch[:0]
 	}
-	log.Printf("Finished processing data: %s\n", u.Addr)
 }
 
 // Run starts the UDP server.
@@ -56,7 +84,7 @@ func (u *UDPServer) Run() {
 	log.Printf("Server stopped: %s\n", u.Addr)
 }
 
-func clientConns(addr string, ch chan message.DriverStatusPoll, server *net.UDPConn) {
+func clientConns(addr string, ch chan message.DriverStatusPollV2, server *net.UDPConn) {
 	log.Printf("%s - Handling client connections...\n", addr)
 	serverAddr, err := net.ResolveUDPAddr("udp", addr)
 	if err != nil {
@@ -70,7 +98,8 @@ func clientConns(addr string, ch chan message.DriverStatusPoll, server *net.UDPC
 	}
 	log.Printf("%s - Listening to UDP port\n", addr)
 
-	buf := make([]byte, 64)
+	// large enough for a DriverStatusPollV2 with the motion of the driver
+	buf := make([]byte, 512)
 	for {
 		log.Printf("%s - Reading UDP packets\n", addr)
 		n, c_addr, err := server.ReadFromUDP(buf)
@@ -84,11 +113,11 @@ func clientConns(addr string, ch chan message.DriverStatusPoll, server *net.UDPC
 	}
 }
 
-func handleConnections(n int, buf []byte, ch chan message.DriverStatusPoll) {
+func handleConnections(n int, buf []byte, ch chan message.DriverStatusPollV2) {
 	log.Printf("Handling data: %d\n", n)
 
-	data := &message.DriverStatusPoll{}
-	err := proto.Unmarshal(buf[0:n], data)
+	// both DriverStatusPoll and DriverStatusPollV2 are accepted
+	data, err := message.UnmarshalDriverStatusPoll(buf[0:n])
 
 	if err != nil {
 		// if there is an decoding data into required DriverStatusPoll format, log it, and wait for next reading
@@ -109,35 +138,59 @@ func handleConnections(n int, buf []byte, ch chan message.DriverStatusPoll) {
 	log.Printf("Finished handling data: %d\n", n)
 }
 
-func processData(data message.DriverStatusPoll) {
-	log.Printf("Processing data: %d at %d\n", data.DriverId, time.Now().Unix())
+func processBatch(batch []message.DriverStatusPollV2) {
+	log.Printf("Processing batch: %d packets at %d\n", len(batch), time.Now().Unix())
 	locController := new(location.LocationController)
-	locController.Init()
-
-	/*
-		// getting status based on data
-		var d location.DriverStatus
-		switch data.Status {
-		// status is Available:
-		case message.DriverStatusPoll_AVAILABLE:
-			d = location.DriverStatus_AVAILABLE
-		case message.DriverStatusPoll_NOTAVAILABLE:
-			d = location.DriverStatus_NOTAVAILABLE
-		case message.DriverStatusPoll_BUSY:
-			d = location.DriverStatus_BUSY
-		// else
-		default:
-			log.Panicf("Unknown driver status")
+	err := locController.Init()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	// the packets are updated by the fleet, the drivers of each tenant are kept in a collection of their own
+	fleets := []string{}
+	packets := map[string][]message.DriverStatusPollV2{}
+	for _, data := range batch {
+		if _, ok := packets[data.Fleet]; !ok {
+			fleets = append(fleets, data.Fleet)
 		}
-	*/
+		packets[data.Fleet] = append(packets[data.Fleet], data)
+	}
 
-	res, err := locController.UpdateDriverLocation(data.DriverId, data.Lat, data.Lng)
+	for _, fleet := range fleets {
+		tenantController, err := locController.ForTenant(fleet)
+		if err != nil {
+			log.Printf("Failed to update %d driver locations of fleet %q: %v\n", len(packets[fleet]), fleet, err)
+			continue
+		}
+		updateDriverLocations(tenantController, packets[fleet])
+	}
+	log.Printf("Successfully processed batch: %d packets at %d\n", len(batch), time.Now().Unix())
+}
+
+func updateDriverLocations(locController *location.LocationController, batch []message.DriverStatusPollV2) {
+	updates := make([]location.DriverLocationUpdateObject, len(batch))
+	for i, data := range batch {
+		updates[i] = location.DriverLocationUpdateObject{
+			DriverID:  data.DriverId,
+			Lat:       data.Lat,
+			Lng:       data.Lng,
+			Timestamp: data.Timestamp,
+			Accuracy:  float64(data.Accuracy),
+		}
+	}
+
+	errs, err := locController.UpdateDriverLocations(updates)
 	if err != nil {
 		log.Println(err)
-	} else {
-		log.Printf("Successfully updated driver status: %d at %d - %v\n", data.DriverId, time.Now().Unix(), res)
+		return
 	}
 
+	for i, err := range errs {
+		if err != nil {
+			log.Printf("Failed to update driver location: %d - %v\n", batch[i].DriverId, err)
+		}
+	}
 }
 
 // Close ensures that the UDPServer is shut down gracefully.
@@ -0,0 +1,41 @@
+package zone
+
+const (
+	HookPrefix string = "HKZONE"
+)
+
+// ZoneListLimit, zones scanned per page when listing the zones
+const (
+	Zone_List_Limit int32 = 1000
+)
+
+// ZoneType
+type Zone_Type int32
+
+const (
+	Zone_Type_General     Zone_Type = 0 // default type
+	Zone_Type_ServiceArea Zone_Type = 1
+	Zone_Type_Depot       Zone_Type = 2
+	Zone_Type_NoGo        Zone_Type = 3
+)
+
+func (t Zone_Type) IsValid() bool {
+	return t >= Zone_Type_General && t <= Zone_Type_NoGo
+}
+
+// ZoneHookDetect is stored as a bit mask in the zone object so the hook can be set again when the zone changes
+type Zone_HookDetect int32
+
+const (
+	Zone_HookDetect_Enter   Zone_HookDetect = 1
+	Zone_HookDetect_Exit    Zone_HookDetect = 2
+	Zone_HookDetect_Inside  Zone_HookDetect = 4
+	Zone_HookDetect_Outside Zone_HookDetect = 8
+)
+
+var hookDetectNames = map[string]Zone_HookDetect{
+	"enter":   Zone_HookDetect_Enter,
+	"exit":    Zone_HookDetect_Exit,
+	"inside":  Zone_HookDetect_Inside,
+	"outside": Zone_HookDetect_Outside,
+}
@@ -0,0 +1,398 @@
+package zone
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/iknowhtml/locationtracker/pkg/config"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+var (
+	ErrZoneNotFound = errors.New("Zone not found")
+	ErrZoneExists   = errors.New("Zone already exists")
+)
+
+var zoneNameRegexp = regexp.MustCompile("^[A-Za-z0-9_-]+$")
+
+type ZoneController struct {
+	locationService    *location.LocationService
+	locationController *location.LocationController
+}
+
+func (zc *ZoneController) Init() error {
+	zc.locationService = new(location.LocationService)
+	err := zc.locationService.Init(nil)
+	if err != nil {
+		return err
+	}
+	zc.locationController = new(location.LocationController)
+	err = zc.locationController.Init()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Create Zone
+// if Zone name already used, return ErrZoneExists
+func (zc *ZoneController) CreateZone(
+	name string,
+	zoneType Zone_Type,
+	geometry json.RawMessage) (*ZoneObject, error) {
+
+	err := checkZoneName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// set only when the zone does not exist, a concurrent create of the same name gets ErrZoneExists
+	return zc.setZone(name, zoneType, 0, geometry, true)
+}
+
+// Update Zone geometry and type
+// if Zone not found, return ErrZoneNotFound
+// if Zone has a hook, the hook is set again with the new geometry
+func (zc *ZoneController) UpdateZone(
+	name string,
+	zoneType Zone_Type,
+	geometry json.RawMessage) (*ZoneObject, error) {
+
+	existObj, err := zc.getZoneObject(name)
+	if err != nil {
+		return nil, err
+	}
+	if !existObj.Ok {
+		return nil, ErrZoneNotFound
+	}
+
+	hookDetect := Zone_HookDetect(existObj.Fields["hookdetect"])
+	zone, err := zc.setZone(name, zoneType, hookDetect, geometry, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if hookDetect != 0 {
+		_, err = zc.setZoneHook(name, geometry, hookDetect)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return zone, nil
+}
+
+func (zc *ZoneController) GetZone(name string) (*ZoneObject, error) {
+
+	existObj, err := zc.getZoneObject(name)
+	if err != nil {
+		return nil, err
+	}
+	if !existObj.Ok {
+		return nil, ErrZoneNotFound
+	}
+
+	zone := &ZoneObject{}
+	return zone.MapFrom(name, existObj.Object, existObj.Fields), nil
+}
+
+// ListZones returns all zones, scanned by pages of Zone_List_Limit zones
+func (zc *ZoneController) ListZones() ([]*ZoneObject, error) {
+
+	zones := []*ZoneObject{}
+	var cursor int32
+	for {
+		res, err := zc.locationService.ScanObject(location.Object_Collection_Zone, Zone_List_Limit, cursor, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// check if ok is false
+		if res.Ok == false {
+			return nil, errors.New(res.Error)
+		}
+
+		for i, o := range res.Objects {
+			zone := &ZoneObject{}
+			zones = append(zones, zone.MapFrom(o.ID, o.Object, res.FieldMap(i)))
+		}
+
+		// the last page has no cursor
+		if res.Cursor == 0 || len(res.Objects) == 0 {
+			break
+		}
+		cursor = res.Cursor
+	}
+
+	return zones, nil
+}
+
+// Delete Zone and its hook
+// if Zone not found, return ErrZoneNotFound
+func (zc *ZoneController) DeleteZone(name string) error {
+
+	existObj, err := zc.getZoneObject(name)
+	if err != nil {
+		return err
+	}
+	if !existObj.Ok {
+		return ErrZoneNotFound
+	}
+
+	if Zone_HookDetect(existObj.Fields["hookdetect"]) != 0 {
+		_, err = zc.delZoneHook(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	res, err := zc.locationService.DelObject(location.Object_Collection_Zone, name)
+	if err != nil {
+		return err
+	}
+
+	// check if ok is false
+	if res.Ok == false {
+		return errors.New(res.Error)
+	}
+
+	log.Printf("Zone deleted: %s\n", name)
+	return nil
+}
+
+// Search drivers of the tenant of the fleet currently inside the Zone
+func (zc *ZoneController) SearchZoneDriver(
+	name string,
+	fleet string,
+	limit int32,
+	providerID int32,
+	search_service_type_id int32,
+	search_service_id int32,
+	search_avail location.NearbySearch_Availability,
+	search_priority location.NearbySearch_Priority,
+	motion *location.SearchMotionObject) (*location.NearbyObjectMapObject, error) {
+
+	existObj, err := zc.getZoneObject(name)
+	if err != nil {
+		return nil, err
+	}
+	if !existObj.Ok {
+		return nil, ErrZoneNotFound
+	}
+
+	locationController, err := zc.locationController.ForTenant(fleet)
+	if err != nil {
+		return nil, err
+	}
+	return locationController.SearchAreaDriver(
+		limit, location.LocationSearch_Type_Within, existObj.Object, providerID, search_service_type_id, search_service_id, search_avail, search_priority, motion)
+}
+
+// Set hook on the Zone to detect drivers entering or exiting
+// if Zone not found, return ErrZoneNotFound
+func (zc *ZoneController) SetZoneHook(name string, detect []string) (*location.HookFenceResponseObject, error) {
+
+	var hookDetect Zone_HookDetect
+	for _, d := range detect {
+		v, ok := hookDetectNames[d]
+		if !ok {
+			return nil, errors.New("Unknown hook detect: " + d)
+		}
+		hookDetect |= v
+	}
+	if hookDetect == 0 {
+		return nil, errors.New("Hook detect is empty")
+	}
+
+	existObj, err := zc.getZoneObject(name)
+	if err != nil {
+		return nil, err
+	}
+	if !existObj.Ok {
+		return nil, ErrZoneNotFound
+	}
+
+	res, err := zc.setZoneHook(name, existObj.Object, hookDetect)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep the detect of the hook with the zone
+	_, err = zc.setZone(name, Zone_Type(existObj.Fields["zonetype"]), hookDetect, existObj.Object, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Delete hook of the Zone
+// if Zone not found, return ErrZoneNotFound
+func (zc *ZoneController) DelZoneHook(name string) (*location.HookFenceResponseObject, error) {
+
+	existObj, err := zc.getZoneObject(name)
+	if err != nil {
+		return nil, err
+	}
+	if !existObj.Ok {
+		return nil, ErrZoneNotFound
+	}
+
+	res, err := zc.delZoneHook(name)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = zc.setZone(name, Zone_Type(existObj.Fields["zonetype"]), 0, existObj.Object, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (zc *ZoneController) getZoneObject(name string) (*location.GetGeoJSONResponseObject, error) {
+	err := checkZoneName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := zc.locationService.GetGeoJSONObject(location.Object_Collection_Zone, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// check if response object is empty
+	if res == nil {
+		return nil, errors.New("response object is empty")
+	}
+
+	return res, nil
+}
+
+func checkZoneName(name string) error {
+	if !zoneNameRegexp.MatchString(name) {
+		return errors.New("Zone name must only contain letters, digits, '_' or '-'")
+	}
+	return nil
+}
+
+// setZone sets the zone, only when it does not exist if create is true
+func (zc *ZoneController) setZone(
+	name string,
+	zoneType Zone_Type,
+	hookDetect Zone_HookDetect,
+	geometry json.RawMessage,
+	create bool) (*ZoneObject, error) {
+
+	if !zoneType.IsValid() {
+		return nil, errors.New("Zone type is invalid")
+	}
+
+	// validate geometry is a Polygon or MultiPolygon
+	_, err := location.ParsePolygons(geometry)
+	if err != nil {
+		return nil, err
+	}
+
+	timeNow := time.Now().Unix()
+	fields := location.LocationObject_Fields{
+		"zonetype":        int32(zoneType),
+		"hookdetect":      int32(hookDetect),
+		"lastupdatedtime": timeNow,
+	}
+
+	var res *location.SetObjectResponseObject
+	if create {
+		res, err = zc.locationService.SetGeoJSONObjectIf(location.Object_Collection_Zone, name, nil, geometry, fields)
+	} else {
+		res, err = zc.locationService.SetGeoJSONObject(location.Object_Collection_Zone, name, geometry, fields)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// check if ok is false
+	if res.Ok == false {
+		if res.Error == location.GeoStore_Error_Conflict {
+			return nil, ErrZoneExists
+		}
+		return nil, errors.New(res.Error)
+	}
+
+	log.Printf("Zone updated: %s\n", name)
+	return &ZoneObject{
+		Name:                 name,
+		ZoneType:             zoneType,
+		HookDetect:           hookDetect.Names(),
+		Geometry:             geometry,
+		LastUpdatedTimestamp: timeNow,
+	}, nil
+}
+
+func (zc *ZoneController) setZoneHook(name string, geometry json.RawMessage, hookDetect Zone_HookDetect) (*location.HookFenceResponseObject, error) {
+
+	// load system configuration based on environment, singleton pattern
+	configuration, err := config.GetInstance("")
+	if configuration == nil {
+		return nil, err
+	}
+
+	// Detect List
+	detectList := map[string]string{}
+	for _, d := range hookDetect.Names() {
+		detectList[d] = d
+	}
+
+	// Command List
+	commandList := map[string]string{} // initialize command list
+
+	// Detect all fleet objects entering or exiting the zone, a hook on the collection of each tenant
+	var res *location.HookFenceResponseObject
+	for _, tenant := range location.GetTenants() {
+		tenantRes, err := zc.locationService.SetHookAreaFence(
+			configuration.Locationremoteserver.HookEndpoints, HookPrefix, GenerateZoneHookName(name, tenant),
+			location.LocationSearch_Type_Within, tenant.Collection, "", geometry, detectList, commandList, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// check if ok is false
+		if tenantRes.Ok == false {
+			return nil, errors.New(tenantRes.Error)
+		}
+		if res == nil {
+			res = tenantRes
+		}
+	}
+
+	log.Printf("Zone hook set: %s %v\n", name, detectList)
+	return res, nil
+}
+
+// delZoneHook deletes the hooks of the zone on the collection of each tenant
+func (zc *ZoneController) delZoneHook(name string) (*location.HookFenceResponseObject, error) {
+	var res *location.HookFenceResponseObject
+	for _, tenant := range location.GetTenants() {
+		tenantRes, err := zc.locationService.DelHook(GenerateZoneHookName(name, tenant))
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			res = tenantRes
+		}
+	}
+	return res, nil
+}
+
+// GenerateZoneHookName returns the name of the hook of the zone on the collection of the tenant,
+// the hook on the drivers of the default tenant keeps the name of the zone hook
+func GenerateZoneHookName(name string, tenant *location.Tenant) string {
+	if tenant.ID == 0 {
+		return location.GenerateHookName(HookPrefix, string(location.Object_Collection_Zone), name)
+	}
+	return location.GenerateHookName(HookPrefix, string(location.Object_Collection_Zone), name+":"+tenant.Name)
+}
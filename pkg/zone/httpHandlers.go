@@ -0,0 +1,338 @@
+package zone
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/iknowhtml/locationtracker/pkg/common"
+	"github.com/iknowhtml/locationtracker/pkg/location"
+)
+
+// POST body: { "name": "klcc", "zonetype": [0|1|2|3], "geometry": { GeoJSON Polygon or MultiPolygon } }
+func HandleCreateZone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	// reading POST body
+	log.Println("Decoding request json body")
+	decoder := json.NewDecoder(r.Body)
+	var reqObj ZoneRequestObject
+	err := decoder.Decode(&reqObj)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+	log.Println(reqObj)
+
+	if reqObj.Name == "" {
+		common.HandleStatus400Response(w, "Zone name is missing, but required")
+		return
+	}
+
+	if len(reqObj.Geometry) == 0 {
+		common.HandleStatus400Response(w, "Zone geometry is missing, but required")
+		return
+	}
+
+	if _, err := location.ParsePolygons(reqObj.Geometry); err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	zoneController := new(ZoneController)
+	err = zoneController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := zoneController.CreateZone(reqObj.Name, reqObj.ZoneType, reqObj.Geometry)
+	if err != nil {
+		handleZoneErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &ZoneResultObject{Zone: res})
+}
+
+func HandleListZones(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	zoneController := new(ZoneController)
+	err := zoneController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := zoneController.ListZones()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &ZonesResultObject{Zones: res})
+}
+
+// url: zone name ("name") (required)
+func HandleGetZone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	zoneController := new(ZoneController)
+	err := zoneController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := zoneController.GetZone(vars["name"])
+	if err != nil {
+		handleZoneErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &ZoneResultObject{Zone: res})
+}
+
+// url: zone name ("name") (required)
+// PUT body: { "zonetype": [0|1|2|3], "geometry": { GeoJSON Polygon or MultiPolygon } }
+func HandleUpdateZone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	// reading PUT body
+	log.Println("Decoding request json body")
+	decoder := json.NewDecoder(r.Body)
+	var reqObj ZoneRequestObject
+	err := decoder.Decode(&reqObj)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+	log.Println(reqObj)
+
+	if len(reqObj.Geometry) == 0 {
+		common.HandleStatus400Response(w, "Zone geometry is missing, but required")
+		return
+	}
+
+	if _, err := location.ParsePolygons(reqObj.Geometry); err != nil {
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	zoneController := new(ZoneController)
+	err = zoneController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := zoneController.UpdateZone(vars["name"], reqObj.ZoneType, reqObj.Geometry)
+	if err != nil {
+		handleZoneErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &ZoneResultObject{Zone: res})
+}
+
+// url: zone name ("name") (required)
+func HandleDeleteZone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "DELETE" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	zoneController := new(ZoneController)
+	err := zoneController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	err = zoneController.DeleteZone(vars["name"])
+	if err != nil {
+		handleZoneErrorResponse(w, err)
+		return
+	}
+
+	common.HandleStatusOKResponse(w, &common.EmptyResultObject{})
+}
+
+// url: zone name ("name") (required)
+// Url Param: limit (limit) (optional)
+// fleet, tenant of the drivers (fleet = towing) (optional, the default tenant)
+// providerid (provider) (optional)
+// availability (avail = 1|0|2|4|5|6|7|8) (optional)
+// service type id (srvtype = 0) (optional)
+// service id (srv = 0) (optional)
+// priority (priority = 1|0) (optional)
+// motion (motion = 1|2|3, comma separated) (optional)
+// max speed in km/h (maxspeed) (optional)
+func HandleGetZoneDrivers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	// get Url Param
+	queryValues := r.URL.Query()
+	log.Println(queryValues)
+
+	limit := location.Search_Limit
+	if queryValues.Get("limit") != "" {
+		l, err := strconv.ParseInt(queryValues.Get("limit"), 10, 32)
+		if err != nil || l <= 0 {
+			common.HandleStatus400Response(w, "Limit is invalid")
+			return
+		}
+		limit = int32(l)
+	}
+
+	filter, err := location.ParseSearchFilter(queryValues)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleStatus400Response(w, err.Error())
+		return
+	}
+
+	zoneController := new(ZoneController)
+	err = zoneController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := zoneController.SearchZoneDriver(vars["name"], filter.Fleet, limit, filter.ProviderID, filter.ServiceTypeID, filter.ServiceID, filter.Availability, filter.Priority, &filter.Motion)
+	if err != nil {
+		handleZoneErrorResponse(w, err)
+		return
+	}
+
+	if res != nil && res.Ok {
+		common.HandleStatusOKResponse(w, &ZoneDriversResultObject{ZoneDrivers: res})
+	} else {
+		common.HandleStatus400Response(w, res.Error)
+	}
+}
+
+// url: zone name ("name") (required)
+// POST body: { "detect": ["enter", "exit"] }
+func HandleSetZoneHook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	// reading POST body
+	log.Println("Decoding request json body")
+	decoder := json.NewDecoder(r.Body)
+	var reqObj ZoneHookRequestObject
+	err := decoder.Decode(&reqObj)
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+	log.Println(reqObj)
+
+	if len(reqObj.Detect) == 0 {
+		common.HandleStatus400Response(w, "Hook detect is missing, but required")
+		return
+	}
+
+	zoneController := new(ZoneController)
+	err = zoneController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := zoneController.SetZoneHook(vars["name"], reqObj.Detect)
+	if err != nil {
+		handleZoneErrorResponse(w, err)
+		return
+	}
+
+	if res != nil && res.Ok {
+		common.HandleStatusOKResponse(w, &common.EmptyResultObject{})
+	} else {
+		common.HandleStatus400Response(w, res.Error)
+	}
+}
+
+// url: zone name ("name") (required)
+func HandleDelZoneHook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "DELETE" {
+		common.HandleMethodNotAllowedResponse(w, "")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	zoneController := new(ZoneController)
+	err := zoneController.Init()
+	if err != nil {
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+		return
+	}
+
+	res, err := zoneController.DelZoneHook(vars["name"])
+	if err != nil {
+		handleZoneErrorResponse(w, err)
+		return
+	}
+
+	if res != nil && res.Ok {
+		common.HandleStatusOKResponse(w, &common.EmptyResultObject{})
+	} else {
+		common.HandleStatus400Response(w, res.Error)
+	}
+}
+
+func handleZoneErrorResponse(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrZoneNotFound:
+		common.HandleStatusNotFoundResponse(w, err.Error())
+	case ErrZoneExists:
+		common.HandleStatus400Response(w, err.Error())
+	default:
+		// send a internal server error back to the caller
+		common.HandleServerErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,21 @@
+package zone
+
+import (
+	"github.com/iknowhtml/locationtracker/pkg/common"
+)
+
+func NewRouter() []common.Route {
+
+	zoneRouter := []common.Route{
+		common.Route{"CreateZone", "POST", "/zones", HandleCreateZone},
+		common.Route{"ListZones", "GET", "/zones", HandleListZones},
+		common.Route{"GetZone", "GET", "/zones/{name:[A-Za-z0-9_-]+}", HandleGetZone},
+		common.Route{"UpdateZone", "PUT", "/zones/{name:[A-Za-z0-9_-]+}", HandleUpdateZone},
+		common.Route{"DeleteZone", "DELETE", "/zones/{name:[A-Za-z0-9_-]+}", HandleDeleteZone},
+		common.Route{"GetZoneDrivers", "GET", "/zones/{name:[A-Za-z0-9_-]+}/drivers", HandleGetZoneDrivers},
+		common.Route{"SetZoneHook", "POST", "/zones/{name:[A-Za-z0-9_-]+}/hooks", HandleSetZoneHook},
+		common.Route{"DelZoneHook", "DELETE", "/zones/{name:[A-Za-z0-9_-]+}/hooks", HandleDelZoneHook},
+	}
+
+	return zoneRouter
+}
@@ -0,0 +1,71 @@
+package zone
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+type ZoneRequestObject struct {
+	Name     string          `json:"name,omitempty"`
+	ZoneType Zone_Type       `json:"zonetype"`
+	Geometry json.RawMessage `json:"geometry"`
+}
+
+type ZoneHookRequestObject struct {
+	Detect []string `json:"detect"`
+}
+
+type ZoneObject struct {
+	Name                 string          `json:"name"`
+	ZoneType             Zone_Type       `json:"zonetype"`
+	HookDetect           []string        `json:"hookdetect,omitempty"`
+	Geometry             json.RawMessage `json:"geometry"`
+	LastUpdatedTimestamp int64           `json:"lastupdatedtime"`
+}
+
+// MapFrom maps the zone fields stored with the GeoJSON object
+func (o *ZoneObject) MapFrom(name string, geometry json.RawMessage, fields map[string]float64) *ZoneObject {
+	return &ZoneObject{
+		Name:                 name,
+		ZoneType:             Zone_Type(fields["zonetype"]),
+		HookDetect:           Zone_HookDetect(fields["hookdetect"]).Names(),
+		Geometry:             geometry,
+		LastUpdatedTimestamp: int64(fields["lastupdatedtime"]),
+	}
+}
+
+// Names returns the detect names of the bit mask
+func (d Zone_HookDetect) Names() []string {
+	names := []string{}
+	for n, v := range hookDetectNames {
+		if d&v != 0 {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+type ZoneResultObject struct {
+	Zone interface{} `json:"zone"`
+}
+
+func (o *ZoneResultObject) SetResult(result interface{}) {
+	o.Zone = result
+}
+
+type ZonesResultObject struct {
+	Zones interface{} `json:"zones"`
+}
+
+func (o *ZonesResultObject) SetResult(result interface{}) {
+	o.Zones = result
+}
+
+type ZoneDriversResultObject struct {
+	ZoneDrivers interface{} `json:"zonedrivers"`
+}
+
+func (o *ZoneDriversResultObject) SetResult(result interface{}) {
+	o.ZoneDrivers = result
+}